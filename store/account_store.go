@@ -1,37 +1,158 @@
 package store
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 type AccountStore interface {
-	Close() error
+	AccountStoreContext
 
 	GetUser(id int64) (*User, error)
 	GetUserByName(name string) (*User, error)
 	GetUserByEmail(email string) (*User, error)
 	FindUsers(*UserFilter, int64, int) ([]*User, int64, error)
+	FindUsersPage(f *UserFilter, page Page) (*UserPage, error)
+	SearchUsers(query string, limit int) ([]*User, error)
 	AddUsers(users []*User) ([]*User, error)
 	UpdateUser(user *User) error
 	DeleteUsers(ids []int64) error
+	RestoreUsers(ids []int64) error
+	PurgeUsers(olderThan time.Duration) (int64, error)
+	Authenticate(nameOrEmail string, password string) (*User, error)
+	ChangePassword(userID int64, oldPassword string, newPassword string) error
+	SetPassword(userID int64, newPassword string) error
 
 	GetPrivilege(id int64) (*Privilege, error)
 	GetPrivilegeByName(name string) (*Privilege, error)
 	FindPrivileges(*PrivilegeFilter, int64, int) ([]*Privilege, int64, error)
+	FindPrivilegesPage(f *PrivilegeFilter, page Page) (*PrivilegePage, error)
 	AddPrivileges(privileges []*Privilege) ([]*Privilege, error)
+	UpdatePrivilege(privilege *Privilege) error
 	DeletePrivileges(ids []int64) error
 
 	GetUserPrivileges(userID int64) ([]UserPrivilege, error)
+
+	GetRole(id int64) (*Role, error)
+	GetRoleByName(name string) (*Role, error)
+	FindRoles(*RoleFilter, int64, int) ([]*Role, int64, error)
+	AddRoles(roles []*Role) ([]*Role, error)
+	UpdateRole(role *Role) error
+	DeleteRoles(ids []int64) error
+
+	FindAuditEvents(f *AuditFilter, offset int64, limit int) ([]*AuditEvent, int64, error)
+}
+
+// AccountStoreContext is the context-first variant of AccountStore, every
+// query is bound to ctx so it is cancelled when ctx is done.
+type AccountStoreContext interface {
+	Close() error
+
+	// SchemaVersion returns the version of the last applied schema migration.
+	SchemaVersion(ctx context.Context) (int64, error)
+
+	// GetUserContext returns the user with its privileges, roles and
+	// effective privileges. The getters, FindUsers and SearchUsers hide the
+	// soft deleted users, see UserFilter.WithDeleted.
+	GetUserContext(ctx context.Context, id int64) (*User, error)
+	GetUserByNameContext(ctx context.Context, name string) (*User, error)
+	GetUserByEmailContext(ctx context.Context, email string) (*User, error)
+	FindUsersContext(ctx context.Context, f *UserFilter, offset int64, limit int) ([]*User, int64, error)
+	// FindUsersPageContext returns the page of users matching f after or
+	// before page.Cursor, it neither skips nor repeats rows when rows change
+	// between pages and counts them only when page.Count asks for it.
+	FindUsersPageContext(ctx context.Context, f *UserFilter, page Page) (*UserPage, error)
+	// SearchUsersContext returns up to limit users matching every term of
+	// query, see SearchTerms, most relevant first. A query without terms
+	// matches no user.
+	SearchUsersContext(ctx context.Context, query string, limit int) ([]*User, error)
+	AddUsersContext(ctx context.Context, users []*User) ([]*User, error)
+	// UpdateUserContext updates the name, email and, when not nil, the
+	// privileges and roles of user, its password is only changed by
+	// ChangePassword and SetPassword. When user.Version is set and the stored
+	// user has another version it fails with a *ConflictError, on success
+	// user.Version is the new version.
+	UpdateUserContext(ctx context.Context, user *User) error
+	// DeleteUsersContext soft deletes users, revoking their sessions, their
+	// name and email are free for a new user while they are deleted.
+	DeleteUsersContext(ctx context.Context, ids []int64) error
+	// RestoreUsersContext restores soft deleted users, ErrDuplicate when a
+	// live user took the name or email of one.
+	RestoreUsersContext(ctx context.Context, ids []int64) error
+	// PurgeUsersContext deletes the users soft deleted more than olderThan
+	// ago for good, returning how many, and records a user.purge audit event
+	// for each.
+	PurgeUsersContext(ctx context.Context, olderThan time.Duration) (int64, error)
+	// AuthenticateContext returns the user, with privileges, whose name or
+	// email is nameOrEmail and whose password matches, ErrInvalidCredentials
+	// otherwise. A successful login is recorded as the user last login.
+	AuthenticateContext(ctx context.Context, nameOrEmail string, password string) (*User, error)
+	// ChangePasswordContext sets the password of a user after verifying its
+	// current password, ErrInvalidCredentials when it does not match.
+	ChangePasswordContext(ctx context.Context, userID int64, oldPassword string, newPassword string) error
+	// SetPasswordContext sets the password of a user without verification.
+	SetPasswordContext(ctx context.Context, userID int64, newPassword string) error
+
+	// GetPrivilegeContext returns the privilege with the privileges it
+	// implies, Implies is nil when it implies none.
+	GetPrivilegeContext(ctx context.Context, id int64) (*Privilege, error)
+	GetPrivilegeByNameContext(ctx context.Context, name string) (*Privilege, error)
+	FindPrivilegesContext(ctx context.Context, f *PrivilegeFilter, offset int64, limit int) ([]*Privilege, int64, error)
+	FindPrivilegesPageContext(ctx context.Context, f *PrivilegeFilter, page Page) (*PrivilegePage, error)
+	// AddPrivilegesContext adds privileges with the privileges, looked up by
+	// name among the stored and the added ones, they imply. ErrCycle when a
+	// privilege would imply itself.
+	AddPrivilegesContext(ctx context.Context, privileges []*Privilege) ([]*Privilege, error)
+	// UpdatePrivilegeContext updates the name and description of privilege
+	// and, when not nil, replaces the privileges it implies, ErrCycle when it
+	// would imply itself. Like UpdateUserContext it fails with a
+	// *ConflictError when privilege.Version is stale.
+	UpdatePrivilegeContext(ctx context.Context, privilege *Privilege) error
+	// DeletePrivilegesContext deletes privileges, ErrInUse while a live user
+	// or a role holds one or a privilege not deleted implies one. They are
+	// taken from the soft deleted users.
+	DeletePrivilegesContext(ctx context.Context, ids []int64) error
+
+	// GetUserPrivilegesContext returns the privileges a user holds directly,
+	// through its roles or implied by another privilege it holds, ordered by
+	// id. An unknown or soft deleted user holds none.
+	GetUserPrivilegesContext(ctx context.Context, userID int64) ([]UserPrivilege, error)
+
+	// GetRoleContext returns the role with its privileges.
+	GetRoleContext(ctx context.Context, id int64) (*Role, error)
+	GetRoleByNameContext(ctx context.Context, name string) (*Role, error)
+	FindRolesContext(ctx context.Context, f *RoleFilter, offset int64, limit int) ([]*Role, int64, error)
+	// AddRolesContext adds roles with the privileges, looked up by name, they
+	// list.
+	AddRolesContext(ctx context.Context, roles []*Role) ([]*Role, error)
+	// UpdateRoleContext updates the name and description of role and, when
+	// not nil, replaces its privileges.
+	UpdateRoleContext(ctx context.Context, role *Role) error
+	// DeleteRolesContext deletes roles, ErrInUse while a live user holds one.
+	// They are taken from the soft deleted users.
+	DeleteRolesContext(ctx context.Context, ids []int64) error
+
+	// FindAuditEventsContext returns the audit events matching f, oldest
+	// first. AddUsers, UpdateUser, ChangePassword, SetPassword, DeleteUsers,
+	// RestoreUsers, PurgeUsers, AddPrivileges, UpdatePrivilege,
+	// DeletePrivileges, AddRoles, UpdateRole and DeleteRoles record them,
+	// for the actor of their ctx, see WithActor.
+	FindAuditEventsContext(ctx context.Context, f *AuditFilter, offset int64, limit int) ([]*AuditEvent, int64, error)
 }
 
-var accountStores = map[string]func() AccountStore{}
+var accountStores = map[string]func(Config) (AccountStore, error){}
 
-func AddAccountStore(name string, store func() AccountStore) {
+// AddAccountStore registers the factory of an AccountStore implementation.
+func AddAccountStore(name string, store func(cfg Config) (AccountStore, error)) {
 	accountStores[name] = store
 }
 
-func GetAccountStore(name string) AccountStore {
+// GetAccountStore opens the AccountStore implementation registered as name.
+func GetAccountStore(name string, cfg Config) (AccountStore, error) {
 	v := accountStores[name]
 	if v == nil {
-		panic(fmt.Sprintf("No implementation for '%s'", name))
+		return nil, fmt.Errorf("no implementation for '%s'", name)
 	}
-	return v()
+	return v(cfg)
 }
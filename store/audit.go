@@ -0,0 +1,209 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Actions recorded in the audit log.
+const (
+	AUDIT_USER_ADD         = "user.add"
+	AUDIT_USER_UPDATE      = "user.update"
+	AUDIT_USER_PASSWORD    = "user.password"
+	AUDIT_USER_DELETE      = "user.delete"
+	AUDIT_USER_RESTORE     = "user.restore"
+	AUDIT_USER_PURGE       = "user.purge"
+	AUDIT_PRIVILEGE_ADD    = "privilege.add"
+	AUDIT_PRIVILEGE_UPDATE = "privilege.update"
+	AUDIT_PRIVILEGE_DELETE = "privilege.delete"
+	AUDIT_ROLE_ADD         = "role.add"
+	AUDIT_ROLE_UPDATE      = "role.update"
+	AUDIT_ROLE_DELETE      = "role.delete"
+)
+
+// AuditEvent is an entry of the append-only audit log, a mutation records
+// one per record it changes in its own transaction.
+type AuditEvent struct {
+	CreatedAt time.Time
+	// Changes holds the changed fields of the record, as decoded from json.
+	Changes map[string]AuditChange
+	// ActorID is the user making the change, see WithActor, nil when unknown.
+	ActorID *int64
+	Action  string
+	// Target is the table of the changed record.
+	Target   string
+	TargetID int64
+	ID       *int64
+}
+
+// AuditChange is the value of a field before and after a change, Before is
+// nil for an added record and After for a deleted one.
+type AuditChange struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AuditFilter matches the audit events passing every field filter and, when
+// they are not zero, created from From up to To, both included.
+type AuditFilter struct {
+	From     time.Time
+	To       time.Time
+	Action   FilterString
+	Target   FilterString
+	ActorID  FilterInt64
+	TargetID FilterInt64
+}
+
+// Set sets the filter from the query values actor, action, target, target_id,
+// see FilterInt64 and FilterString, and from and to in RFC 3339.
+func (f *AuditFilter) Set(values map[string][]string) {
+	f.ActorID.Set("actor", values)
+	f.Action.Set("action", values)
+	f.Target.Set("target", values)
+	f.TargetID.Set("target_id", values)
+	if v, ok := first(values, "from"); ok {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			f.From = t
+		}
+	}
+	if v, ok := first(values, "to"); ok {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			f.To = t
+		}
+	}
+}
+
+// CreatedAt returns the From and To range as a filter of the created_at
+// column, in unix milliseconds.
+func (f *AuditFilter) CreatedAt() FilterInt64 {
+	res := FilterInt64{}
+	switch {
+	case !f.From.IsZero() && !f.To.IsZero():
+		res.Between(f.From.UnixMilli(), f.To.UnixMilli())
+	case !f.From.IsZero():
+		res.Gte(f.From.UnixMilli())
+	case !f.To.IsZero():
+		res.Lte(f.To.UnixMilli())
+	}
+	return res
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx recording actorID as the user making the
+// changes audited under it.
+func WithActor(ctx context.Context, actorID int64) context.Context {
+	return context.WithValue(ctx, actorKey{}, actorID)
+}
+
+// Actor returns the actor recorded by WithActor in ctx, nil when there is
+// none.
+func Actor(ctx context.Context) *int64 {
+	if v, ok := ctx.Value(actorKey{}).(int64); ok {
+		return &v
+	}
+	return nil
+}
+
+// NewAuditEvent returns the event of action on the record id of target by
+// the actor of ctx, changed from the fields before to after, nil when no
+// field changed.
+func NewAuditEvent(ctx context.Context, action string, target string, id int64, before, after map[string]interface{}) *AuditEvent {
+	changes := map[string]AuditChange{}
+	for k, v := range before {
+		if !reflect.DeepEqual(v, after[k]) {
+			changes[k] = AuditChange{Before: v, After: after[k]}
+		}
+	}
+	for k, v := range after {
+		if _, ok := before[k]; !ok {
+			changes[k] = AuditChange{After: v}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return &AuditEvent{
+		CreatedAt: time.Now(),
+		Changes:   changes,
+		ActorID:   Actor(ctx),
+		Action:    action,
+		Target:    target,
+		TargetID:  id,
+	}
+}
+
+// UserAuditFields returns the audited fields of user, those not nil, with
+// the names of its privileges and roles sorted. The password is never
+// audited.
+func UserAuditFields(user *User) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if user.Name != nil {
+		fields["name"] = *user.Name
+	}
+	if user.Email != nil {
+		fields["email"] = *user.Email
+	}
+	if user.Privileges != nil {
+		fields["privileges"] = privilegeNames(*user.Privileges)
+	}
+	if user.Roles != nil {
+		names := []string{}
+		for _, r := range *user.Roles {
+			names = append(names, *r.Name)
+		}
+		sort.Strings(names)
+		fields["roles"] = names
+	}
+	return fields
+}
+
+// PrivilegeAuditFields returns the audited fields of privilege, those not
+// nil, with the names of the privileges it implies sorted.
+func PrivilegeAuditFields(privilege *Privilege) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if privilege.Name != nil {
+		fields["name"] = *privilege.Name
+	}
+	if privilege.Description != nil {
+		fields["description"] = *privilege.Description
+	}
+	if privilege.Implies != nil {
+		fields["implies"] = privilegeNames(*privilege.Implies)
+	}
+	return fields
+}
+
+// RoleAuditFields returns the audited fields of role, those not nil, with
+// the names of its privileges sorted.
+func RoleAuditFields(role *Role) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if role.Name != nil {
+		fields["name"] = *role.Name
+	}
+	if role.Description != nil {
+		fields["description"] = *role.Description
+	}
+	if role.Privileges != nil {
+		fields["privileges"] = privilegeNames(*role.Privileges)
+	}
+	return fields
+}
+
+// NewPasswordAuditEvent returns the event of a password change of the user
+// id by the actor of ctx, it records that the password changed, never the
+// password or its hash.
+func NewPasswordAuditEvent(ctx context.Context, id int64) *AuditEvent {
+	return NewAuditEvent(ctx, AUDIT_USER_PASSWORD, "user", id, nil, map[string]interface{}{"password": "changed"})
+}
+
+func privilegeNames(privileges []*Privilege) []string {
+	names := []string{}
+	for _, p := range privileges {
+		names = append(names, *p.Name)
+	}
+	sort.Strings(names)
+	return names
+}
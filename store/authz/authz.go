@@ -0,0 +1,165 @@
+// Package authz checks the permissions of users against the privileges they
+// hold directly, through their roles and by implication.
+//
+// A permission is a privilege name of colon separated segments, usually
+// resource:action:scope as in "user:edit:self" and "user:edit:any". A held
+// privilege grants a permission when each of its segments is "*" or equal to
+// the segment of the permission, a held privilege with fewer segments grants
+// every permission it is a prefix of, "user" and "user:*" grant
+// "user:edit:any". Plain names like "Admin" are one segment permissions.
+package authz
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+const (
+	// ScopeSelf is the scope of a permission on the records of the user itself.
+	ScopeSelf = "self"
+	// ScopeAny is the scope of a permission on the records of any user.
+	ScopeAny = "any"
+)
+
+// Checker checks permissions with the privileges of users resolved by the
+// wrapped store and cached per user.
+//
+// Changes made through the Checker invalidate the cache of the users they
+// affect, changes made by another handle of the database need Invalidate or
+// InvalidateAll.
+type Checker struct {
+	store.AccountStore
+	// cache holds the sorted names of the privileges of each user
+	cache map[int64][]string
+	mutex sync.RWMutex
+	// generation is incremented by every invalidation, privileges resolved
+	// across one are not cached
+	generation uint64
+}
+
+// New returns a Checker resolving privileges with s.
+func New(s store.AccountStore) *Checker {
+	return &Checker{AccountStore: s, cache: map[int64][]string{}}
+}
+
+// Match reports whether the held privilege grants permission, see the
+// package documentation.
+func Match(held string, permission string) bool {
+	hs := strings.Split(held, ":")
+	ps := strings.Split(permission, ":")
+	if len(hs) > len(ps) {
+		return false
+	}
+	for i, h := range hs {
+		if h != "*" && h != ps[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Privileges returns the names of the privileges user holds, sorted. A user
+// without id holds none.
+func (c *Checker) Privileges(ctx context.Context, user *store.User) ([]string, error) {
+	if user == nil || user.ID == nil {
+		return []string{}, nil
+	}
+	c.mutex.RLock()
+	names, ok := c.cache[*user.ID]
+	generation := c.generation
+	c.mutex.RUnlock()
+	if ok {
+		return names, nil
+	}
+	ups, err := c.AccountStore.GetUserPrivilegesContext(ctx, *user.ID)
+	if err != nil {
+		return nil, err
+	}
+	names = []string{}
+	for _, up := range ups {
+		names = append(names, *up.Name)
+	}
+	sort.Strings(names)
+	c.mutex.Lock()
+	if c.generation == generation {
+		c.cache[*user.ID] = names
+	}
+	c.mutex.Unlock()
+	return names, nil
+}
+
+// HasPrivilege reports whether user holds a privilege granting permission.
+func (c *Checker) HasPrivilege(ctx context.Context, user *store.User, permission string) (bool, error) {
+	return c.HasAny(ctx, user, permission)
+}
+
+// HasAny reports whether user is granted at least one of permissions, false
+// when there are none.
+func (c *Checker) HasAny(ctx context.Context, user *store.User, permissions ...string) (bool, error) {
+	names, err := c.Privileges(ctx, user)
+	if err != nil {
+		return false, err
+	}
+	for _, permission := range permissions {
+		if granted(names, permission) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HasAll reports whether user is granted every one of permissions, true when
+// there are none.
+func (c *Checker) HasAll(ctx context.Context, user *store.User, permissions ...string) (bool, error) {
+	names, err := c.Privileges(ctx, user)
+	if err != nil {
+		return false, err
+	}
+	for _, permission := range permissions {
+		if !granted(names, permission) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// HasPrivilegeOn reports whether user is granted permission on a record of
+// the user ownerID, permission with ScopeAny or, when user is the owner,
+// with ScopeSelf, "user:edit" is checked as "user:edit:any" or
+// "user:edit:self".
+func (c *Checker) HasPrivilegeOn(ctx context.Context, user *store.User, permission string, ownerID int64) (bool, error) {
+	permissions := []string{permission + ":" + ScopeAny}
+	if user != nil && user.ID != nil && *user.ID == ownerID {
+		permissions = append(permissions, permission+":"+ScopeSelf)
+	}
+	return c.HasAny(ctx, user, permissions...)
+}
+
+// Invalidate drops the cached privileges of a user.
+func (c *Checker) Invalidate(userID int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.generation++
+	delete(c.cache, userID)
+}
+
+// InvalidateAll drops the cached privileges of every user.
+func (c *Checker) InvalidateAll() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.generation++
+	c.cache = map[int64][]string{}
+}
+
+func granted(names []string, permission string) bool {
+	for _, name := range names {
+		if Match(name, permission) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package authz_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/senomas/gohtmx/store/authz"
+	_ "github.com/senomas/gohtmx/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	for _, tc := range []struct {
+		held       string
+		permission string
+		expected   bool
+	}{
+		{held: "Admin", permission: "Admin", expected: true},
+		{held: "Admin", permission: "admin", expected: false},
+		{held: "user:edit:self", permission: "user:edit:self", expected: true},
+		{held: "user:edit:self", permission: "user:edit:any", expected: false},
+		{held: "user:edit:*", permission: "user:edit:any", expected: true},
+		{held: "user:*:any", permission: "user:delete:any", expected: true},
+		{held: "user:*:any", permission: "user:delete:self", expected: false},
+		{held: "user", permission: "user:edit:any", expected: true},
+		{held: "*", permission: "privilege:delete", expected: true},
+		{held: "user:edit:any", permission: "user:edit", expected: false},
+		{held: "user:edit", permission: "user:view:any", expected: false},
+	} {
+		assert.Equal(t, tc.expected, authz.Match(tc.held, tc.permission), "%s grants %s", tc.held, tc.permission)
+	}
+}
+
+func TestChecker(t *testing.T) {
+	ctx := context.Background()
+	accountStore, err := store.GetAccountStore("memory", store.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer accountStore.Close()
+	checker := authz.New(accountStore)
+
+	_, err = checker.AddPrivileges([]*store.Privilege{
+		(&store.Privilege{}).SetName("user:edit:self").SetDescription("Edit own account"),
+		(&store.Privilege{}).SetName("user:*:any").SetDescription("Manage accounts").
+			AddImplies((&store.Privilege{}).SetName("user:edit:self")),
+		(&store.Privilege{}).SetName("report:view").SetDescription("View reports"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := checker.AddUsers([]*store.User{
+		(&store.User{}).SetName("Administrator").SetEmail("admin@cool.com").SetPassword("admin").
+			AddPrivilege((&store.Privilege{}).SetName("user:*:any")),
+		(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1").
+			AddPrivilege((&store.Privilege{}).SetName("user:edit:self")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, user1 := users[0], users[1]
+
+	t.Run("has privilege", func(t *testing.T) {
+		ok, err := checker.HasPrivilege(ctx, admin, "user:delete:any")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = checker.HasPrivilege(ctx, admin, "user:edit:self")
+		assert.NoError(t, err)
+		assert.True(t, ok, "implied")
+		ok, err = checker.HasPrivilege(ctx, user1, "user:delete:any")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = checker.HasPrivilege(ctx, &store.User{}, "user:edit:self")
+		assert.NoError(t, err)
+		assert.False(t, ok, "user without id")
+	})
+
+	t.Run("has any and all", func(t *testing.T) {
+		ok, err := checker.HasAny(ctx, user1, "report:view", "user:edit:self")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = checker.HasAny(ctx, user1)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = checker.HasAll(ctx, user1, "report:view", "user:edit:self")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = checker.HasAll(ctx, admin, "user:edit:any", "user:edit:self")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = checker.HasAll(ctx, user1)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("has privilege on", func(t *testing.T) {
+		ok, err := checker.HasPrivilegeOn(ctx, user1, "user:edit", *user1.ID)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = checker.HasPrivilegeOn(ctx, user1, "user:edit", *admin.ID)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = checker.HasPrivilegeOn(ctx, admin, "user:edit", *user1.ID)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		err := accountStore.UpdateUser((&store.User{}).SetID(*user1.ID).
+			AddPrivilege((&store.Privilege{}).SetName("report:view")))
+		assert.NoError(t, err)
+		ok, err := checker.HasPrivilege(ctx, user1, "report:view")
+		assert.NoError(t, err)
+		assert.False(t, ok, "changed behind the checker")
+		checker.Invalidate(*user1.ID)
+		ok, err = checker.HasPrivilege(ctx, user1, "report:view")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		err = checker.UpdateUser((&store.User{}).SetID(*user1.ID).SetPrivileges([]*store.Privilege{}))
+		assert.NoError(t, err)
+		names, err := checker.Privileges(ctx, user1)
+		assert.NoError(t, err)
+		assert.Empty(t, names, "update user invalidates")
+
+		err = checker.UpdateUser((&store.User{}).SetID(*admin.ID).SetPrivileges([]*store.Privilege{
+			(&store.Privilege{}).SetName("user:*:any"),
+			(&store.Privilege{}).SetName("report:view"),
+		}))
+		assert.NoError(t, err)
+		names, err = checker.Privileges(ctx, admin)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"report:view", "user:*:any", "user:edit:self"}, names)
+
+		err = accountStore.UpdateUser((&store.User{}).SetID(*admin.ID).SetPrivileges([]*store.Privilege{
+			(&store.Privilege{}).SetName("user:*:any"),
+		}))
+		assert.NoError(t, err)
+		p, err := checker.AddPrivileges([]*store.Privilege{
+			(&store.Privilege{}).SetName("report:edit").SetDescription("Edit reports"),
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, checker.DeletePrivileges([]int64{*p[0].ID}))
+		names, err = checker.Privileges(ctx, admin)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user:*:any", "user:edit:self"}, names, "delete privileges invalidates")
+	})
+
+	t.Run("delete roles and purge", func(t *testing.T) {
+		roles, err := checker.AddRoles([]*store.Role{
+			(&store.Role{}).SetName("Reporter").SetDescription("Reporter").
+				AddPrivilege((&store.Privilege{}).SetName("report:view")),
+		})
+		assert.NoError(t, err)
+
+		names, err := checker.Privileges(ctx, user1)
+		assert.NoError(t, err)
+		assert.Empty(t, names)
+		err = accountStore.UpdateUser((&store.User{}).SetID(*user1.ID).
+			AddPrivilege((&store.Privilege{}).SetName("report:view")))
+		assert.NoError(t, err)
+		assert.NoError(t, checker.DeleteRoles([]int64{*roles[0].ID}))
+		names, err = checker.Privileges(ctx, user1)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"report:view"}, names, "delete roles invalidates")
+
+		err = accountStore.UpdateUser((&store.User{}).SetID(*user1.ID).SetPrivileges([]*store.Privilege{}))
+		assert.NoError(t, err)
+		_, err = checker.PurgeUsers(time.Hour)
+		assert.NoError(t, err)
+		names, err = checker.Privileges(ctx, user1)
+		assert.NoError(t, err)
+		assert.Empty(t, names, "purge users invalidates")
+	})
+}
+
+func TestCheckerSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	accountStore, err := store.GetAccountStore("memory", store.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer accountStore.Close()
+	checker := authz.New(accountStore)
+
+	_, err = checker.AddPrivileges([]*store.Privilege{
+		(&store.Privilege{}).SetName("Admin").SetDescription("Administrator"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := checker.AddUsers([]*store.User{
+		(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1").
+			AddPrivilege((&store.Privilege{}).SetName("Admin")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := users[0]
+
+	ok, err := checker.HasPrivilege(ctx, user, "Admin")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, checker.DeleteUsers([]int64{*user.ID}))
+	ok, err = checker.HasPrivilege(ctx, user, "Admin")
+	assert.NoError(t, err)
+	assert.False(t, ok, "a deleted user holds no privilege")
+	assert.NoError(t, checker.RestoreUsers([]int64{*user.ID}))
+	ok, err = checker.HasPrivilege(ctx, user, "Admin")
+	assert.NoError(t, err)
+	assert.True(t, ok, "restore invalidates")
+}
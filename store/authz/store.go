@@ -0,0 +1,115 @@
+package authz
+
+import (
+	"context"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// UpdateUser implements store.AccountStore, invalidating the user.
+func (c *Checker) UpdateUser(user *store.User) error {
+	return c.UpdateUserContext(context.Background(), user)
+}
+
+// UpdateUserContext implements store.AccountStore, invalidating the user.
+func (c *Checker) UpdateUserContext(ctx context.Context, user *store.User) error {
+	err := c.AccountStore.UpdateUserContext(ctx, user)
+	if user.ID != nil {
+		c.Invalidate(*user.ID)
+	}
+	return err
+}
+
+// DeleteUsers implements store.AccountStore, invalidating the users.
+func (c *Checker) DeleteUsers(ids []int64) error {
+	return c.DeleteUsersContext(context.Background(), ids)
+}
+
+// DeleteUsersContext implements store.AccountStore, invalidating the users.
+func (c *Checker) DeleteUsersContext(ctx context.Context, ids []int64) error {
+	err := c.AccountStore.DeleteUsersContext(ctx, ids)
+	for _, id := range ids {
+		c.Invalidate(id)
+	}
+	return err
+}
+
+// RestoreUsers implements store.AccountStore, invalidating the users.
+func (c *Checker) RestoreUsers(ids []int64) error {
+	return c.RestoreUsersContext(context.Background(), ids)
+}
+
+// RestoreUsersContext implements store.AccountStore, invalidating the users.
+func (c *Checker) RestoreUsersContext(ctx context.Context, ids []int64) error {
+	err := c.AccountStore.RestoreUsersContext(ctx, ids)
+	for _, id := range ids {
+		c.Invalidate(id)
+	}
+	return err
+}
+
+// PurgeUsers implements store.AccountStore, invalidating every user as the
+// purged users are not known.
+func (c *Checker) PurgeUsers(olderThan time.Duration) (int64, error) {
+	return c.PurgeUsersContext(context.Background(), olderThan)
+}
+
+// PurgeUsersContext implements store.AccountStore, invalidating every user.
+func (c *Checker) PurgeUsersContext(ctx context.Context, olderThan time.Duration) (int64, error) {
+	count, err := c.AccountStore.PurgeUsersContext(ctx, olderThan)
+	c.InvalidateAll()
+	return count, err
+}
+
+// UpdatePrivilege implements store.AccountStore, invalidating every user as
+// the privileges it implies may change.
+func (c *Checker) UpdatePrivilege(privilege *store.Privilege) error {
+	return c.UpdatePrivilegeContext(context.Background(), privilege)
+}
+
+// UpdatePrivilegeContext implements store.AccountStore, invalidating every
+// user.
+func (c *Checker) UpdatePrivilegeContext(ctx context.Context, privilege *store.Privilege) error {
+	err := c.AccountStore.UpdatePrivilegeContext(ctx, privilege)
+	c.InvalidateAll()
+	return err
+}
+
+// DeletePrivileges implements store.AccountStore, invalidating every user.
+func (c *Checker) DeletePrivileges(ids []int64) error {
+	return c.DeletePrivilegesContext(context.Background(), ids)
+}
+
+// DeletePrivilegesContext implements store.AccountStore, invalidating every
+// user.
+func (c *Checker) DeletePrivilegesContext(ctx context.Context, ids []int64) error {
+	err := c.AccountStore.DeletePrivilegesContext(ctx, ids)
+	c.InvalidateAll()
+	return err
+}
+
+// UpdateRole implements store.AccountStore, invalidating every user as the
+// privileges of the role may change.
+func (c *Checker) UpdateRole(role *store.Role) error {
+	return c.UpdateRoleContext(context.Background(), role)
+}
+
+// UpdateRoleContext implements store.AccountStore, invalidating every user.
+func (c *Checker) UpdateRoleContext(ctx context.Context, role *store.Role) error {
+	err := c.AccountStore.UpdateRoleContext(ctx, role)
+	c.InvalidateAll()
+	return err
+}
+
+// DeleteRoles implements store.AccountStore, invalidating every user.
+func (c *Checker) DeleteRoles(ids []int64) error {
+	return c.DeleteRolesContext(context.Background(), ids)
+}
+
+// DeleteRolesContext implements store.AccountStore, invalidating every user.
+func (c *Checker) DeleteRolesContext(ctx context.Context, ids []int64) error {
+	err := c.AccountStore.DeleteRolesContext(ctx, ids)
+	c.InvalidateAll()
+	return err
+}
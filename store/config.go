@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Config is passed to the account store factories, zero values select the
+// backend defaults.
+type Config struct {
+	// DSN is the driver data source name.
+	DSN string
+	// Migration controls the schema migration run when the store is opened.
+	Migration MigrationConfig
+	// Session holds the lifetime of sessions created by a SessionStore.
+	Session SessionConfig
+	// PasswordPolicy defaults to DefaultPasswordPolicy.
+	PasswordPolicy *PasswordPolicy
+	// PasswordHasher hashes the new passwords of the store, for example an
+	// Argon2idHasher with its own costs, it defaults to the default password
+	// hasher when the store is opened, see SetDefaultPasswordHasher.
+	PasswordHasher PasswordHasher
+	// MaxLimit is the largest limit accepted by the find methods, default 100.
+	MaxLimit        int
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// ConnectTimeout is how long to retry the initial ping of the database.
+	ConnectTimeout time.Duration
+}
+
+type MigrationConfig struct {
+	// Disabled skips the migration, the schema must already be up to date.
+	Disabled bool
+	// Version migrates to this version instead of the latest one.
+	Version int64
+	// LockTimeout overrides Migrator.LockTimeout.
+	LockTimeout time.Duration
+}
+
+// ConfigFromEnv builds a Config from the DB_URL and DB_MAX_LIMIT environment
+// variables.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		DSN: os.Getenv("DB_URL"),
+	}
+	if maxLimit := os.Getenv("DB_MAX_LIMIT"); maxLimit != "" {
+		v, err := strconv.ParseInt(maxLimit, 10, 32)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid DB_MAX_LIMIT '%s': %w", maxLimit, err)
+		}
+		cfg.MaxLimit = int(v)
+	}
+	return cfg, nil
+}
+
+// GetMaxLimit returns MaxLimit or its default.
+func (c Config) GetMaxLimit() int {
+	if c.MaxLimit > 0 {
+		return c.MaxLimit
+	}
+	return 100
+}
+
+// GetPasswordPolicy returns PasswordPolicy or its default.
+func (c Config) GetPasswordPolicy() *PasswordPolicy {
+	if c.PasswordPolicy != nil {
+		return c.PasswordPolicy
+	}
+	return DefaultPasswordPolicy()
+}
+
+// GetPasswordHasher returns PasswordHasher or the default password hasher.
+func (c Config) GetPasswordHasher() PasswordHasher {
+	if c.PasswordHasher != nil {
+		return c.PasswordHasher
+	}
+	return GetDefaultPasswordHasher()
+}
+
+// Validate checks the settings a store can not fix up by a default, the
+// backends call it when the store is opened.
+func (c Config) Validate() error {
+	if h, ok := c.GetPasswordHasher().(PasswordHasherValidator); ok {
+		if err := h.Validate(); err != nil {
+			return fmt.Errorf("error password hasher: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetupPool applies the connection pool settings to db.
+func (c Config) SetupPool(db *sqlx.DB) {
+	if c.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(c.MaxOpenConns)
+	}
+	if c.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(c.MaxIdleConns)
+	}
+	if c.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	}
+	if c.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	}
+}
+
+// Run migrates the schema with m as configured.
+func (c MigrationConfig) Run(ctx context.Context, m *Migrator) error {
+	if c.Disabled {
+		return nil
+	}
+	if c.LockTimeout > 0 {
+		m.LockTimeout = c.LockTimeout
+	}
+	if c.Version > 0 {
+		return m.Migrate(ctx, c.Version)
+	}
+	return m.Up(ctx)
+}
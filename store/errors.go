@@ -0,0 +1,84 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when a record, or a record it refers to, does not exist.
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate matches every *DuplicateError.
+	ErrDuplicate = errors.New("duplicate record")
+	// ErrInUse is returned when a record is still referenced by another record.
+	ErrInUse = errors.New("record in use")
+	// ErrInvalidLimit is returned when a find limit is outside 1..max limit.
+	ErrInvalidLimit = errors.New("invalid limit")
+	// ErrInvalidSort is returned when a find sorts by a field it does not allow.
+	ErrInvalidSort = errors.New("invalid sort")
+	// ErrInvalidCursor is returned for a page cursor that is malformed or was
+	// made for another sort.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrCycle matches every *CycleError.
+	ErrCycle = errors.New("implication cycle")
+	// ErrConflict is returned when a record was changed by another writer, it
+	// matches every *ConflictError.
+	ErrConflict = errors.New("record conflict")
+	// ErrInvalidCredentials is returned when a user is unknown or the password
+	// does not match, the two are not told apart.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrInvalidHash is returned for an encoded password hash of unknown or
+	// malformed format.
+	ErrInvalidHash = errors.New("invalid password hash")
+	// ErrPasswordPolicy matches every *PasswordPolicyError.
+	ErrPasswordPolicy = errors.New("password policy")
+	// ErrSessionExpired is returned for a session past its expiry or idle timeout.
+	ErrSessionExpired = errors.New("session expired")
+)
+
+// DuplicateError is returned when a record violates a unique constraint,
+// errors.Is(err, ErrDuplicate) reports true for it.
+type DuplicateError struct {
+	Value interface{}
+	Table string
+	Field string
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%v %s.%s '%v'", ErrDuplicate, e.Table, e.Field, e.Value)
+}
+
+func (e *DuplicateError) Is(target error) bool {
+	return target == ErrDuplicate
+}
+
+// CycleError is returned when a privilege would imply itself through Path,
+// errors.Is(err, ErrCycle) reports true for it.
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("%v %s", ErrCycle, strings.Join(e.Path, " > "))
+}
+
+func (e *CycleError) Is(target error) bool {
+	return target == ErrCycle
+}
+
+// ConflictError is returned when a record was updated since Version was read,
+// errors.Is(err, ErrConflict) reports true for it.
+type ConflictError struct {
+	Table   string
+	ID      int64
+	Version int64
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%v %s.id %d version %d", ErrConflict, e.Table, e.ID, e.Version)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
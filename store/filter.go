@@ -1,25 +1,84 @@
 package store
 
-import "strconv"
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
 
 const (
 	OP_NOP = iota
 	OP_EQ
 	OP_LIKE
+	OP_NE
+	OP_IN
+	OP_NIN
+	OP_GTE
+	OP_LTE
+	// OP_BETWEEN matches Value up to To, both included.
+	OP_BETWEEN
+	OP_NULL
+	OP_NOT_NULL
+	OP_ANY
+	OP_ALL
+	OP_NONE
+	// OP_EMPTY matches a null or empty string.
+	OP_EMPTY
+	OP_NOT_EMPTY
 )
 
+// FilterInt64 is set from the query values id, id.ne, id.in, id.nin, id.gte,
+// id.lte and id.null, the first one present wins but id.gte and id.lte
+// combine into a range. The in lists are repeated or comma separated values.
 type FilterInt64 struct {
-	Op    int
-	Value int64
+	Values []int64
+	Op     int
+	Value  int64
+	To     int64
 }
 
 func (f *FilterInt64) Set(id string, values map[string][]string) {
-	if v, ok := values[id]; ok {
-		if len(v) > 0 {
-			if vi, err := strconv.ParseInt(v[0], 10, 64); err == nil {
-				f.Eq(vi)
-				return
-			}
+	if v, ok := first(values, id); ok {
+		if vi, err := strconv.ParseInt(v, 10, 64); err == nil {
+			f.Eq(vi)
+			return
+		}
+	}
+	if v, ok := first(values, id+".ne"); ok {
+		if vi, err := strconv.ParseInt(v, 10, 64); err == nil {
+			f.Ne(vi)
+			return
+		}
+	}
+	if v, ok := values[id+".in"]; ok {
+		if vi, err := parseInt64s(v); err == nil {
+			f.In(vi...)
+			return
+		}
+	}
+	if v, ok := values[id+".nin"]; ok {
+		if vi, err := parseInt64s(v); err == nil {
+			f.Nin(vi...)
+			return
+		}
+	}
+	gte, gteErr := int64Value(values, id+".gte")
+	lte, lteErr := int64Value(values, id+".lte")
+	switch {
+	case gteErr == nil && lteErr == nil:
+		f.Between(gte, lte)
+		return
+	case gteErr == nil:
+		f.Gte(gte)
+		return
+	case lteErr == nil:
+		f.Lte(lte)
+		return
+	}
+	if v, ok := first(values, id+".null"); ok {
+		if null, err := strconv.ParseBool(v); err == nil {
+			f.Null(null)
+			return
 		}
 	}
 }
@@ -29,21 +88,85 @@ func (f *FilterInt64) Eq(value int64) {
 	f.Value = value
 }
 
+func (f *FilterInt64) Ne(value int64) {
+	f.Op = OP_NE
+	f.Value = value
+}
+
+func (f *FilterInt64) In(values ...int64) {
+	f.Op = OP_IN
+	f.Values = values
+}
+
+func (f *FilterInt64) Nin(values ...int64) {
+	f.Op = OP_NIN
+	f.Values = values
+}
+
+func (f *FilterInt64) Gte(value int64) {
+	f.Op = OP_GTE
+	f.Value = value
+}
+
+func (f *FilterInt64) Lte(value int64) {
+	f.Op = OP_LTE
+	f.Value = value
+}
+
+func (f *FilterInt64) Between(from int64, to int64) {
+	f.Op = OP_BETWEEN
+	f.Value = from
+	f.To = to
+}
+
+// Null matches a null value, or a non null one when null is false.
+func (f *FilterInt64) Null(null bool) {
+	if null {
+		f.Op = OP_NULL
+	} else {
+		f.Op = OP_NOT_NULL
+	}
+}
+
+// FilterString is set from the query values id, id.ne, id.like, id.in, id.nin,
+// id.null and id.empty, the first one present wins. The in lists are repeated
+// or comma separated values, as in FilterInt64.
 type FilterString struct {
-	Value string
-	Op    int
+	Values []string
+	Value  string
+	Op     int
 }
 
 func (f *FilterString) Set(id string, values map[string][]string) {
-	if v, ok := values[id]; ok {
-		if len(v) > 0 {
-			f.Eq(v[0])
+	if v, ok := first(values, id); ok {
+		f.Eq(v)
+		return
+	}
+	if v, ok := first(values, id+".ne"); ok {
+		f.Ne(v)
+		return
+	}
+	if v, ok := first(values, id+".like"); ok {
+		f.Like(v)
+		return
+	}
+	if v, ok := values[id+".in"]; ok {
+		f.In(parseStrings(v)...)
+		return
+	}
+	if v, ok := values[id+".nin"]; ok {
+		f.Nin(parseStrings(v)...)
+		return
+	}
+	if v, ok := first(values, id+".null"); ok {
+		if null, err := strconv.ParseBool(v); err == nil {
+			f.Null(null)
 			return
 		}
 	}
-	if v, ok := values[id+".like"]; ok {
-		if len(v) > 0 {
-			f.Like(v[0])
+	if v, ok := first(values, id+".empty"); ok {
+		if empty, err := strconv.ParseBool(v); err == nil {
+			f.Empty(empty)
 			return
 		}
 	}
@@ -54,7 +177,158 @@ func (f *FilterString) Eq(value string) {
 	f.Value = value
 }
 
+func (f *FilterString) Ne(value string) {
+	f.Op = OP_NE
+	f.Value = value
+}
+
 func (f *FilterString) Like(value string) {
 	f.Op = OP_LIKE
 	f.Value = value
 }
+
+func (f *FilterString) In(values ...string) {
+	f.Op = OP_IN
+	f.Values = values
+}
+
+func (f *FilterString) Nin(values ...string) {
+	f.Op = OP_NIN
+	f.Values = values
+}
+
+// Null matches a null value, or a non null one when null is false.
+func (f *FilterString) Null(null bool) {
+	if null {
+		f.Op = OP_NULL
+	} else {
+		f.Op = OP_NOT_NULL
+	}
+}
+
+// Empty matches a null or empty value, or any other when empty is false.
+func (f *FilterString) Empty(empty bool) {
+	if empty {
+		f.Op = OP_EMPTY
+	} else {
+		f.Op = OP_NOT_EMPTY
+	}
+}
+
+func first(values map[string][]string, key string) (string, bool) {
+	if v, ok := values[key]; ok && len(v) > 0 {
+		return v[0], true
+	}
+	return "", false
+}
+
+func int64Value(values map[string][]string, key string) (int64, error) {
+	v, ok := first(values, key)
+	if !ok {
+		return 0, strconv.ErrSyntax
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
+
+// parseInt64s parses repeated and comma separated values.
+func parseInt64s(values []string) ([]int64, error) {
+	res := []int64{}
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v == "" {
+				continue
+			}
+			vi, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, vi)
+		}
+	}
+	return res, nil
+}
+
+// parseStrings splits repeated and comma separated values like parseInt64s.
+func parseStrings(values []string) []string {
+	res := []string{}
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				res = append(res, v)
+			}
+		}
+	}
+	return res
+}
+
+// filterQuery holds the query values of the groups nested in a filter.
+type filterQuery struct {
+	Not map[string][]string
+	And []map[string][]string
+	Or  []map[string][]string
+}
+
+// splitFilterQuery returns the query values of the groups nested in values.
+// The key "or.N.key" is key of the N-th Or group, "and.N.key" of the N-th And
+// group and "not.key" of the Not group, groups nest as in
+// "or.0.not.name.like". The key "a|b.op" is an And group matching a.op or
+// b.op, a search box named "name|email.like" looks up both fields.
+func splitFilterQuery(values map[string][]string) filterQuery {
+	q := filterQuery{}
+	and := map[int]map[string][]string{}
+	or := map[int]map[string][]string{}
+	alternatives := []map[string][]string{}
+	for key, v := range values {
+		group, rest, _ := strings.Cut(key, ".")
+		switch group {
+		case "not":
+			if q.Not == nil {
+				q.Not = map[string][]string{}
+			}
+			q.Not[rest] = v
+		case "and", "or":
+			index, rest, ok := strings.Cut(rest, ".")
+			n, err := strconv.Atoi(index)
+			if !ok || err != nil || n < 0 {
+				continue
+			}
+			groups := and
+			if group == "or" {
+				groups = or
+			}
+			if groups[n] == nil {
+				groups[n] = map[string][]string{}
+			}
+			groups[n][rest] = v
+		default:
+			if !strings.Contains(group, "|") {
+				continue
+			}
+			op := ""
+			if rest != "" {
+				op = "." + rest
+			}
+			alternative := map[string][]string{}
+			for i, field := range strings.Split(group, "|") {
+				alternative["or."+strconv.Itoa(i)+"."+field+op] = v
+			}
+			alternatives = append(alternatives, alternative)
+		}
+	}
+	q.And = append(sortedGroups(and), alternatives...)
+	q.Or = sortedGroups(or)
+	return q
+}
+
+func sortedGroups(groups map[int]map[string][]string) []map[string][]string {
+	keys := []int{}
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	res := []map[string][]string{}
+	for _, k := range keys {
+		res = append(res, groups[k])
+	}
+	return res
+}
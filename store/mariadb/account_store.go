@@ -1,45 +1,74 @@
 package mariadb
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"github.com/senomas/gohtmx/store"
 )
 
 type MariadbAccountStore struct {
-	db       *sqlx.DB
-	maxLimit int
+	db             *sqlx.DB
+	migrator       *store.Migrator
+	session        store.SessionConfig
+	passwordPolicy *store.PasswordPolicy
+	passwordHasher store.PasswordHasher
+	maxLimit       int
+	// ftMinTokenSize is the innodb_ft_min_token_size of the server, shorter
+	// search terms are not in the fulltext index
+	ftMinTokenSize int
 }
 
 func init() {
-	store.AddAccountStore("mariadb", func() store.AccountStore {
-		v := MariadbAccountStore{}
-		return v.init()
+	store.AddAccountStore("mariadb", func(cfg store.Config) (store.AccountStore, error) {
+		s, err := Open(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
 	})
 }
 
-var err_duplicate_rx = regexp.MustCompile(`^Error 1062 \((?P<code>\d+)\): Duplicate entry '(?P<value>[^']+)' for key '(?P<field>[^']+)'$`)
+var (
+	err_duplicate_rx   = regexp.MustCompile(`^Error 1062 \((?P<code>\d+)\): Duplicate entry '(?P<value>[^']+)' for key '(?P<field>[^']+)'$`)
+	err_foreign_key_rx = regexp.MustCompile(`^Error (1451|1452) \(\d+\): `)
+)
 
-func (s *MariadbAccountStore) init() store.AccountStore {
-	url := os.Getenv("DB_URL")
+// Open connects to the mariadb database cfg.DSN and migrates its schema.
+func Open(cfg store.Config) (*MariadbAccountStore, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	url := cfg.DSN
 	if url == "" {
 		url = "root:dodol123@tcp(localhost:13306)/test"
 	}
-	db, err := sqlx.Open("mysql", url)
+	mcfg, err := mysql.ParseDSN(url)
 	if err != nil {
-		panic(fmt.Errorf("error opening database [%s]: %v", url, err))
+		return nil, fmt.Errorf("error parsing database url [%s]: %w", url, err)
 	}
+	// report matched instead of changed rows, an UPDATE that keeps the
+	// current values must not look like a missing record
+	mcfg.ClientFoundRows = true
+	db, err := sqlx.Open("mysql", mcfg.FormatDSN())
+	if err != nil {
+		return nil, fmt.Errorf("error opening database [%s]: %w", url, err)
+	}
+	cfg.SetupPool(db)
 
+	connectTimeout := cfg.ConnectTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = 3 * time.Second
+	}
+	deadline := time.Now().Add(connectTimeout)
 	err = db.Ping()
-	for i := 0; err != nil && i < 2; i++ {
+	for err != nil && time.Now().Before(deadline) {
 		if strings.Contains(err.Error(), "bad connection") ||
 			strings.Contains(err.Error(), "connection refused") {
 			time.Sleep(1 * time.Second)
@@ -49,65 +78,48 @@ func (s *MariadbAccountStore) init() store.AccountStore {
 		}
 	}
 	if err != nil {
-		panic(fmt.Errorf("error ping database [%s]: %v", url, err))
-	}
-
-	qry := `CREATE TABLE IF NOT EXISTS user (
-    id INTEGER PRIMARY KEY AUTO_INCREMENT,
-    name TEXT NOT NULL,
-    email TEXT NOT NULL,
-    password TEXT NOT NULL,
-    UNIQUE(name),
-    UNIQUE(email)
-  )`
-	_, err = db.Exec(qry)
-	if err != nil {
-		panic(fmt.Errorf("error creating table: %v\n\n%s", err, qry))
+		db.Close()
+		return nil, fmt.Errorf("error ping database [%s]: %w", url, err)
 	}
 
-	qry = `CREATE TABLE IF NOT EXISTS privilege (
-    id INTEGER PRIMARY KEY AUTO_INCREMENT,
-    name TEXT NOT NULL,
-    description TEXT NOT NULL,
-    UNIQUE(name)
-  )`
-	_, err = db.Exec(qry)
+	var ftMinTokenSize int
+	err = db.Get(&ftMinTokenSize, "SELECT @@innodb_ft_min_token_size")
 	if err != nil {
-		panic(fmt.Errorf("error creating table: %v\n\n%s", err, qry))
+		db.Close()
+		return nil, fmt.Errorf("error select innodb_ft_min_token_size [%s]: %w", url, err)
 	}
 
-	qry = `CREATE TABLE IF NOT EXISTS user_privilege (
-    user INTEGER NOT NULL,
-    privilege INTEGER NOT NULL,
-    UNIQUE(user, privilege),
-    FOREIGN KEY(user) REFERENCES user(id) ON DELETE CASCADE,
-    FOREIGN KEY(privilege) REFERENCES privilege(id)
-  )`
-	_, err = db.Exec(qry)
-	if err != nil {
-		panic(fmt.Errorf("error creating table: %v\n\n%s", err, qry))
-	}
-	ctx := MariadbAccountStore{
-		db: db,
+	s := &MariadbAccountStore{
+		db:             db,
+		migrator:       store.NewMigrator(db, migrations),
+		session:        cfg.Session,
+		passwordPolicy: cfg.GetPasswordPolicy(),
+		passwordHasher: cfg.GetPasswordHasher(),
+		maxLimit:       cfg.GetMaxLimit(),
+		ftMinTokenSize: ftMinTokenSize,
 	}
-
-	maxLimit := os.Getenv("DB_MAX_LIMIT")
-	if maxLimit == "" {
-		ctx.maxLimit = 100
-	} else {
-		v, err := strconv.ParseInt(maxLimit, 10, 32)
-		if err != nil {
-			panic(fmt.Errorf("invalid DB_MAX_LIMIT '%s': %v", maxLimit, err))
-		}
-		ctx.maxLimit = int(v)
+	err = cfg.Migration.Run(context.Background(), s.migrator)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error migrating database [%s]: %w", url, err)
 	}
-	return &ctx
+	return s, nil
 }
 
 func (s *MariadbAccountStore) Close() error {
 	return s.db.Close()
 }
 
+// Migrator returns the schema migrator of the store database.
+func (s *MariadbAccountStore) Migrator() *store.Migrator {
+	return s.migrator
+}
+
+// SchemaVersion implements store.AccountStore.
+func (s *MariadbAccountStore) SchemaVersion(ctx context.Context) (int64, error) {
+	return s.migrator.Version(ctx)
+}
+
 func (s *MariadbAccountStore) ValidLimit(limit int) bool {
 	return limit > 0 && limit <= s.maxLimit
 }
@@ -121,6 +133,24 @@ func (s *MariadbAccountStore) ValueString(v interface{}) string {
 	return str
 }
 
+// uniqueViolation reports the field and value of a duplicate entry error.
+func uniqueViolation(err error) (string, string, bool) {
+	er := err_duplicate_rx.FindStringSubmatch(err.Error())
+	if er == nil {
+		return "", "", false
+	}
+	field := er[err_duplicate_rx.SubexpIndex("field")]
+	if i := strings.LastIndex(field, "."); i >= 0 {
+		field = field[i+1:]
+	}
+	return field, er[err_duplicate_rx.SubexpIndex("value")], true
+}
+
+// foreignKeyViolation reports whether err is a failed foreign key constraint.
+func foreignKeyViolation(err error) bool {
+	return err_foreign_key_rx.MatchString(err.Error())
+}
+
 type filter struct {
 	filters []string
 	args    []interface{}
@@ -132,6 +162,28 @@ func (ctx *filter) Int64(field string, f store.FilterInt64) {
 	case store.OP_EQ:
 		ctx.filters = append(ctx.filters, field+" = ?")
 		ctx.args = append(ctx.args, f.Value)
+	case store.OP_NE:
+		ctx.filters = append(ctx.filters, field+" <> ?")
+		ctx.args = append(ctx.args, f.Value)
+	case store.OP_IN, store.OP_NIN:
+		values := []interface{}{}
+		for _, v := range f.Values {
+			values = append(values, v)
+		}
+		ctx.in(field, f.Op == store.OP_NIN, values)
+	case store.OP_GTE:
+		ctx.filters = append(ctx.filters, field+" >= ?")
+		ctx.args = append(ctx.args, f.Value)
+	case store.OP_LTE:
+		ctx.filters = append(ctx.filters, field+" <= ?")
+		ctx.args = append(ctx.args, f.Value)
+	case store.OP_BETWEEN:
+		ctx.filters = append(ctx.filters, field+" BETWEEN ? AND ?")
+		ctx.args = append(ctx.args, f.Value, f.To)
+	case store.OP_NULL:
+		ctx.filters = append(ctx.filters, field+" IS NULL")
+	case store.OP_NOT_NULL:
+		ctx.filters = append(ctx.filters, field+" IS NOT NULL")
 	default:
 		panic(fmt.Errorf("invalid op %s: %+v", field, f))
 	}
@@ -143,17 +195,149 @@ func (ctx *filter) String(field string, f store.FilterString) {
 	case store.OP_EQ:
 		ctx.filters = append(ctx.filters, field+" = ?")
 		ctx.args = append(ctx.args, f.Value)
+	case store.OP_NE:
+		ctx.filters = append(ctx.filters, field+" <> ?")
+		ctx.args = append(ctx.args, f.Value)
 	case store.OP_LIKE:
 		ctx.filters = append(ctx.filters, field+" like ?")
 		ctx.args = append(ctx.args, f.Value)
+	case store.OP_IN, store.OP_NIN:
+		values := []interface{}{}
+		for _, v := range f.Values {
+			values = append(values, v)
+		}
+		ctx.in(field, f.Op == store.OP_NIN, values)
+	case store.OP_NULL:
+		ctx.filters = append(ctx.filters, field+" IS NULL")
+	case store.OP_NOT_NULL:
+		ctx.filters = append(ctx.filters, field+" IS NOT NULL")
+	case store.OP_EMPTY:
+		ctx.filters = append(ctx.filters, "("+field+" IS NULL OR "+field+" = '')")
+	case store.OP_NOT_EMPTY:
+		ctx.filters = append(ctx.filters, "("+field+" IS NOT NULL AND "+field+" <> '')")
 	default:
 		panic(fmt.Errorf("invalid op %s: %+v", field, f))
 	}
 }
 
+// in adds field IN (values), or NOT IN when not is set. An empty list, a
+// syntax error in sql, matches nothing, or everything when negated.
+func (ctx *filter) in(field string, not bool, values []interface{}) {
+	if len(values) == 0 {
+		if !not {
+			ctx.filters = append(ctx.filters, "1 = 0")
+		}
+		return
+	}
+	op := " IN ("
+	if not {
+		op = " NOT IN ("
+	}
+	ctx.filters = append(ctx.filters, field+op+strings.TrimSuffix(strings.Repeat("?,", len(values)), ",")+")")
+	ctx.args = append(ctx.args, values...)
+}
+
+// group returns the conditions add appends, joined with AND, an empty group
+// is always true.
+func (ctx *filter) group(add func(where *filter)) string {
+	sub := filter{args: ctx.args}
+	add(&sub)
+	ctx.args = sub.args
+	if len(sub.filters) == 0 {
+		return "1 = 1"
+	}
+	return "(" + strings.Join(sub.filters, " AND ") + ")"
+}
+
+// Or adds the groups joined with OR, no groups adds no condition.
+func (ctx *filter) Or(groups ...func(where *filter)) {
+	if len(groups) == 0 {
+		return
+	}
+	ors := []string{}
+	for _, g := range groups {
+		ors = append(ors, ctx.group(g))
+	}
+	ctx.filters = append(ctx.filters, "("+strings.Join(ors, " OR ")+")")
+}
+
+// Not adds the negated group.
+func (ctx *filter) Not(group func(where *filter)) {
+	ctx.filters = append(ctx.filters, "NOT "+ctx.group(group))
+}
+
+// Keyset adds the rows after cursor in sort order, or before it when cursor
+// is Prev, sort ends with the unique id.
+func (ctx *filter) Keyset(sort store.Sort, cursor *store.Cursor) {
+	groups := []func(where *filter){}
+	for i, f := range sort {
+		i, f := i, f
+		groups = append(groups, func(where *filter) {
+			for j := 0; j < i; j++ {
+				where.filters = append(where.filters, sort[j].Field+" = ?")
+				where.args = append(where.args, cursor.Values[j])
+			}
+			op := " > "
+			if f.Desc != cursor.Prev {
+				op = " < "
+			}
+			where.filters = append(where.filters, f.Field+op+"?")
+			where.args = append(where.args, cursor.Values[i])
+		})
+	}
+	ctx.Or(groups...)
+}
+
 func (ctx *filter) AppendWhere(query string) string {
 	if len(ctx.filters) > 0 {
 		return query + " WHERE " + strings.Join(ctx.filters, " AND ")
 	}
 	return query
 }
+
+// orderBy returns the ORDER BY clause of sort, its fields are checked against
+// the entity sort fields by Sort.Resolve and are safe to inline.
+func orderBy(sort store.Sort) string {
+	fields := []string{}
+	for _, f := range sort {
+		if f.Desc {
+			fields = append(fields, f.Field+" DESC")
+		} else {
+			fields = append(fields, f.Field+" ASC")
+		}
+	}
+	return " ORDER BY " + strings.Join(fields, ", ")
+}
+
+// count returns the number of rows of table matching where as asked by
+// count, the estimate is the information_schema row count.
+func (s *MariadbAccountStore) count(ctx context.Context, table string, where *filter, count int) (*int64, bool, error) {
+	if count == store.COUNT_NONE {
+		return nil, false, nil
+	}
+	var total int64
+	if count == store.COUNT_ESTIMATE && len(where.filters) == 0 {
+		total, err := s.estimate(ctx, table)
+		if err != nil {
+			return nil, false, err
+		}
+		return &total, true, nil
+	}
+	qry := where.AppendWhere("SELECT count(id) FROM " + table)
+	err := s.db.GetContext(ctx, &total, qry, where.args...)
+	if err != nil {
+		return nil, false, err
+	}
+	return &total, false, nil
+}
+
+// estimate returns the information_schema row count of table.
+func (s *MariadbAccountStore) estimate(ctx context.Context, table string) (int64, error) {
+	var total int64
+	qry := "SELECT IFNULL(TABLE_ROWS, 0) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?"
+	err := s.db.GetContext(ctx, &total, qry, table)
+	if err != nil {
+		return 0, fmt.Errorf("error estimate %s: %w", table, err)
+	}
+	return total, nil
+}
@@ -0,0 +1,57 @@
+package mariadb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// FindAuditEvents implements store.Store.
+func (s *MariadbAccountStore) FindAuditEvents(
+	f *store.AuditFilter, offset int64, limit int,
+) ([]*store.AuditEvent, int64, error) {
+	return s.FindAuditEventsContext(context.Background(), f, offset, limit)
+}
+
+// FindAuditEventsContext implements store.Store.
+func (s *MariadbAccountStore) FindAuditEventsContext(
+	ctx context.Context, f *store.AuditFilter, offset int64, limit int,
+) ([]*store.AuditEvent, int64, error) {
+	where := filter{}
+	where.Int64("actor", f.ActorID)
+	where.String("action", f.Action)
+	where.String("target", f.Target)
+	where.Int64("target_id", f.TargetID)
+	where.Int64("created_at", f.CreatedAt())
+
+	if !s.ValidLimit(limit) {
+		return nil, 0, fmt.Errorf("%w %d", store.ErrInvalidLimit, limit)
+	}
+
+	qry := "SELECT count(id) FROM audit_log"
+	qry = where.AppendWhere(qry)
+	var total int64
+	err := s.db.GetContext(ctx, &total, qry, where.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows := []auditRow{}
+	qry = "SELECT id, created_at, actor, action, target, target_id, changes FROM audit_log"
+	qry = where.AppendWhere(qry)
+	qry += " ORDER BY id LIMIT ? OFFSET ?"
+	args := append(where.args, limit, offset)
+	err = s.db.SelectContext(ctx, &rows, qry, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	events := []*store.AuditEvent{}
+	for i := range rows {
+		e, err := rows[i].event()
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, e)
+	}
+	return events, total, nil
+}
@@ -0,0 +1,226 @@
+package mariadb
+
+import "github.com/senomas/gohtmx/store"
+
+// migrations are written to be run again, mariadb commits every DDL statement
+// on its own and a failed migration leaves the statements before it applied.
+var migrations = []store.Migration{
+	{
+		Version: 1,
+		Name:    "create user and privilege",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS user (
+    id INTEGER PRIMARY KEY AUTO_INCREMENT,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    password TEXT NOT NULL,
+    UNIQUE(name),
+    UNIQUE(email)
+  )`,
+			`CREATE TABLE IF NOT EXISTS privilege (
+    id INTEGER PRIMARY KEY AUTO_INCREMENT,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    UNIQUE(name)
+  )`,
+			`CREATE TABLE IF NOT EXISTS user_privilege (
+    user INTEGER NOT NULL,
+    privilege INTEGER NOT NULL,
+    UNIQUE(user, privilege),
+    FOREIGN KEY(user) REFERENCES user(id) ON DELETE CASCADE,
+    FOREIGN KEY(privilege) REFERENCES privilege(id)
+  )`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS user_privilege`,
+			`DROP TABLE IF EXISTS privilege`,
+			`DROP TABLE IF EXISTS user`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "create session",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS session (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user INTEGER NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    created_at BIGINT NOT NULL,
+    expires_at BIGINT NOT NULL,
+    last_seen_at BIGINT NOT NULL,
+    idle_timeout BIGINT NOT NULL,
+    ip_address VARCHAR(64) NOT NULL,
+    user_agent TEXT NOT NULL,
+    UNIQUE(token_hash),
+    INDEX session_expires_at (expires_at),
+    FOREIGN KEY(user) REFERENCES user(id) ON DELETE CASCADE
+  )`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS session`,
+		},
+	},
+	{
+		Version: 3,
+		Name:    "add user last_login",
+		Up: []string{
+			`ALTER TABLE user ADD COLUMN IF NOT EXISTS last_login BIGINT NULL`,
+		},
+		Down: []string{
+			`ALTER TABLE user DROP COLUMN IF EXISTS last_login`,
+		},
+	},
+	{
+		Version: 4,
+		Name:    "create password_history",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS password_history (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user INTEGER NOT NULL,
+    password TEXT NOT NULL,
+    created_at BIGINT NOT NULL,
+    FOREIGN KEY(user) REFERENCES user(id) ON DELETE CASCADE
+  )`,
+			`CREATE INDEX IF NOT EXISTS password_history_user ON password_history(user)`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS password_history`,
+		},
+	},
+	{
+		Version: 5,
+		Name:    "create user_search",
+		Up: []string{
+			`ALTER TABLE user ADD FULLTEXT INDEX IF NOT EXISTS user_search (name, email)`,
+			// MATCH needs an index on exactly its columns, the name relevance
+			// is weighted up with it
+			`ALTER TABLE user ADD FULLTEXT INDEX IF NOT EXISTS user_search_name (name)`,
+		},
+		Down: []string{
+			`ALTER TABLE user DROP INDEX IF EXISTS user_search_name`,
+			`ALTER TABLE user DROP INDEX IF EXISTS user_search`,
+		},
+	},
+	{
+		Version: 6,
+		Name:    "create role",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS role (
+    id INTEGER PRIMARY KEY AUTO_INCREMENT,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    UNIQUE(name)
+  )`,
+			`CREATE TABLE IF NOT EXISTS role_privilege (
+    role INTEGER NOT NULL,
+    privilege INTEGER NOT NULL,
+    UNIQUE(role, privilege),
+    FOREIGN KEY(role) REFERENCES role(id) ON DELETE CASCADE,
+    FOREIGN KEY(privilege) REFERENCES privilege(id)
+  )`,
+			`CREATE TABLE IF NOT EXISTS user_role (
+    user INTEGER NOT NULL,
+    role INTEGER NOT NULL,
+    UNIQUE(user, role),
+    FOREIGN KEY(user) REFERENCES user(id) ON DELETE CASCADE,
+    FOREIGN KEY(role) REFERENCES role(id)
+  )`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS user_role`,
+			`DROP TABLE IF EXISTS role_privilege`,
+			`DROP TABLE IF EXISTS role`,
+		},
+	},
+	{
+		Version: 7,
+		Name:    "create privilege_implies",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS privilege_implies (
+    privilege INTEGER NOT NULL,
+    implies INTEGER NOT NULL,
+    UNIQUE(privilege, implies),
+    FOREIGN KEY(privilege) REFERENCES privilege(id) ON DELETE CASCADE,
+    FOREIGN KEY(implies) REFERENCES privilege(id)
+  )`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS privilege_implies`,
+		},
+	},
+	{
+		Version: 8,
+		Name:    "create audit_log",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    created_at BIGINT NOT NULL,
+    actor INTEGER,
+    action VARCHAR(64) NOT NULL,
+    target VARCHAR(64) NOT NULL,
+    target_id INTEGER NOT NULL,
+    changes TEXT NOT NULL,
+    INDEX audit_log_actor (actor),
+    INDEX audit_log_target (target, target_id),
+    INDEX audit_log_created_at (created_at)
+  )`,
+			`CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log FOR EACH ROW
+  SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only'`,
+			`CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log FOR EACH ROW
+  SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only'`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS audit_log`,
+		},
+	},
+	{
+		Version: 9,
+		Name:    "add user deleted_at",
+		// mariadb has no partial index, live_name and live_email are null for
+		// a deleted user and nulls are never duplicates
+		Up: []string{
+			`ALTER TABLE user ADD COLUMN IF NOT EXISTS deleted_at BIGINT NULL`,
+			`ALTER TABLE user
+    ADD COLUMN IF NOT EXISTS live_name TEXT AS (IF(deleted_at IS NULL, name, NULL)) PERSISTENT,
+    ADD COLUMN IF NOT EXISTS live_email TEXT AS (IF(deleted_at IS NULL, email, NULL)) PERSISTENT,
+    DROP INDEX IF EXISTS name,
+    DROP INDEX IF EXISTS email,
+    ADD UNIQUE INDEX name (live_name),
+    ADD UNIQUE INDEX email (live_email)`,
+		},
+		Down: []string{
+			`DELETE FROM user WHERE deleted_at IS NOT NULL`,
+			`ALTER TABLE user
+    DROP INDEX IF EXISTS name,
+    DROP INDEX IF EXISTS email,
+    DROP COLUMN IF EXISTS live_name,
+    DROP COLUMN IF EXISTS live_email,
+    ADD UNIQUE INDEX name (name),
+    ADD UNIQUE INDEX email (email)`,
+			`ALTER TABLE user DROP COLUMN IF EXISTS deleted_at`,
+		},
+	},
+	{
+		Version: 10,
+		Name:    "add user and privilege version",
+		Up: []string{
+			`ALTER TABLE user ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1`,
+			`ALTER TABLE privilege ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1`,
+		},
+		Down: []string{
+			`ALTER TABLE privilege DROP COLUMN IF EXISTS version`,
+			`ALTER TABLE user DROP COLUMN IF EXISTS version`,
+		},
+	},
+	{
+		Version: 11,
+		Name:    "add user deleted_at index",
+		// the live user estimate counts the soft deleted users
+		Up: []string{
+			`CREATE INDEX IF NOT EXISTS user_deleted_at ON user(deleted_at)`,
+		},
+		Down: []string{
+			`DROP INDEX IF EXISTS user_deleted_at ON user`,
+		},
+	},
+}
@@ -1,6 +1,7 @@
 package mariadb
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/senomas/gohtmx/store"
@@ -10,27 +11,56 @@ import (
 func (s *MariadbAccountStore) FindPrivileges(
 	f *store.PrivilegeFilter, offset int64, limit int,
 ) ([]*store.Privilege, int64, error) {
-	ctx := filter{}
-	ctx.Int64("id", f.ID)
-	ctx.String("name", f.Name)
-	ctx.String("description", f.Description)
+	return s.FindPrivilegesContext(context.Background(), f, offset, limit)
+}
+
+// FindPrivilegesContext implements store.Store.
+func (s *MariadbAccountStore) FindPrivilegesContext(
+	ctx context.Context, f *store.PrivilegeFilter, offset int64, limit int,
+) ([]*store.Privilege, int64, error) {
+	where := filter{}
+	privilegeWhere(&where, f)
 
 	if !s.ValidLimit(limit) {
-		return nil, 0, fmt.Errorf("invalid limit %d", limit)
+		return nil, 0, fmt.Errorf("%w %d", store.ErrInvalidLimit, limit)
+	}
+	sort, err := f.Sort.Resolve(store.PrivilegeSortFields)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	qry := "SELECT count(id) FROM privilege"
-	qry = ctx.AppendWhere(qry)
+	qry = where.AppendWhere(qry)
 	var total int64
-	err := s.db.Get(&total, qry, ctx.args...)
+	err = s.db.GetContext(ctx, &total, qry, where.args...)
 	if err != nil {
 		return nil, 0, err
 	}
 	privileges := []*store.Privilege{}
-	qry = "SELECT id, name, description FROM privilege"
-	qry = ctx.AppendWhere(qry)
+	qry = "SELECT id, name, description, version FROM privilege"
+	qry = where.AppendWhere(qry)
+	qry += orderBy(sort)
 	qry += " LIMIT ? OFFSET ?"
-	args := append(ctx.args, limit, offset)
-	err = s.db.Select(&privileges, qry, args...)
+	args := append(where.args, limit, offset)
+	err = s.db.SelectContext(ctx, &privileges, qry, args...)
 	return privileges, total, err
 }
+
+// privilegeWhere adds the conditions of f and its nested groups to where.
+func privilegeWhere(where *filter, f *store.PrivilegeFilter) {
+	where.Int64("id", f.ID)
+	where.String("name", f.Name)
+	where.String("description", f.Description)
+	for _, g := range f.And {
+		privilegeWhere(where, g)
+	}
+	ors := []func(where *filter){}
+	for _, g := range f.Or {
+		g := g
+		ors = append(ors, func(where *filter) { privilegeWhere(where, g) })
+	}
+	where.Or(ors...)
+	if f.Not != nil {
+		where.Not(func(where *filter) { privilegeWhere(where, f.Not) })
+	}
+}
@@ -0,0 +1,59 @@
+package mariadb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// AddRoles implements store.Store.
+func (s *MariadbAccountStore) AddRoles(roles []*store.Role) ([]*store.Role, error) {
+	return s.AddRolesContext(context.Background(), roles)
+}
+
+// AddRolesContext implements store.Store.
+func (s *MariadbAccountStore) AddRolesContext(ctx context.Context, roles []*store.Role) ([]*store.Role, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	ps, err := tx.PrepareNamedContext(ctx, "INSERT INTO role (name, description) VALUES (:name, :description)")
+	if err != nil {
+		return nil, fmt.Errorf("error prepare insert into role: %w", err)
+	}
+	res := []*store.Role{}
+	for _, role := range roles {
+		rs, err := ps.ExecContext(ctx, role)
+		if err != nil {
+			if field, v, ok := uniqueViolation(err); ok {
+				return nil, fmt.Errorf("error insert role%s: %w", s.ValueString(role),
+					&store.DuplicateError{Table: "role", Field: field, Value: v})
+			}
+			return nil, fmt.Errorf("error insert role%s: %w", s.ValueString(role), err)
+		}
+		id, err := rs.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("error insert role%s get id: %w", s.ValueString(role), err)
+		}
+		role.ID = &id
+		if role.Privileges != nil {
+			privileges, err := s.setRolePrivileges(ctx, tx, id, *role.Privileges)
+			if err != nil {
+				return nil, err
+			}
+			role.Privileges = &privileges
+		}
+		after, err := s.auditRole(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.audit(ctx, tx, store.NewAuditEvent(ctx, store.AUDIT_ROLE_ADD, "role", id, nil, after)); err != nil {
+			return nil, err
+		}
+		res = append(res, role)
+	}
+	err = tx.Commit()
+	return res, err
+}
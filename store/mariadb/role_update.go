@@ -0,0 +1,112 @@
+package mariadb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/senomas/gohtmx/store"
+)
+
+// UpdateRole implements store.Store.
+func (s *MariadbAccountStore) UpdateRole(role *store.Role) error {
+	return s.UpdateRoleContext(context.Background(), role)
+}
+
+// UpdateRoleContext implements store.Store.
+func (s *MariadbAccountStore) UpdateRoleContext(ctx context.Context, role *store.Role) error {
+	if role.ID == nil {
+		return fmt.Errorf("error update role%s: %w", s.ValueString(role), store.ErrNotFound)
+	}
+	updates := []string{}
+	args := []interface{}{}
+	if role.Name != nil {
+		updates = append(updates, "name = ?")
+		args = append(args, *role.Name)
+	}
+	if role.Description != nil {
+		updates = append(updates, "description = ?")
+		args = append(args, *role.Description)
+	}
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	before, err := s.auditRole(ctx, tx, *role.ID)
+	if err != nil {
+		return err
+	}
+	var affected int64
+	if len(updates) > 0 {
+		qry := "UPDATE role SET " + strings.Join(updates, ", ") + " WHERE id = ?"
+		args = append(args, *role.ID)
+		rs, err := tx.ExecContext(ctx, qry, args...)
+		if err != nil {
+			if field, v, ok := uniqueViolation(err); ok {
+				return fmt.Errorf("error update role%s: %w", s.ValueString(role),
+					&store.DuplicateError{Table: "role", Field: field, Value: v})
+			}
+			return fmt.Errorf("error update role %s: %w", qry, err)
+		}
+		affected, err = rs.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error update role%s affected: %w", s.ValueString(role), err)
+		}
+	} else {
+		err := tx.GetContext(ctx, &affected, "SELECT count(id) FROM role WHERE id = ?", *role.ID)
+		if err != nil {
+			return fmt.Errorf("error select role.id %v: %w", *role.ID, err)
+		}
+	}
+	if affected != 1 {
+		return fmt.Errorf("error update role%s affected %v: %w", s.ValueString(role), affected, store.ErrNotFound)
+	}
+	if role.Privileges != nil {
+		if _, err := s.setRolePrivileges(ctx, tx, *role.ID, *role.Privileges); err != nil {
+			return err
+		}
+	}
+	after, err := s.auditRole(ctx, tx, *role.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.audit(ctx, tx, store.NewAuditEvent(ctx, store.AUDIT_ROLE_UPDATE, "role", *role.ID, before, after)); err != nil {
+		return err
+	}
+	err = tx.Commit()
+	return err
+}
+
+// setRolePrivileges replaces the privileges of a role with privileges, looked
+// up by name, and returns them.
+func (s *MariadbAccountStore) setRolePrivileges(ctx context.Context, tx *sqlx.Tx, roleID int64, privileges []*store.Privilege) ([]*store.Privilege, error) {
+	_, err := tx.ExecContext(ctx, "DELETE FROM role_privilege WHERE role = ?", roleID)
+	if err != nil {
+		return nil, fmt.Errorf("error delete role_privilege.role %d: %w", roleID, err)
+	}
+	res := []*store.Privilege{}
+	for _, p := range privileges {
+		privilege := store.Privilege{}
+		err := tx.GetContext(ctx, &privilege, "SELECT id, name, description, version FROM privilege WHERE name = ?", p.Name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get privilege name '%s': %w", *p.Name, store.ErrNotFound)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error get privilege name '%s': %w", *p.Name, err)
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO role_privilege (role, privilege) VALUES (?, ?)", roleID, *privilege.ID)
+		if err != nil {
+			if _, _, ok := uniqueViolation(err); ok {
+				return nil, fmt.Errorf("error insert role_privilege(%d, %d): %w", roleID, *privilege.ID,
+					&store.DuplicateError{Table: "role_privilege", Field: "privilege", Value: *p.Name})
+			}
+			return nil, fmt.Errorf("error insert role_privilege(%d, %d): %w", roleID, *privilege.ID, err)
+		}
+		res = append(res, &privilege)
+	}
+	return res, nil
+}
@@ -0,0 +1,69 @@
+package mariadb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+type sessionRow struct {
+	IPAddress   string `db:"ip_address"`
+	UserAgent   string `db:"user_agent"`
+	ID          int64  `db:"id"`
+	User        int64  `db:"user"`
+	CreatedAt   int64  `db:"created_at"`
+	ExpiresAt   int64  `db:"expires_at"`
+	LastSeenAt  int64  `db:"last_seen_at"`
+	IdleTimeout int64  `db:"idle_timeout"`
+}
+
+func (r *sessionRow) session() *store.Session {
+	return &store.Session{
+		ID:          &r.ID,
+		UserID:      &r.User,
+		CreatedAt:   time.UnixMilli(r.CreatedAt),
+		ExpiresAt:   time.UnixMilli(r.ExpiresAt),
+		LastSeenAt:  time.UnixMilli(r.LastSeenAt),
+		IdleTimeout: time.Duration(r.IdleTimeout) * time.Millisecond,
+		IPAddress:   &r.IPAddress,
+		UserAgent:   &r.UserAgent,
+	}
+}
+
+// CreateSession implements store.SessionStore.
+func (s *MariadbAccountStore) CreateSession(ctx context.Context, user *store.User, meta store.SessionMeta) (string, *store.Session, error) {
+	if user == nil || user.ID == nil {
+		return "", nil, fmt.Errorf("error insert session: user without id")
+	}
+	token, hash, err := store.NewSessionToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("error insert session token: %w", err)
+	}
+	now := time.Now().UnixMilli()
+	row := sessionRow{
+		User:        *user.ID,
+		CreatedAt:   now,
+		ExpiresAt:   now + s.session.GetTTL().Milliseconds(),
+		LastSeenAt:  now,
+		IdleTimeout: s.session.GetIdleTimeout().Milliseconds(),
+		IPAddress:   meta.IPAddress,
+		UserAgent:   meta.UserAgent,
+	}
+	rs, err := s.db.ExecContext(ctx, `INSERT INTO session
+    (user, token_hash, created_at, expires_at, last_seen_at, idle_timeout, ip_address, user_agent)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		row.User, hash, row.CreatedAt, row.ExpiresAt, row.LastSeenAt, row.IdleTimeout, row.IPAddress, row.UserAgent)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return "", nil, fmt.Errorf("error insert session user.id %d: %w", *user.ID, store.ErrNotFound)
+		}
+		return "", nil, fmt.Errorf("error insert session user.id %d: %w", *user.ID, err)
+	}
+	row.ID, err = rs.LastInsertId()
+	if err != nil {
+		return "", nil, fmt.Errorf("error insert session user.id %d get id: %w", *user.ID, err)
+	}
+	return token, row.session(), nil
+}
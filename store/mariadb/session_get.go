@@ -0,0 +1,60 @@
+package mariadb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/senomas/gohtmx/store"
+)
+
+func (s *MariadbAccountStore) getSession(ctx context.Context, q sqlx.QueryerContext, token string) (*sessionRow, error) {
+	var row sessionRow
+	err := sqlx.GetContext(ctx, q, &row, `SELECT id, user, created_at, expires_at, last_seen_at, idle_timeout, ip_address, user_agent
+    FROM session WHERE token_hash = ?`, store.SessionTokenHash(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error get session: %w", store.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error get session: %w", err)
+	}
+	if row.session().Expired(time.Now()) {
+		return nil, fmt.Errorf("error get session.id %d: %w", row.ID, store.ErrSessionExpired)
+	}
+	return &row, nil
+}
+
+// GetSession implements store.SessionStore.
+func (s *MariadbAccountStore) GetSession(ctx context.Context, token string) (*store.Session, error) {
+	row, err := s.getSession(ctx, s.db, token)
+	if err != nil {
+		return nil, err
+	}
+	return row.session(), nil
+}
+
+// RefreshSession implements store.SessionStore.
+func (s *MariadbAccountStore) RefreshSession(ctx context.Context, token string) (*store.Session, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	row, err := s.getSession(ctx, tx, token)
+	if err != nil {
+		return nil, err
+	}
+	row.LastSeenAt = time.Now().UnixMilli()
+	_, err = tx.ExecContext(ctx, "UPDATE session SET last_seen_at = ? WHERE id = ?", row.LastSeenAt, row.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error update session.id %d: %w", row.ID, err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return row.session(), nil
+}
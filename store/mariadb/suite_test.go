@@ -0,0 +1,32 @@
+package mariadb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/senomas/gohtmx/store/mariadb"
+	"github.com/senomas/gohtmx/store/storetest"
+)
+
+func TestMariadbAccountStoreSuite(t *testing.T) {
+	dsn := startMariaDB(t)
+	defer stopMariaDB(t)
+
+	storetest.RunAccountStoreSuite(t, func(t *testing.T, cfg store.Config) store.AccountStore {
+		cfg.DSN = dsn
+		accountStore, err := mariadb.Open(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// every group starts from an empty schema
+		ctx := context.Background()
+		if err := accountStore.Migrator().Migrate(ctx, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := accountStore.Migrator().Up(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return accountStore
+	})
+}
@@ -1,59 +1,69 @@
 package mariadb
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/senomas/gohtmx/store"
 )
 
 // AddUsers implements store.store.
 func (s *MariadbAccountStore) AddUsers(users []*store.User) ([]*store.User, error) {
-	tx := s.db.MustBegin()
+	return s.AddUsersContext(context.Background(), users)
+}
+
+// AddUsersContext implements store.store.
+func (s *MariadbAccountStore) AddUsersContext(ctx context.Context, users []*store.User) ([]*store.User, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error begin transaction: %w", err)
+	}
 	defer tx.Rollback()
-	ps, err := tx.PrepareNamed("INSERT INTO user (name, email, password) VALUES (:name, :email, :password)")
+	ps, err := tx.PrepareNamedContext(ctx, "INSERT INTO user (name, email, password) VALUES (:name, :email, :password)")
 	if err != nil {
-		return nil, fmt.Errorf("error prepare insert into user: %v", err)
+		return nil, fmt.Errorf("error prepare insert into user: %w", err)
 	}
-	psp, err := tx.PrepareNamed("INSERT INTO user_privilege (user, privilege) VALUES (:user, :privilege)")
+	psp, err := tx.PrepareNamedContext(ctx, "INSERT INTO user_privilege (user, privilege) VALUES (:user, :privilege)")
 	if err != nil {
-		return nil, fmt.Errorf("error prepare insert into user_privilege: %v", err)
+		return nil, fmt.Errorf("error prepare insert into user_privilege: %w", err)
 	}
 	res := []*store.User{}
+	events := []*store.AuditEvent{}
 	for _, user := range users {
-		rs, err := ps.Exec(user)
+		if password, ok := user.PlainPassword(); ok {
+			if err := s.passwordPolicy.Validate(password); err != nil {
+				return nil, fmt.Errorf("error insert user%s password: %w", s.ValueString(user), err)
+			}
+			hash, err := store.HashPasswordWith(s.passwordHasher, password)
+			if err != nil {
+				return nil, fmt.Errorf("error insert user%s password: %w", s.ValueString(user), err)
+			}
+			user.Password = &hash
+		}
+		rs, err := ps.ExecContext(ctx, user)
 		if err != nil {
-			em := err.Error()
-			if strings.HasPrefix(em, "UNIQUE constraint failed: ") {
-				ks := em[26:]
-				ka := strings.SplitN(ks, ".", 3)
-				var v interface{}
-				if len(ka) == 2 {
-					switch ka[1] {
-					case "name":
-						v = *user.Name
-					case "email":
-						v = *user.Email
-					default:
-						v = s.ValueString(user)
-					}
-				}
-				return nil, fmt.Errorf("error insert user%s: duplicate record %s '%v'", s.ValueString(user), ks, v)
+			if field, v, ok := uniqueViolation(err); ok {
+				return nil, fmt.Errorf("error insert user%s: %w", s.ValueString(user),
+					&store.DuplicateError{Table: "user", Field: field, Value: v})
 			}
-			return nil, fmt.Errorf("error insert user%s: %v", s.ValueString(user), err)
+			return nil, fmt.Errorf("error insert user%s: %w", s.ValueString(user), err)
 		}
 		affected, err := rs.RowsAffected()
 		if err != nil {
-			return res, fmt.Errorf("error insert user%s affected %v: %v", s.ValueString(user), affected, err)
+			return res, fmt.Errorf("error insert user%s affected %v: %w", s.ValueString(user), affected, err)
 		}
 		if affected != 1 {
 			return res, fmt.Errorf("error insert user%s affected %v", s.ValueString(user), affected)
 		}
 		id, err := rs.LastInsertId()
 		if err != nil {
-			return res, fmt.Errorf("error insert user%s get id: %v", s.ValueString(user), err)
+			return res, fmt.Errorf("error insert user%s get id: %w", s.ValueString(user), err)
 		}
 		user.ID = &id
+		version := int64(1)
+		user.Version = &version
 		if user.Privileges != nil {
 			privileges := []*store.Privilege{}
 			type UserPrivilege struct {
@@ -62,18 +72,25 @@ func (s *MariadbAccountStore) AddUsers(users []*store.User) ([]*store.User, erro
 			}
 			for _, p := range *user.Privileges {
 				privilege := store.Privilege{}
-				err := tx.Get(&privilege, "SELECT id, name, description FROM privilege WHERE name = ?", p.Name)
+				err := tx.GetContext(ctx, &privilege, "SELECT id, name, description, version FROM privilege WHERE name = ?", p.Name)
+				if errors.Is(err, sql.ErrNoRows) {
+					return res, fmt.Errorf("error get privilege name '%s': %w", *p.Name, store.ErrNotFound)
+				}
 				if err != nil {
-					return res, fmt.Errorf("error get privilege name '%s': %v", *privilege.Name, err)
+					return res, fmt.Errorf("error get privilege name '%s': %w", *p.Name, err)
 				}
 				up := UserPrivilege{User: *user.ID, Privilege: *privilege.ID}
-				rs, err := psp.Exec(up)
+				rs, err := psp.ExecContext(ctx, up)
 				if err != nil {
-					return nil, fmt.Errorf("error insert user_privilege%s: %v", s.ValueString(up), err)
+					if _, _, ok := uniqueViolation(err); ok {
+						return nil, fmt.Errorf("error insert user_privilege%s: %w", s.ValueString(up),
+							&store.DuplicateError{Table: "user_privilege", Field: "privilege", Value: *p.Name})
+					}
+					return nil, fmt.Errorf("error insert user_privilege%s: %w", s.ValueString(up), err)
 				}
 				affected, err := rs.RowsAffected()
 				if err != nil {
-					return res, fmt.Errorf("error insert user_privilege%s affected %v: %v", s.ValueString(up), affected, err)
+					return res, fmt.Errorf("error insert user_privilege%s affected %v: %w", s.ValueString(up), affected, err)
 				}
 				if affected != 1 {
 					return res, fmt.Errorf("error insert user_privilege%s affected %v", s.ValueString(up), affected)
@@ -82,7 +99,18 @@ func (s *MariadbAccountStore) AddUsers(users []*store.User) ([]*store.User, erro
 			}
 			user.Privileges = &privileges
 		}
+		if user.Roles != nil {
+			roles, err := s.setUserRoles(ctx, tx, id, *user.Roles)
+			if err != nil {
+				return nil, err
+			}
+			user.Roles = &roles
+		}
 		res = append(res, user)
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_USER_ADD, "user", id, nil, store.UserAuditFields(user)))
+	}
+	if err := s.audit(ctx, tx, events...); err != nil {
+		return nil, err
 	}
 	err = tx.Commit()
 	return res, err
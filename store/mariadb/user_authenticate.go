@@ -0,0 +1,75 @@
+package mariadb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// Authenticate implements store.store.
+func (s *MariadbAccountStore) Authenticate(nameOrEmail string, password string) (*store.User, error) {
+	return s.AuthenticateContext(context.Background(), nameOrEmail, password)
+}
+
+// AuthenticateContext implements store.store.
+func (s *MariadbAccountStore) AuthenticateContext(ctx context.Context, nameOrEmail string, password string) (*store.User, error) {
+	// an overlong password is rejected before it is hashed
+	if s.passwordPolicy.CheckLength(password) != nil {
+		return nil, fmt.Errorf("error authenticate '%s': %w", nameOrEmail, store.ErrInvalidCredentials)
+	}
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	var user store.User
+	err = tx.GetContext(ctx, &user, "SELECT id, name, email, password, version FROM user WHERE (name = ? OR email = ?) AND deleted_at IS NULL ORDER BY name = ? DESC LIMIT 1",
+		nameOrEmail, nameOrEmail, nameOrEmail)
+	if errors.Is(err, sql.ErrNoRows) {
+		store.DummyVerifyPassword(s.passwordHasher, password)
+		return nil, fmt.Errorf("error authenticate '%s': %w", nameOrEmail, store.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error authenticate '%s': %w", nameOrEmail, err)
+	}
+	ok, err := store.VerifyPassword(password, *user.Password)
+	if err != nil {
+		return nil, fmt.Errorf("error authenticate user.id %d: %w", *user.ID, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("error authenticate '%s': %w", nameOrEmail, store.ErrInvalidCredentials)
+	}
+	if s.passwordHasher.NeedsRehash(*user.Password) {
+		var hash string
+		hash, err = store.HashPasswordWith(s.passwordHasher, password)
+		if err != nil {
+			return nil, fmt.Errorf("error authenticate user.id %d: %w", *user.ID, err)
+		}
+		user.Password = &hash
+		_, err = tx.ExecContext(ctx, "UPDATE user SET password = ?, last_login = ? WHERE id = ?", user.Password, time.Now().UnixMilli(), user.ID)
+	} else {
+		_, err = tx.ExecContext(ctx, "UPDATE user SET last_login = ? WHERE id = ?", time.Now().UnixMilli(), user.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error update user.id %d last_login: %w", *user.ID, err)
+	}
+	privileges := []*store.Privilege{}
+	err = tx.SelectContext(ctx, &privileges, "SELECT p.id, p.name, p.description, p.version FROM privilege p JOIN user_privilege up ON p.id = up.privilege WHERE up.user = ?", user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error select user.id %d privileges: %w", *user.ID, err)
+	}
+	user.Privileges = &privileges
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	// loaded like GetUser, the caller authorizes by the effective privileges
+	if err := s.userRoles(ctx, &user); err != nil {
+		return nil, err
+	}
+	return &user, s.userEffectivePrivileges(ctx, &user)
+}
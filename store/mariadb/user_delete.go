@@ -1,37 +1,60 @@
 package mariadb
 
 import (
+	"context"
 	"fmt"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
 )
 
 // DeleteUsers implements store.store.
 func (s *MariadbAccountStore) DeleteUsers(ids []int64) error {
-	tx := s.db.MustBegin()
+	return s.DeleteUsersContext(context.Background(), ids)
+}
+
+// DeleteUsersContext implements store.store.
+func (s *MariadbAccountStore) DeleteUsersContext(ctx context.Context, ids []int64) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
 	defer tx.Rollback()
-	qry := "DELETE FROM user WHERE id IN ("
-	args := []interface{}{}
+	events := []*store.AuditEvent{}
+	for _, id := range ids {
+		before, err := s.auditUser(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_USER_DELETE, "user", id, before, nil))
+	}
+	in := ""
+	args := []interface{}{time.Now().UnixMilli()}
 	for i, id := range ids {
 		if i > 0 {
-			qry += ","
+			in += ","
 		}
-		qry += "?"
+		in += "?"
 		args = append(args, id)
 	}
-	qry += ")"
-	rs, err := tx.Exec(qry, args...)
+	// the privileges and roles are kept for RestoreUsers
+	rs, err := tx.ExecContext(ctx, "UPDATE user SET deleted_at = ? WHERE id IN ("+in+") AND deleted_at IS NULL", args...)
 	if err != nil {
-		em := err.Error()
-		if em == "FOREIGN KEY constraint failed" {
-			return fmt.Errorf("error delete user.id%s: record in use", s.ValueString(ids))
-		}
-		return fmt.Errorf("error delete user.id%s: %v", s.ValueString(ids), err)
+		return fmt.Errorf("error delete user.id%s: %w", s.ValueString(ids), err)
 	}
 	affected, err := rs.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error delete user.id%s affected: %v", s.ValueString(ids), err)
+		return fmt.Errorf("error delete user.id%s affected: %w", s.ValueString(ids), err)
 	}
 	if affected != int64(len(ids)) {
-		return fmt.Errorf("error delete user.id%s affected %v", s.ValueString(ids), affected)
+		return fmt.Errorf("error delete user.id%s affected %v: %w", s.ValueString(ids), affected, store.ErrNotFound)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM session WHERE user IN ("+in+")", args[1:]...)
+	if err != nil {
+		return fmt.Errorf("error delete session.user%s: %w", s.ValueString(ids), err)
+	}
+	if err := s.audit(ctx, tx, events...); err != nil {
+		return err
 	}
 	err = tx.Commit()
 	return err
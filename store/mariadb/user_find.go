@@ -1,34 +1,159 @@
 package mariadb
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/senomas/gohtmx/store"
 )
 
 // FindUsers implements store.store.
 func (s *MariadbAccountStore) FindUsers(f *store.UserFilter, offset int64, limit int) ([]*store.User, int64, error) {
-	ctx := filter{}
-	ctx.Int64("id", f.ID)
-	ctx.String("name", f.Name)
-	ctx.String("email", f.Email)
+	return s.FindUsersContext(context.Background(), f, offset, limit)
+}
+
+// FindUsersContext implements store.store.
+func (s *MariadbAccountStore) FindUsersContext(ctx context.Context, f *store.UserFilter, offset int64, limit int) ([]*store.User, int64, error) {
+	where := filter{}
+	userWhere(&where, f)
+	liveUserWhere(&where, f)
 
 	if !s.ValidLimit(limit) {
-		return nil, 0, fmt.Errorf("invalid limit %d", limit)
+		return nil, 0, fmt.Errorf("%w %d", store.ErrInvalidLimit, limit)
+	}
+	sort, err := f.Sort.Resolve(store.UserSortFields)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	qry := "SELECT count(id) FROM user"
-	qry = ctx.AppendWhere(qry)
+	qry = where.AppendWhere(qry)
 	var total int64
-	err := s.db.Get(&total, qry, ctx.args...)
+	err = s.db.GetContext(ctx, &total, qry, where.args...)
 	if err != nil {
 		return nil, 0, err
 	}
 	users := []*store.User{}
-	qry = "SELECT id, name, email, password FROM user"
-	qry = ctx.AppendWhere(qry)
+	qry = "SELECT id, name, email, password, deleted_at, version FROM user"
+	qry = where.AppendWhere(qry)
+	qry += orderBy(sort)
 	qry += " LIMIT ? OFFSET ?"
-	args := append(ctx.args, limit, offset)
-	err = s.db.Select(&users, qry, args...)
+	args := append(where.args, limit, offset)
+	err = s.db.SelectContext(ctx, &users, qry, args...)
+	if err == nil && f.WithPrivileges {
+		err = s.userPrivileges(ctx, users)
+	}
 	return users, total, err
 }
+
+// userWhere adds the conditions of f and its nested groups to where.
+func userWhere(where *filter, f *store.UserFilter) {
+	where.Int64("id", f.ID)
+	where.String("name", f.Name)
+	where.String("email", f.Email)
+	userPrivilegeWhere(where, f.Privilege)
+	for _, g := range f.And {
+		userWhere(where, g)
+	}
+	ors := []func(where *filter){}
+	for _, g := range f.Or {
+		g := g
+		ors = append(ors, func(where *filter) { userWhere(where, g) })
+	}
+	where.Or(ors...)
+	if f.Not != nil {
+		where.Not(func(where *filter) { userWhere(where, f.Not) })
+	}
+}
+
+// liveUserWhere hides the soft deleted users unless f includes them.
+func liveUserWhere(where *filter, f *store.UserFilter) {
+	if !f.WithDeleted {
+		where.filters = append(where.filters, "deleted_at IS NULL")
+	}
+}
+
+// heldPrivileges is the recursive held(uid, pid) of the privileges every user
+// holds, granted directly, through a role or implied by one held.
+const heldPrivileges = "WITH RECURSIVE held(uid, pid) AS (SELECT user, privilege FROM user_privilege" +
+	" UNION SELECT ur.user, rp.privilege FROM user_role ur JOIN role_privilege rp ON rp.role = ur.role" +
+	" UNION SELECT h.uid, pi.implies FROM held h JOIN privilege_implies pi ON pi.privilege = h.pid)"
+
+// userPrivilegeWhere adds the conditions of f on the privileges the user
+// holds, see heldPrivileges.
+func userPrivilegeWhere(where *filter, f store.FilterPrivilege) {
+	names := []interface{}{}
+	for _, v := range f.Names {
+		names = append(names, v)
+	}
+	ids := []interface{}{}
+	for _, v := range f.IDs {
+		ids = append(ids, v)
+	}
+	exists := func(names []interface{}, ids []interface{}) string {
+		return "EXISTS (" + heldPrivileges + " SELECT 1 FROM held h JOIN privilege p ON p.id = h.pid WHERE h.uid = user.id AND " +
+			where.group(func(where *filter) {
+				ors := []func(where *filter){}
+				if len(names) > 0 {
+					ors = append(ors, func(where *filter) { where.in("p.name", false, names) })
+				}
+				if len(ids) > 0 {
+					ors = append(ors, func(where *filter) { where.in("p.id", false, ids) })
+				}
+				where.Or(ors...)
+			}) + ")"
+	}
+	switch f.Op {
+	case store.OP_NOP:
+	case store.OP_ANY:
+		if len(names) == 0 && len(ids) == 0 {
+			where.filters = append(where.filters, "1 = 0")
+			return
+		}
+		where.filters = append(where.filters, exists(names, ids))
+	case store.OP_ALL:
+		for _, v := range names {
+			where.filters = append(where.filters, exists([]interface{}{v}, nil))
+		}
+		for _, v := range ids {
+			where.filters = append(where.filters, exists(nil, []interface{}{v}))
+		}
+	case store.OP_NONE:
+		if len(names) == 0 && len(ids) == 0 {
+			return
+		}
+		where.filters = append(where.filters, "NOT "+exists(names, ids))
+	default:
+		panic(fmt.Errorf("invalid op privilege: %+v", f))
+	}
+}
+
+// userPrivileges sets the privileges of users, ordered by id, in one query.
+func (s *MariadbAccountStore) userPrivileges(ctx context.Context, users []*store.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+	ids := []interface{}{}
+	byID := map[int64]*store.User{}
+	for _, u := range users {
+		privileges := []*store.Privilege{}
+		u.Privileges = &privileges
+		ids = append(ids, *u.ID)
+		byID[*u.ID] = u
+	}
+	rows := []struct {
+		store.Privilege
+		User int64 `db:"user"`
+	}{}
+	qry := "SELECT up.user, p.id, p.name, p.description, p.version FROM user_privilege up JOIN privilege p ON p.id = up.privilege WHERE up.user IN (" + strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",") + ") ORDER BY up.user, p.id"
+	err := s.db.SelectContext(ctx, &rows, qry, ids...)
+	if err != nil {
+		return fmt.Errorf("error select user_privilege '%s' %+v: %w", qry, ids, err)
+	}
+	for i := range rows {
+		u := byID[rows[i].User]
+		*u.Privileges = append(*u.Privileges, &rows[i].Privilege)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package mariadb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// FindUsersPage implements store.store.
+func (s *MariadbAccountStore) FindUsersPage(f *store.UserFilter, page store.Page) (*store.UserPage, error) {
+	return s.FindUsersPageContext(context.Background(), f, page)
+}
+
+// FindUsersPageContext implements store.store.
+func (s *MariadbAccountStore) FindUsersPageContext(ctx context.Context, f *store.UserFilter, page store.Page) (*store.UserPage, error) {
+	if !s.ValidLimit(page.Limit) {
+		return nil, fmt.Errorf("%w %d", store.ErrInvalidLimit, page.Limit)
+	}
+	sort, err := f.Sort.Resolve(store.UserSortFields)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := page.ParseCursor(sort)
+	if err != nil {
+		return nil, err
+	}
+	where := filter{}
+	userWhere(&where, f)
+	liveUserWhere(&where, f)
+
+	total, estimated, err := s.countUsers(ctx, &where, f, page.Count)
+	if err != nil {
+		return nil, err
+	}
+	order := sort
+	if cursor != nil {
+		where.Keyset(sort, cursor)
+		if cursor.Prev {
+			order = sort.Reverse()
+		}
+	}
+	users := []*store.User{}
+	qry := "SELECT id, name, email, password, deleted_at, version FROM user"
+	qry = where.AppendWhere(qry)
+	qry += orderBy(order)
+	qry += " LIMIT ?"
+	args := append(where.args, page.Limit+1)
+	err = s.db.SelectContext(ctx, &users, qry, args...)
+	if err != nil {
+		return nil, err
+	}
+	res := store.NewUserPage(users, sort, cursor, page.Limit)
+	if f.WithPrivileges {
+		err = s.userPrivileges(ctx, res.Users)
+		if err != nil {
+			return nil, err
+		}
+	}
+	res.Total, res.Estimated = total, estimated
+	return res, nil
+}
+
+// countUsers counts the users matching where like count, a filter of no more
+// than liveUserWhere is estimated from the table less the soft deleted users.
+func (s *MariadbAccountStore) countUsers(ctx context.Context, where *filter, f *store.UserFilter, count int) (*int64, bool, error) {
+	live := 0
+	if !f.WithDeleted {
+		live = 1
+	}
+	if count != store.COUNT_ESTIMATE || len(where.filters) != live {
+		return s.count(ctx, "user", where, count)
+	}
+	total, err := s.estimate(ctx, "user")
+	if err != nil {
+		return nil, false, err
+	}
+	if !f.WithDeleted {
+		var deleted int64
+		err := s.db.GetContext(ctx, &deleted, "SELECT count(id) FROM user WHERE deleted_at IS NOT NULL")
+		if err != nil {
+			return nil, false, fmt.Errorf("error count deleted user: %w", err)
+		}
+		total = max(total-deleted, 0)
+	}
+	return &total, true, nil
+}
@@ -0,0 +1,53 @@
+package mariadb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// RestoreUsers implements store.store.
+func (s *MariadbAccountStore) RestoreUsers(ids []int64) error {
+	return s.RestoreUsersContext(context.Background(), ids)
+}
+
+// RestoreUsersContext implements store.store.
+func (s *MariadbAccountStore) RestoreUsersContext(ctx context.Context, ids []int64) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	events := []*store.AuditEvent{}
+	for _, id := range ids {
+		var user store.User
+		err := tx.GetContext(ctx, &user, "SELECT id, name, email FROM user WHERE id = ? AND deleted_at IS NOT NULL", id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("error restore user.id %d: %w", id, store.ErrNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("error restore user.id %d: %w", id, err)
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE user SET deleted_at = NULL WHERE id = ?", id)
+		if err != nil {
+			if field, v, ok := uniqueViolation(err); ok {
+				return fmt.Errorf("error restore user.id %d: %w", id,
+					&store.DuplicateError{Table: "user", Field: field, Value: v})
+			}
+			return fmt.Errorf("error restore user.id %d: %w", id, err)
+		}
+		after, err := s.auditUser(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_USER_RESTORE, "user", id, nil, after))
+	}
+	if err := s.audit(ctx, tx, events...); err != nil {
+		return err
+	}
+	err = tx.Commit()
+	return err
+}
@@ -0,0 +1,67 @@
+package mariadb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// SearchUsers implements store.store.
+func (s *MariadbAccountStore) SearchUsers(query string, limit int) ([]*store.User, error) {
+	return s.SearchUsersContext(context.Background(), query, limit)
+}
+
+// SearchUsersContext implements store.store, terms in the stopword list of
+// the server match nothing. Terms shorter than innodb_ft_min_token_size are
+// not indexed and match a word start by regexp instead, which scans the user
+// table.
+func (s *MariadbAccountStore) SearchUsersContext(ctx context.Context, query string, limit int) ([]*store.User, error) {
+	if !s.ValidLimit(limit) {
+		return nil, fmt.Errorf("%w %d", store.ErrInvalidLimit, limit)
+	}
+	users := []*store.User{}
+	terms := store.SearchTerms(query)
+	if len(terms) == 0 {
+		return users, nil
+	}
+	indexed, short := []string{}, []string{}
+	for _, t := range terms {
+		if utf8.RuneCountInString(t) < s.ftMinTokenSize {
+			short = append(short, t)
+		} else {
+			indexed = append(indexed, t)
+		}
+	}
+	where := []string{"deleted_at IS NULL"}
+	ranks := []string{}
+	args := []interface{}{}
+	rankArgs := []interface{}{}
+	if len(indexed) > 0 {
+		// the terms are letters and digits only, in boolean mode +term*
+		// requires a word starting with term
+		match := "+" + strings.Join(indexed, "* +") + "*"
+		where = append(where, "MATCH(name, email) AGAINST(? IN BOOLEAN MODE)")
+		args = append(args, match)
+		ranks = append(ranks, "MATCH(name) AGAINST(? IN BOOLEAN MODE) * 2 + MATCH(name, email) AGAINST(? IN BOOLEAN MODE)")
+		rankArgs = append(rankArgs, match, match)
+	}
+	for _, t := range short {
+		// a word start, like the prefix match of the fulltext index
+		rx := `(?i)\b` + t
+		where = append(where, "(name REGEXP ? OR email REGEXP ?)")
+		args = append(args, rx, rx)
+		ranks = append(ranks, "(name REGEXP ?) * 2 + (email REGEXP ?)")
+		rankArgs = append(rankArgs, rx, rx)
+	}
+	qry := "SELECT id, name, email, password, version FROM user WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY " + strings.Join(ranks, " + ") + " DESC, id LIMIT ?"
+	args = append(append(args, rankArgs...), limit)
+	err := s.db.SelectContext(ctx, &users, qry, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error search user '%s': %w", query, err)
+	}
+	return users, nil
+}
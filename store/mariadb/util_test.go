@@ -4,14 +4,17 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func startMariaDB(t *testing.T) {
-	out, err := os.Create("mariadb.log")
+// startMariaDB runs a throwaway mariadb container on localhost:13306 with a
+// "test" database and returns its DSN.
+func startMariaDB(t *testing.T) string {
+	out, err := os.Create(filepath.Join(t.TempDir(), "mariadb.log"))
 	assert.NoError(t, err)
 	defer out.Close()
 
@@ -66,6 +69,7 @@ func startMariaDB(t *testing.T) {
 		}
 		time.Sleep(1 * time.Second)
 	}
+	return "root:dodol123@tcp(localhost:13306)/test"
 }
 
 func stopMariaDB(t *testing.T) {
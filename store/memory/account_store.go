@@ -0,0 +1,565 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+type userRow struct {
+	lastLogin *int64
+	deletedAt *int64
+	name      string
+	email     string
+	password  string
+	id        int64
+	version   int64
+}
+
+type privilegeRow struct {
+	name        string
+	description string
+	id          int64
+	version     int64
+}
+
+type auditRow struct {
+	actorID   *int64
+	action    string
+	target    string
+	changes   []byte
+	id        int64
+	createdAt int64
+	targetID  int64
+}
+
+type roleRow struct {
+	name        string
+	description string
+	id          int64
+}
+
+// MemoryAccountStore keeps the accounts in memory, it needs neither cgo nor a
+// database server and is meant for tests.
+type MemoryAccountStore struct {
+	users      map[int64]*userRow
+	privileges map[int64]*privilegeRow
+	roles      map[int64]*roleRow
+	// userPrivileges holds the privilege ids of each user, ordered by id
+	userPrivileges map[int64][]int64
+	// rolePrivileges holds the privilege ids of each role, ordered by id
+	rolePrivileges map[int64][]int64
+	// userRoles holds the role ids of each user, ordered by id
+	userRoles map[int64][]int64
+	// privilegeImplies holds the ids of the privileges each privilege
+	// implies, ordered by id
+	privilegeImplies map[int64][]int64
+	// passwordHistory holds the previous password hashes of each user, newest first
+	passwordHistory map[int64][]string
+	// auditLog holds the audit events, oldest first
+	auditLog        []*auditRow
+	sessions        map[string]*sessionRow
+	passwordPolicy  *store.PasswordPolicy
+	passwordHasher  store.PasswordHasher
+	session         store.SessionConfig
+	mutex           sync.RWMutex
+	maxLimit        int
+	lastUserID      int64
+	lastPrivilegeID int64
+	lastRoleID      int64
+	lastSessionID   int64
+	lastAuditID     int64
+}
+
+func init() {
+	store.AddAccountStore("memory", func(cfg store.Config) (store.AccountStore, error) {
+		s, err := Open(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+}
+
+// Open returns an empty store, cfg.DSN and the migration settings are ignored.
+func Open(cfg store.Config) (*MemoryAccountStore, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &MemoryAccountStore{
+		users:            map[int64]*userRow{},
+		privileges:       map[int64]*privilegeRow{},
+		roles:            map[int64]*roleRow{},
+		userPrivileges:   map[int64][]int64{},
+		rolePrivileges:   map[int64][]int64{},
+		userRoles:        map[int64][]int64{},
+		privilegeImplies: map[int64][]int64{},
+		passwordHistory:  map[int64][]string{},
+		sessions:         map[string]*sessionRow{},
+		passwordPolicy:   cfg.GetPasswordPolicy(),
+		passwordHasher:   cfg.GetPasswordHasher(),
+		session:          cfg.Session,
+		maxLimit:         cfg.GetMaxLimit(),
+	}, nil
+}
+
+func (s *MemoryAccountStore) Close() error {
+	return nil
+}
+
+// SchemaVersion implements store.AccountStore, the store has no schema and
+// always reports version 0.
+func (s *MemoryAccountStore) SchemaVersion(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return 0, nil
+}
+
+func (s *MemoryAccountStore) ValidLimit(limit int) bool {
+	return limit > 0 && limit <= s.maxLimit
+}
+
+func (s *MemoryAccountStore) ValueString(v interface{}) string {
+	bstr, _ := json.Marshal(v)
+	str := string(bstr)
+	if strings.HasPrefix(str, "{") {
+		return fmt.Sprintf("(%s)", str[1:len(str)-1])
+	}
+	return str
+}
+
+func (r *userRow) user() *store.User {
+	name, email, password, id, version := r.name, r.email, r.password, r.id, r.version
+	user := &store.User{ID: &id, Name: &name, Email: &email, Password: &password, Version: &version}
+	if r.deletedAt != nil {
+		deletedAt := *r.deletedAt
+		user.DeletedAt = &deletedAt
+	}
+	return user
+}
+
+func (r *privilegeRow) privilege() *store.Privilege {
+	name, description, id, version := r.name, r.description, r.id, r.version
+	return &store.Privilege{ID: &id, Name: &name, Description: &description, Version: &version}
+}
+
+func (r *roleRow) role() *store.Role {
+	name, description, id := r.name, r.description, r.id
+	return &store.Role{ID: &id, Name: &name, Description: &description}
+}
+
+// liveUser returns the user id, nil when there is none or it is soft deleted.
+func (s *MemoryAccountStore) liveUser(id int64) *userRow {
+	if u, ok := s.users[id]; ok && u.deletedAt == nil {
+		return u
+	}
+	return nil
+}
+
+// userByName returns the live user named name, nil when there is none.
+func (s *MemoryAccountStore) userByName(name string) *userRow {
+	for _, u := range s.users {
+		if u.name == name && u.deletedAt == nil {
+			return u
+		}
+	}
+	return nil
+}
+
+// userByEmail returns the live user with email, nil when there is none.
+func (s *MemoryAccountStore) userByEmail(email string) *userRow {
+	for _, u := range s.users {
+		if u.email == email && u.deletedAt == nil {
+			return u
+		}
+	}
+	return nil
+}
+
+// privilegeByName returns the privilege named name, nil when there is none.
+func (s *MemoryAccountStore) privilegeByName(name string) *privilegeRow {
+	for _, p := range s.privileges {
+		if p.name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// userPrivilegeList returns the privileges of a user ordered by id.
+func (s *MemoryAccountStore) userPrivilegeList(userID int64) *[]*store.Privilege {
+	privileges := []*store.Privilege{}
+	for _, id := range s.userPrivileges[userID] {
+		privileges = append(privileges, s.privileges[id].privilege())
+	}
+	return &privileges
+}
+
+// roleByName returns the role named name, nil when there is none.
+func (s *MemoryAccountStore) roleByName(name string) *roleRow {
+	for _, r := range s.roles {
+		if r.name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// privilegeIDsByName returns the ids of privileges, looked up by name, in
+// their order.
+func (s *MemoryAccountStore) privilegeIDsByName(table string, privileges []*store.Privilege) ([]int64, error) {
+	ids := []int64{}
+	for _, p := range privileges {
+		privilege := s.privilegeByName(*p.Name)
+		if privilege == nil {
+			return nil, fmt.Errorf("error get privilege name '%s': %w", *p.Name, store.ErrNotFound)
+		}
+		if containsID(ids, privilege.id) {
+			return nil, fmt.Errorf("error insert %s%s: %w", table, s.ValueString(p),
+				&store.DuplicateError{Table: table, Field: "privilege", Value: *p.Name})
+		}
+		ids = append(ids, privilege.id)
+	}
+	return ids, nil
+}
+
+// roleIDsByName returns the ids of roles, looked up by name, in their order.
+func (s *MemoryAccountStore) roleIDsByName(roles []*store.Role) ([]int64, error) {
+	ids := []int64{}
+	for _, r := range roles {
+		role := s.roleByName(*r.Name)
+		if role == nil {
+			return nil, fmt.Errorf("error get role name '%s': %w", *r.Name, store.ErrNotFound)
+		}
+		if containsID(ids, role.id) {
+			return nil, fmt.Errorf("error insert user_role%s: %w", s.ValueString(r),
+				&store.DuplicateError{Table: "user_role", Field: "role", Value: *r.Name})
+		}
+		ids = append(ids, role.id)
+	}
+	return ids, nil
+}
+
+// userRoleList returns the roles of a user ordered by id, without their
+// privileges.
+func (s *MemoryAccountStore) userRoleList(userID int64) *[]*store.Role {
+	roles := []*store.Role{}
+	for _, id := range s.userRoles[userID] {
+		roles = append(roles, s.roles[id].role())
+	}
+	return &roles
+}
+
+// rolePrivilegeList returns the privileges of a role ordered by id.
+func (s *MemoryAccountStore) rolePrivilegeList(roleID int64) *[]*store.Privilege {
+	privileges := []*store.Privilege{}
+	for _, id := range s.rolePrivileges[roleID] {
+		privileges = append(privileges, s.privileges[id].privilege())
+	}
+	return &privileges
+}
+
+// privilegeImpliesList returns the privileges a privilege implies ordered by
+// id, nil when there are none.
+func (s *MemoryAccountStore) privilegeImpliesList(privilegeID int64) *[]*store.Privilege {
+	if len(s.privilegeImplies[privilegeID]) == 0 {
+		return nil
+	}
+	privileges := []*store.Privilege{}
+	for _, id := range s.privilegeImplies[privilegeID] {
+		privileges = append(privileges, s.privileges[id].privilege())
+	}
+	return &privileges
+}
+
+// impliedIDs returns the ids of implies, looked up by name in added, the
+// privileges being added, then in the store, in their order.
+func (s *MemoryAccountStore) impliedIDs(implies []*store.Privilege, added map[string]int64) ([]int64, error) {
+	ids := []int64{}
+	for _, p := range implies {
+		id, ok := added[*p.Name]
+		if !ok {
+			privilege := s.privilegeByName(*p.Name)
+			if privilege == nil {
+				return nil, fmt.Errorf("error get privilege name '%s': %w", *p.Name, store.ErrNotFound)
+			}
+			id = privilege.id
+		}
+		if containsID(ids, id) {
+			return nil, fmt.Errorf("error insert privilege_implies%s: %w", s.ValueString(p),
+				&store.DuplicateError{Table: "privilege_implies", Field: "implies", Value: *p.Name})
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// implications returns the implications of implies, the ids each privilege
+// implies, the privileges named in names are not stored yet or renamed.
+func (s *MemoryAccountStore) implications(implies map[int64][]int64, names map[int64]string) []store.Implication {
+	res := []store.Implication{}
+	for _, by := range sortedIDs(implies) {
+		for _, id := range implies[by] {
+			im := store.Implication{ImpliedBy: by}
+			if row, ok := s.privileges[id]; ok {
+				im.Privilege = *row.privilege()
+			}
+			if name, ok := names[id]; ok {
+				id := id
+				im.ID, im.Name = &id, &name
+			}
+			res = append(res, im)
+		}
+	}
+	return res
+}
+
+// checkImplicationCycle returns a *store.CycleError when implies, the ids
+// each privilege would imply, holds a cycle, see implications for names.
+func (s *MemoryAccountStore) checkImplicationCycle(implies map[int64][]int64, names map[int64]string) error {
+	if cycle := store.ImplicationCycle(s.implications(implies, names)); cycle != nil {
+		return fmt.Errorf("error update privilege_implies: %w", &store.CycleError{Path: cycle})
+	}
+	return nil
+}
+
+// sortedIDs returns the keys of m in ascending order, the order the sql
+// backends return rows in.
+func sortedIDs[T any](m map[int64]T) []int64 {
+	ids := make([]int64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// likeRegexp returns the regexp of a sql LIKE pattern, case insensitive as
+// in sqlite and mariadb.
+func likeRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+type filter struct {
+	matchers []func(values map[string]interface{}) bool
+}
+
+func (ctx *filter) Int64(field string, f store.FilterInt64) {
+	value := func(values map[string]interface{}) (int64, bool) {
+		v, ok := values[field].(int64)
+		return v, ok
+	}
+	switch f.Op {
+	case store.OP_NOP:
+	case store.OP_EQ, store.OP_NE, store.OP_GTE, store.OP_LTE, store.OP_BETWEEN:
+		ctx.add(func(values map[string]interface{}) bool {
+			v, ok := value(values)
+			if !ok {
+				// comparing null is never true in sql
+				return false
+			}
+			switch f.Op {
+			case store.OP_EQ:
+				return v == f.Value
+			case store.OP_NE:
+				return v != f.Value
+			case store.OP_GTE:
+				return v >= f.Value
+			case store.OP_LTE:
+				return v <= f.Value
+			}
+			return v >= f.Value && v <= f.To
+		})
+	case store.OP_IN, store.OP_NIN:
+		in := map[interface{}]bool{}
+		for _, v := range f.Values {
+			in[v] = true
+		}
+		ctx.in(field, f.Op == store.OP_NIN, in)
+	case store.OP_NULL, store.OP_NOT_NULL:
+		ctx.null(field, f.Op == store.OP_NULL)
+	default:
+		panic(fmt.Errorf("invalid op %s: %+v", field, f))
+	}
+}
+
+func (ctx *filter) String(field string, f store.FilterString) {
+	switch f.Op {
+	case store.OP_NOP:
+	case store.OP_EQ, store.OP_NE, store.OP_LIKE:
+		// compiled once per find, not per row
+		var rx *regexp.Regexp
+		if f.Op == store.OP_LIKE {
+			rx = likeRegexp(f.Value)
+		}
+		ctx.add(func(values map[string]interface{}) bool {
+			v, ok := values[field].(string)
+			if !ok {
+				return false
+			}
+			switch f.Op {
+			case store.OP_EQ:
+				return v == f.Value
+			case store.OP_NE:
+				return v != f.Value
+			}
+			return rx.MatchString(v)
+		})
+	case store.OP_IN, store.OP_NIN:
+		in := map[interface{}]bool{}
+		for _, v := range f.Values {
+			in[v] = true
+		}
+		ctx.in(field, f.Op == store.OP_NIN, in)
+	case store.OP_NULL, store.OP_NOT_NULL:
+		ctx.null(field, f.Op == store.OP_NULL)
+	case store.OP_EMPTY, store.OP_NOT_EMPTY:
+		ctx.add(func(values map[string]interface{}) bool {
+			v, _ := values[field].(string)
+			return (v == "") == (f.Op == store.OP_EMPTY)
+		})
+	default:
+		panic(fmt.Errorf("invalid op %s: %+v", field, f))
+	}
+}
+
+func (ctx *filter) add(m func(values map[string]interface{}) bool) {
+	ctx.matchers = append(ctx.matchers, m)
+}
+
+// in matches the values of field in set, or not in it when not is set, null
+// never matches a non empty list.
+func (ctx *filter) in(field string, not bool, set map[interface{}]bool) {
+	if not && len(set) == 0 {
+		return
+	}
+	ctx.add(func(values map[string]interface{}) bool {
+		v := values[field]
+		if v == nil {
+			return false
+		}
+		return set[v] != not
+	})
+}
+
+func (ctx *filter) null(field string, null bool) {
+	ctx.add(func(values map[string]interface{}) bool {
+		return (values[field] == nil) == null
+	})
+}
+
+// Or adds the groups, a row matches when it matches any of them, no groups
+// adds no condition.
+func (ctx *filter) Or(groups ...func(where *filter)) {
+	if len(groups) == 0 {
+		return
+	}
+	ors := []*filter{}
+	for _, g := range groups {
+		sub := &filter{}
+		g(sub)
+		ors = append(ors, sub)
+	}
+	ctx.add(func(values map[string]interface{}) bool {
+		for _, sub := range ors {
+			if sub.Match(values) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Not adds the negated group.
+func (ctx *filter) Not(group func(where *filter)) {
+	sub := &filter{}
+	group(sub)
+	ctx.add(func(values map[string]interface{}) bool {
+		return !sub.Match(values)
+	})
+}
+
+// Keyset adds the rows after cursor in sort order, or before it when cursor
+// is Prev, sort ends with the unique id.
+func (ctx *filter) Keyset(sort store.Sort, cursor *store.Cursor) {
+	ctx.add(func(values map[string]interface{}) bool {
+		for i, f := range sort {
+			c := compare(values[f.Field], cursor.Values[i])
+			if c != 0 {
+				return (c > 0) != (f.Desc != cursor.Prev)
+			}
+		}
+		return false
+	})
+}
+
+// Match reports whether values, the columns of a row, pass every filter.
+func (ctx *filter) Match(values map[string]interface{}) bool {
+	for _, m := range ctx.matchers {
+		if !m(values) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortRows orders rows by the column values of each sort field in turn.
+func sortRows[T any](rows []T, values func(T) map[string]interface{}, order store.Sort) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		vi, vj := values(rows[i]), values(rows[j])
+		for _, f := range order {
+			c := compare(vi[f.Field], vj[f.Field])
+			if c != 0 {
+				return (c < 0) != f.Desc
+			}
+		}
+		return false
+	})
+}
+
+// compare returns -1, 0 or 1 as a is less than, equal to or greater than b,
+// int64 or string column values, null sorts first as in sqlite and mariadb.
+func compare(a interface{}, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	switch av := a.(type) {
+	case int64:
+		bv := b.(int64)
+		switch {
+		case av < bv:
+			return -1
+		case av > bv:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(av, b.(string))
+	}
+	panic(fmt.Errorf("invalid column value %T", a))
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// FindAuditEvents implements store.Store.
+func (s *MemoryAccountStore) FindAuditEvents(
+	f *store.AuditFilter, offset int64, limit int,
+) ([]*store.AuditEvent, int64, error) {
+	return s.FindAuditEventsContext(context.Background(), f, offset, limit)
+}
+
+// FindAuditEventsContext implements store.Store.
+func (s *MemoryAccountStore) FindAuditEventsContext(
+	ctx context.Context, f *store.AuditFilter, offset int64, limit int,
+) ([]*store.AuditEvent, int64, error) {
+	where := filter{}
+	where.Int64("actor", f.ActorID)
+	where.String("action", f.Action)
+	where.String("target", f.Target)
+	where.Int64("target_id", f.TargetID)
+	where.Int64("created_at", f.CreatedAt())
+
+	if !s.ValidLimit(limit) {
+		return nil, 0, fmt.Errorf("%w %d", store.ErrInvalidLimit, limit)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	rows := []*auditRow{}
+	for _, row := range s.auditLog {
+		if where.Match(row.values()) {
+			rows = append(rows, row)
+		}
+	}
+	events := []*store.AuditEvent{}
+	for i := max(offset, 0); i < int64(len(rows)) && len(events) < limit; i++ {
+		e, err := rows[i].event()
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, e)
+	}
+	return events, int64(len(rows)), nil
+}
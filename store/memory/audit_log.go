@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+func (r *auditRow) values() map[string]interface{} {
+	values := map[string]interface{}{
+		"id":         r.id,
+		"created_at": r.createdAt,
+		"action":     r.action,
+		"target":     r.target,
+		"target_id":  r.targetID,
+	}
+	if r.actorID != nil {
+		values["actor"] = *r.actorID
+	}
+	return values
+}
+
+func (r *auditRow) event() (*store.AuditEvent, error) {
+	changes := map[string]store.AuditChange{}
+	if err := json.Unmarshal(r.changes, &changes); err != nil {
+		return nil, fmt.Errorf("error decode audit_log.changes %d: %w", r.id, err)
+	}
+	id := r.id
+	var actorID *int64
+	if r.actorID != nil {
+		v := *r.actorID
+		actorID = &v
+	}
+	return &store.AuditEvent{
+		ID:        &id,
+		CreatedAt: time.UnixMilli(r.createdAt),
+		ActorID:   actorID,
+		Action:    r.action,
+		Target:    r.target,
+		TargetID:  r.targetID,
+		Changes:   changes,
+	}, nil
+}
+
+// audit appends events, skipping nil ones, to the audit log. Every event is
+// encoded before any is appended.
+func (s *MemoryAccountStore) audit(events ...*store.AuditEvent) error {
+	rows := []*auditRow{}
+	for _, e := range events {
+		if e == nil {
+			continue
+		}
+		changes, err := json.Marshal(e.Changes)
+		if err != nil {
+			return fmt.Errorf("error encode audit_log.changes: %w", err)
+		}
+		rows = append(rows, &auditRow{
+			createdAt: e.CreatedAt.UnixMilli(),
+			actorID:   e.ActorID,
+			action:    e.Action,
+			target:    e.Target,
+			targetID:  e.TargetID,
+			changes:   changes,
+		})
+	}
+	for _, row := range rows {
+		s.lastAuditID++
+		row.id = s.lastAuditID
+		s.auditLog = append(s.auditLog, row)
+	}
+	return nil
+}
+
+// auditUser returns the audited fields of the user id, nil when there is no
+// such live user.
+func (s *MemoryAccountStore) auditUser(id int64) map[string]interface{} {
+	row := s.liveUser(id)
+	if row == nil {
+		return nil
+	}
+	return s.auditUserRow(row)
+}
+
+// auditUserRow returns the audited fields of the user of row, with its
+// privileges and roles.
+func (s *MemoryAccountStore) auditUserRow(row *userRow) map[string]interface{} {
+	user := row.user()
+	user.Privileges = s.userPrivilegeList(row.id)
+	user.Roles = s.userRoleList(row.id)
+	return store.UserAuditFields(user)
+}
+
+// auditPrivilege returns the audited fields of the privilege id, nil when
+// there is no such privilege.
+func (s *MemoryAccountStore) auditPrivilege(id int64) map[string]interface{} {
+	row, ok := s.privileges[id]
+	if !ok {
+		return nil
+	}
+	privilege := row.privilege()
+	privilege.Implies = &[]*store.Privilege{}
+	if implies := s.privilegeImpliesList(id); implies != nil {
+		privilege.Implies = implies
+	}
+	return store.PrivilegeAuditFields(privilege)
+}
+
+// auditRole returns the audited fields of the role id, nil when there is no
+// such role.
+func (s *MemoryAccountStore) auditRole(id int64) map[string]interface{} {
+	row, ok := s.roles[id]
+	if !ok {
+		return nil
+	}
+	role := row.role()
+	role.Privileges = s.rolePrivilegeList(id)
+	return store.RoleAuditFields(role)
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// AddPrivileges implements store.Store.
+func (s *MemoryAccountStore) AddPrivileges(privileges []*store.Privilege) ([]*store.Privilege, error) {
+	return s.AddPrivilegesContext(context.Background(), privileges)
+}
+
+// AddPrivilegesContext implements store.Store.
+func (s *MemoryAccountStore) AddPrivilegesContext(ctx context.Context, privileges []*store.Privilege) ([]*store.Privilege, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	names := map[string]bool{}
+	for _, privilege := range privileges {
+		if privilege.Name == nil || privilege.Description == nil {
+			return nil, fmt.Errorf("error insert privilege%s: name and description are required", s.ValueString(privilege))
+		}
+		if names[*privilege.Name] || s.privilegeByName(*privilege.Name) != nil {
+			return nil, fmt.Errorf("error insert privilege%s: %w", s.ValueString(privilege),
+				&store.DuplicateError{Table: "privilege", Field: "name", Value: *privilege.Name})
+		}
+		names[*privilege.Name] = true
+	}
+	// implied privileges may be added in the same call, they are looked up
+	// by the ids they are stored with below
+	added := map[string]int64{}
+	addedNames := map[int64]string{}
+	for i, privilege := range privileges {
+		id := s.lastPrivilegeID + int64(i) + 1
+		added[*privilege.Name] = id
+		addedNames[id] = *privilege.Name
+	}
+	implies := map[int64][]int64{}
+	for id, pids := range s.privilegeImplies {
+		implies[id] = pids
+	}
+	// impliedIDs holds the ids each added privilege implies in their order
+	impliedIDs := map[int64][]int64{}
+	for _, privilege := range privileges {
+		if privilege.Implies != nil {
+			pids, err := s.impliedIDs(*privilege.Implies, added)
+			if err != nil {
+				return nil, err
+			}
+			impliedIDs[added[*privilege.Name]] = pids
+			sorted := []int64{}
+			for _, pid := range pids {
+				sorted = appendID(sorted, pid)
+			}
+			implies[added[*privilege.Name]] = sorted
+		}
+	}
+	if err := s.checkImplicationCycle(implies, addedNames); err != nil {
+		return nil, err
+	}
+	res := []*store.Privilege{}
+	for _, privilege := range privileges {
+		s.lastPrivilegeID++
+		id := s.lastPrivilegeID
+		s.privileges[id] = &privilegeRow{id: id, name: *privilege.Name, description: *privilege.Description, version: 1}
+		privilege.ID = &id
+		version := int64(1)
+		privilege.Version = &version
+		res = append(res, privilege)
+	}
+	for _, privilege := range privileges {
+		if privilege.Implies != nil {
+			s.privilegeImplies[*privilege.ID] = implies[*privilege.ID]
+			v := []*store.Privilege{}
+			for _, pid := range impliedIDs[*privilege.ID] {
+				v = append(v, s.privileges[pid].privilege())
+			}
+			privilege.Implies = &v
+		}
+	}
+	events := []*store.AuditEvent{}
+	for _, privilege := range privileges {
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_PRIVILEGE_ADD, "privilege", *privilege.ID, nil, store.PrivilegeAuditFields(privilege)))
+	}
+	if err := s.audit(events...); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
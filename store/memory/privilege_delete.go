@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// DeletePrivileges implements store.Store.
+func (s *MemoryAccountStore) DeletePrivileges(ids []int64) error {
+	return s.DeletePrivilegesContext(context.Background(), ids)
+}
+
+// DeletePrivilegesContext implements store.Store.
+func (s *MemoryAccountStore) DeletePrivilegesContext(ctx context.Context, ids []int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	found := map[int64]bool{}
+	for _, id := range ids {
+		if _, ok := s.privileges[id]; ok {
+			found[id] = true
+		}
+	}
+	for uid, pids := range s.userPrivileges {
+		if s.users[uid].deletedAt != nil {
+			continue
+		}
+		for _, pid := range pids {
+			if found[pid] {
+				return fmt.Errorf("error delete privilege.id%s: %w", s.ValueString(ids), store.ErrInUse)
+			}
+		}
+	}
+	for _, pids := range s.rolePrivileges {
+		for _, pid := range pids {
+			if found[pid] {
+				return fmt.Errorf("error delete privilege.id%s: %w", s.ValueString(ids), store.ErrInUse)
+			}
+		}
+	}
+	// the implications of the deleted privileges are deleted with them
+	for id, pids := range s.privilegeImplies {
+		if found[id] {
+			continue
+		}
+		for _, pid := range pids {
+			if found[pid] {
+				return fmt.Errorf("error delete privilege.id%s: %w", s.ValueString(ids), store.ErrInUse)
+			}
+		}
+	}
+	if len(found) != len(ids) {
+		return fmt.Errorf("error delete privilege.id%s affected %v: %w", s.ValueString(ids), len(found), store.ErrNotFound)
+	}
+	events := []*store.AuditEvent{}
+	for _, id := range ids {
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_PRIVILEGE_DELETE, "privilege", id, s.auditPrivilege(id), nil))
+	}
+	// the soft deleted users do not keep a privilege in use
+	for uid, pids := range s.userPrivileges {
+		s.userPrivileges[uid] = removeIDs(pids, found)
+	}
+	for id := range found {
+		delete(s.privileges, id)
+		delete(s.privilegeImplies, id)
+	}
+	return s.audit(events...)
+}
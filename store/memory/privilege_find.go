@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+func (r *privilegeRow) values() map[string]interface{} {
+	return map[string]interface{}{"id": r.id, "name": r.name, "description": r.description}
+}
+
+// FindPrivileges implements store.Store.
+func (s *MemoryAccountStore) FindPrivileges(
+	f *store.PrivilegeFilter, offset int64, limit int,
+) ([]*store.Privilege, int64, error) {
+	return s.FindPrivilegesContext(context.Background(), f, offset, limit)
+}
+
+// FindPrivilegesContext implements store.Store.
+func (s *MemoryAccountStore) FindPrivilegesContext(
+	ctx context.Context, f *store.PrivilegeFilter, offset int64, limit int,
+) ([]*store.Privilege, int64, error) {
+	where := filter{}
+	privilegeWhere(&where, f)
+
+	if !s.ValidLimit(limit) {
+		return nil, 0, fmt.Errorf("%w %d", store.ErrInvalidLimit, limit)
+	}
+	sort, err := f.Sort.Resolve(store.PrivilegeSortFields)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	rows := []*privilegeRow{}
+	for _, id := range sortedIDs(s.privileges) {
+		row := s.privileges[id]
+		if where.Match(row.values()) {
+			rows = append(rows, row)
+		}
+	}
+	sortRows(rows, (*privilegeRow).values, sort)
+	privileges := []*store.Privilege{}
+	for i := max(offset, 0); i < int64(len(rows)) && len(privileges) < limit; i++ {
+		privileges = append(privileges, rows[i].privilege())
+	}
+	return privileges, int64(len(rows)), nil
+}
+
+// privilegeWhere adds the conditions of f and its nested groups to where.
+func privilegeWhere(where *filter, f *store.PrivilegeFilter) {
+	where.Int64("id", f.ID)
+	where.String("name", f.Name)
+	where.String("description", f.Description)
+	for _, g := range f.And {
+		privilegeWhere(where, g)
+	}
+	ors := []func(where *filter){}
+	for _, g := range f.Or {
+		g := g
+		ors = append(ors, func(where *filter) { privilegeWhere(where, g) })
+	}
+	where.Or(ors...)
+	if f.Not != nil {
+		where.Not(func(where *filter) { privilegeWhere(where, f.Not) })
+	}
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// FindPrivilegesPage implements store.Store.
+func (s *MemoryAccountStore) FindPrivilegesPage(
+	f *store.PrivilegeFilter, page store.Page,
+) (*store.PrivilegePage, error) {
+	return s.FindPrivilegesPageContext(context.Background(), f, page)
+}
+
+// FindPrivilegesPageContext implements store.Store, the total is always exact.
+func (s *MemoryAccountStore) FindPrivilegesPageContext(
+	ctx context.Context, f *store.PrivilegeFilter, page store.Page,
+) (*store.PrivilegePage, error) {
+	if !s.ValidLimit(page.Limit) {
+		return nil, fmt.Errorf("%w %d", store.ErrInvalidLimit, page.Limit)
+	}
+	sort, err := f.Sort.Resolve(store.PrivilegeSortFields)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := page.ParseCursor(sort)
+	if err != nil {
+		return nil, err
+	}
+	where := filter{}
+	privilegeWhere(&where, f)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var total int64
+	keyset := filter{}
+	order := sort
+	if cursor != nil {
+		keyset.Keyset(sort, cursor)
+		if cursor.Prev {
+			order = sort.Reverse()
+		}
+	}
+	rows := []*privilegeRow{}
+	for _, id := range sortedIDs(s.privileges) {
+		row := s.privileges[id]
+		if !where.Match(row.values()) {
+			continue
+		}
+		total++
+		if keyset.Match(row.values()) {
+			rows = append(rows, row)
+		}
+	}
+	sortRows(rows, (*privilegeRow).values, order)
+	privileges := []*store.Privilege{}
+	for i := 0; i < len(rows) && i <= page.Limit; i++ {
+		privileges = append(privileges, rows[i].privilege())
+	}
+	res := store.NewPrivilegePage(privileges, sort, cursor, page.Limit)
+	if page.Count != store.COUNT_NONE {
+		res.Total = &total
+	}
+	return res, nil
+}
@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// GetPrivilege implements store.Store.
+func (s *MemoryAccountStore) GetPrivilege(id int64) (*store.Privilege, error) {
+	return s.GetPrivilegeContext(context.Background(), id)
+}
+
+// GetPrivilegeContext implements store.Store.
+func (s *MemoryAccountStore) GetPrivilegeContext(ctx context.Context, id int64) (*store.Privilege, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	row, ok := s.privileges[id]
+	if !ok {
+		return nil, fmt.Errorf("error get privilege.id %d: %w", id, store.ErrNotFound)
+	}
+	privilege := row.privilege()
+	privilege.Implies = s.privilegeImpliesList(row.id)
+	return privilege, nil
+}
+
+// GetPrivilegeByName implements store.Store.
+func (s *MemoryAccountStore) GetPrivilegeByName(name string) (*store.Privilege, error) {
+	return s.GetPrivilegeByNameContext(context.Background(), name)
+}
+
+// GetPrivilegeByNameContext implements store.Store.
+func (s *MemoryAccountStore) GetPrivilegeByNameContext(ctx context.Context, name string) (*store.Privilege, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	row := s.privilegeByName(name)
+	if row == nil {
+		return nil, fmt.Errorf("error get privilege.name '%s': %w", name, store.ErrNotFound)
+	}
+	privilege := row.privilege()
+	privilege.Implies = s.privilegeImpliesList(row.id)
+	return privilege, nil
+}
+
+// GetUserPrivileges implements store.Store.
+func (s *MemoryAccountStore) GetUserPrivileges(userID int64) ([]store.UserPrivilege, error) {
+	return s.GetUserPrivilegesContext(context.Background(), userID)
+}
+
+// GetUserPrivilegesContext implements store.Store.
+func (s *MemoryAccountStore) GetUserPrivilegesContext(ctx context.Context, userID int64) ([]store.UserPrivilege, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.effectivePrivileges(userID), nil
+}
+
+// effectivePrivileges returns the privileges a user holds, see
+// GetUserPrivilegesContext, the caller holds the lock.
+func (s *MemoryAccountStore) effectivePrivileges(userID int64) []store.UserPrivilege {
+	grants := []store.PrivilegeGrant{}
+	// a soft deleted user keeps its grants for a restore but holds none
+	if s.liveUser(userID) == nil {
+		return store.NewUserPrivileges(userID, grants, nil)
+	}
+	for _, id := range s.userPrivileges[userID] {
+		grants = append(grants, store.PrivilegeGrant{Privilege: *s.privileges[id].privilege()})
+	}
+	for _, rid := range s.userRoles[userID] {
+		role := s.roles[rid].name
+		for _, id := range s.rolePrivileges[rid] {
+			grants = append(grants, store.PrivilegeGrant{Privilege: *s.privileges[id].privilege(), Role: &role})
+		}
+	}
+	// ordered by privilege id then role, direct grants first, as in sql
+	sort.SliceStable(grants, func(i, j int) bool {
+		gi, gj := grants[i], grants[j]
+		if *gi.ID != *gj.ID {
+			return *gi.ID < *gj.ID
+		}
+		if gi.Role == nil || gj.Role == nil {
+			return gi.Role == nil && gj.Role != nil
+		}
+		return *gi.Role < *gj.Role
+	})
+	return store.NewUserPrivileges(userID, grants, s.implications(s.privilegeImplies, nil))
+}
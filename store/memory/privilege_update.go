@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// UpdatePrivilege implements store.Store.
+func (s *MemoryAccountStore) UpdatePrivilege(privilege *store.Privilege) error {
+	return s.UpdatePrivilegeContext(context.Background(), privilege)
+}
+
+// UpdatePrivilegeContext implements store.Store.
+func (s *MemoryAccountStore) UpdatePrivilegeContext(ctx context.Context, privilege *store.Privilege) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if privilege.ID == nil {
+		return fmt.Errorf("error update privilege%s: %w", s.ValueString(privilege), store.ErrNotFound)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	row, ok := s.privileges[*privilege.ID]
+	if !ok {
+		return fmt.Errorf("error update privilege%s: %w", s.ValueString(privilege), store.ErrNotFound)
+	}
+	if privilege.Version != nil && *privilege.Version != row.version {
+		return fmt.Errorf("error update privilege%s: %w", s.ValueString(privilege),
+			&store.ConflictError{Table: "privilege", ID: row.id, Version: *privilege.Version})
+	}
+	names := map[int64]string{}
+	if privilege.Name != nil {
+		if p := s.privilegeByName(*privilege.Name); p != nil && p.id != row.id {
+			return fmt.Errorf("error update privilege%s: %w", s.ValueString(privilege),
+				&store.DuplicateError{Table: "privilege", Field: "name", Value: *privilege.Name})
+		}
+		names[row.id] = *privilege.Name
+	}
+	var implies []int64
+	if privilege.Implies != nil {
+		pids, err := s.impliedIDs(*privilege.Implies, nil)
+		if err != nil {
+			return err
+		}
+		implies = []int64{}
+		for _, pid := range pids {
+			implies = appendID(implies, pid)
+		}
+		all := map[int64][]int64{}
+		for id, pids := range s.privilegeImplies {
+			all[id] = pids
+		}
+		all[row.id] = implies
+		if err := s.checkImplicationCycle(all, names); err != nil {
+			return err
+		}
+	}
+	before := s.auditPrivilege(row.id)
+	if privilege.Name != nil {
+		row.name = *privilege.Name
+	}
+	if privilege.Description != nil {
+		row.description = *privilege.Description
+	}
+	if implies != nil {
+		s.privilegeImplies[row.id] = implies
+	}
+	row.version++
+	version := row.version
+	privilege.Version = &version
+	return s.audit(store.NewAuditEvent(ctx, store.AUDIT_PRIVILEGE_UPDATE, "privilege", row.id, before, s.auditPrivilege(row.id)))
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// AddRoles implements store.Store.
+func (s *MemoryAccountStore) AddRoles(roles []*store.Role) ([]*store.Role, error) {
+	return s.AddRolesContext(context.Background(), roles)
+}
+
+// AddRolesContext implements store.Store.
+func (s *MemoryAccountStore) AddRolesContext(ctx context.Context, roles []*store.Role) ([]*store.Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// every role is checked before any is stored, like a rolled back transaction
+	names := map[string]bool{}
+	privilegeIDs := [][]int64{}
+	for _, role := range roles {
+		if role.Name == nil || role.Description == nil {
+			return nil, fmt.Errorf("error insert role%s: name and description are required", s.ValueString(role))
+		}
+		if names[*role.Name] || s.roleByName(*role.Name) != nil {
+			return nil, fmt.Errorf("error insert role%s: %w", s.ValueString(role),
+				&store.DuplicateError{Table: "role", Field: "name", Value: *role.Name})
+		}
+		names[*role.Name] = true
+		pids := []int64{}
+		if role.Privileges != nil {
+			var err error
+			if pids, err = s.privilegeIDsByName("role_privilege", *role.Privileges); err != nil {
+				return nil, err
+			}
+		}
+		privilegeIDs = append(privilegeIDs, pids)
+	}
+	res := []*store.Role{}
+	events := []*store.AuditEvent{}
+	for i, role := range roles {
+		s.lastRoleID++
+		id := s.lastRoleID
+		s.roles[id] = &roleRow{id: id, name: *role.Name, description: *role.Description}
+		role.ID = &id
+		if role.Privileges != nil {
+			privileges := []*store.Privilege{}
+			for _, pid := range privilegeIDs[i] {
+				s.rolePrivileges[id] = appendID(s.rolePrivileges[id], pid)
+				privileges = append(privileges, s.privileges[pid].privilege())
+			}
+			role.Privileges = &privileges
+		}
+		res = append(res, role)
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_ROLE_ADD, "role", id, nil, s.auditRole(id)))
+	}
+	return res, s.audit(events...)
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// DeleteRoles implements store.Store.
+func (s *MemoryAccountStore) DeleteRoles(ids []int64) error {
+	return s.DeleteRolesContext(context.Background(), ids)
+}
+
+// DeleteRolesContext implements store.Store.
+func (s *MemoryAccountStore) DeleteRolesContext(ctx context.Context, ids []int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	found := map[int64]bool{}
+	for _, id := range ids {
+		if _, ok := s.roles[id]; ok {
+			found[id] = true
+		}
+	}
+	for uid, rids := range s.userRoles {
+		if s.users[uid].deletedAt != nil {
+			continue
+		}
+		for _, rid := range rids {
+			if found[rid] {
+				return fmt.Errorf("error delete role.id%s: %w", s.ValueString(ids), store.ErrInUse)
+			}
+		}
+	}
+	if len(found) != len(ids) {
+		return fmt.Errorf("error delete role.id%s affected %v: %w", s.ValueString(ids), len(found), store.ErrNotFound)
+	}
+	events := []*store.AuditEvent{}
+	for _, id := range ids {
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_ROLE_DELETE, "role", id, s.auditRole(id), nil))
+	}
+	// the soft deleted users do not keep a role in use
+	for uid, rids := range s.userRoles {
+		s.userRoles[uid] = removeIDs(rids, found)
+	}
+	for id := range found {
+		delete(s.roles, id)
+		delete(s.rolePrivileges, id)
+	}
+	return s.audit(events...)
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+func (r *roleRow) values() map[string]interface{} {
+	return map[string]interface{}{"id": r.id, "name": r.name, "description": r.description}
+}
+
+// FindRoles implements store.Store.
+func (s *MemoryAccountStore) FindRoles(
+	f *store.RoleFilter, offset int64, limit int,
+) ([]*store.Role, int64, error) {
+	return s.FindRolesContext(context.Background(), f, offset, limit)
+}
+
+// FindRolesContext implements store.Store.
+func (s *MemoryAccountStore) FindRolesContext(
+	ctx context.Context, f *store.RoleFilter, offset int64, limit int,
+) ([]*store.Role, int64, error) {
+	where := filter{}
+	roleWhere(&where, f)
+
+	if !s.ValidLimit(limit) {
+		return nil, 0, fmt.Errorf("%w %d", store.ErrInvalidLimit, limit)
+	}
+	sort, err := f.Sort.Resolve(store.RoleSortFields)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	rows := []*roleRow{}
+	for _, id := range sortedIDs(s.roles) {
+		row := s.roles[id]
+		if where.Match(row.values()) {
+			rows = append(rows, row)
+		}
+	}
+	sortRows(rows, (*roleRow).values, sort)
+	roles := []*store.Role{}
+	for i := max(offset, 0); i < int64(len(rows)) && len(roles) < limit; i++ {
+		roles = append(roles, rows[i].role())
+	}
+	return roles, int64(len(rows)), nil
+}
+
+// roleWhere adds the conditions of f and its nested groups to where.
+func roleWhere(where *filter, f *store.RoleFilter) {
+	where.Int64("id", f.ID)
+	where.String("name", f.Name)
+	where.String("description", f.Description)
+	for _, g := range f.And {
+		roleWhere(where, g)
+	}
+	ors := []func(where *filter){}
+	for _, g := range f.Or {
+		g := g
+		ors = append(ors, func(where *filter) { roleWhere(where, g) })
+	}
+	where.Or(ors...)
+	if f.Not != nil {
+		where.Not(func(where *filter) { roleWhere(where, f.Not) })
+	}
+}
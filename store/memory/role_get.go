@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// GetRole implements store.Store.
+func (s *MemoryAccountStore) GetRole(id int64) (*store.Role, error) {
+	return s.GetRoleContext(context.Background(), id)
+}
+
+// GetRoleContext implements store.Store.
+func (s *MemoryAccountStore) GetRoleContext(ctx context.Context, id int64) (*store.Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	row, ok := s.roles[id]
+	if !ok {
+		return nil, fmt.Errorf("error get role.id %d: %w", id, store.ErrNotFound)
+	}
+	role := row.role()
+	role.Privileges = s.rolePrivilegeList(row.id)
+	return role, nil
+}
+
+// GetRoleByName implements store.Store.
+func (s *MemoryAccountStore) GetRoleByName(name string) (*store.Role, error) {
+	return s.GetRoleByNameContext(context.Background(), name)
+}
+
+// GetRoleByNameContext implements store.Store.
+func (s *MemoryAccountStore) GetRoleByNameContext(ctx context.Context, name string) (*store.Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	row := s.roleByName(name)
+	if row == nil {
+		return nil, fmt.Errorf("error get role.name '%s': %w", name, store.ErrNotFound)
+	}
+	role := row.role()
+	role.Privileges = s.rolePrivilegeList(row.id)
+	return role, nil
+}
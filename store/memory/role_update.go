@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// UpdateRole implements store.Store.
+func (s *MemoryAccountStore) UpdateRole(role *store.Role) error {
+	return s.UpdateRoleContext(context.Background(), role)
+}
+
+// UpdateRoleContext implements store.Store.
+func (s *MemoryAccountStore) UpdateRoleContext(ctx context.Context, role *store.Role) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if role.ID == nil {
+		return fmt.Errorf("error update role%s: %w", s.ValueString(role), store.ErrNotFound)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	row, ok := s.roles[*role.ID]
+	if !ok {
+		return fmt.Errorf("error update role%s: %w", s.ValueString(role), store.ErrNotFound)
+	}
+	if role.Name != nil {
+		if r := s.roleByName(*role.Name); r != nil && r.id != row.id {
+			return fmt.Errorf("error update role%s: %w", s.ValueString(role),
+				&store.DuplicateError{Table: "role", Field: "name", Value: *role.Name})
+		}
+	}
+	pids := []int64{}
+	if role.Privileges != nil {
+		var err error
+		if pids, err = s.privilegeIDsByName("role_privilege", *role.Privileges); err != nil {
+			return err
+		}
+	}
+	before := s.auditRole(row.id)
+	if role.Name != nil {
+		row.name = *role.Name
+	}
+	if role.Description != nil {
+		row.description = *role.Description
+	}
+	if role.Privileges != nil {
+		privileges := []int64{}
+		for _, pid := range pids {
+			privileges = appendID(privileges, pid)
+		}
+		s.rolePrivileges[row.id] = privileges
+	}
+	return s.audit(store.NewAuditEvent(ctx, store.AUDIT_ROLE_UPDATE, "role", row.id, before, s.auditRole(row.id)))
+}
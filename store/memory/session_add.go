@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+type sessionRow struct {
+	IPAddress   string
+	UserAgent   string
+	ID          int64
+	User        int64
+	CreatedAt   int64
+	ExpiresAt   int64
+	LastSeenAt  int64
+	IdleTimeout int64
+}
+
+// session has a value receiver, the result does not alias the stored row.
+func (r sessionRow) session() *store.Session {
+	return &store.Session{
+		ID:          &r.ID,
+		UserID:      &r.User,
+		CreatedAt:   time.UnixMilli(r.CreatedAt),
+		ExpiresAt:   time.UnixMilli(r.ExpiresAt),
+		LastSeenAt:  time.UnixMilli(r.LastSeenAt),
+		IdleTimeout: time.Duration(r.IdleTimeout) * time.Millisecond,
+		IPAddress:   &r.IPAddress,
+		UserAgent:   &r.UserAgent,
+	}
+}
+
+// CreateSession implements store.SessionStore.
+func (s *MemoryAccountStore) CreateSession(ctx context.Context, user *store.User, meta store.SessionMeta) (string, *store.Session, error) {
+	if user == nil || user.ID == nil {
+		return "", nil, fmt.Errorf("error insert session: user without id")
+	}
+	if err := ctx.Err(); err != nil {
+		return "", nil, err
+	}
+	token, hash, err := store.NewSessionToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("error insert session token: %w", err)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.users[*user.ID]; !ok {
+		return "", nil, fmt.Errorf("error insert session user.id %d: %w", *user.ID, store.ErrNotFound)
+	}
+	now := time.Now().UnixMilli()
+	s.lastSessionID++
+	row := &sessionRow{
+		ID:          s.lastSessionID,
+		User:        *user.ID,
+		CreatedAt:   now,
+		ExpiresAt:   now + s.session.GetTTL().Milliseconds(),
+		LastSeenAt:  now,
+		IdleTimeout: s.session.GetIdleTimeout().Milliseconds(),
+		IPAddress:   meta.IPAddress,
+		UserAgent:   meta.UserAgent,
+	}
+	s.sessions[hash] = row
+	return token, row.session(), nil
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// RevokeSession implements store.SessionStore.
+func (s *MemoryAccountStore) RevokeSession(ctx context.Context, token string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	hash := store.SessionTokenHash(token)
+	if _, ok := s.sessions[hash]; !ok {
+		return fmt.Errorf("error delete session affected 0: %w", store.ErrNotFound)
+	}
+	delete(s.sessions, hash)
+	return nil
+}
+
+// RevokeUserSessions implements store.SessionStore.
+func (s *MemoryAccountStore) RevokeUserSessions(ctx context.Context, userID int64) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var revoked int64
+	for hash, row := range s.sessions {
+		if row.User == userID {
+			delete(s.sessions, hash)
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+// PurgeExpiredSessions implements store.SessionStore.
+func (s *MemoryAccountStore) PurgeExpiredSessions(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	var purged int64
+	for hash, row := range s.sessions {
+		if row.session().Expired(now) {
+			delete(s.sessions, hash)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// getSession must be called with the mutex held.
+func (s *MemoryAccountStore) getSession(token string) (*sessionRow, error) {
+	row, ok := s.sessions[store.SessionTokenHash(token)]
+	if !ok {
+		return nil, fmt.Errorf("error get session: %w", store.ErrNotFound)
+	}
+	if row.session().Expired(time.Now()) {
+		return nil, fmt.Errorf("error get session.id %d: %w", row.ID, store.ErrSessionExpired)
+	}
+	return row, nil
+}
+
+// GetSession implements store.SessionStore.
+func (s *MemoryAccountStore) GetSession(ctx context.Context, token string) (*store.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	row, err := s.getSession(token)
+	if err != nil {
+		return nil, err
+	}
+	return row.session(), nil
+}
+
+// RefreshSession implements store.SessionStore.
+func (s *MemoryAccountStore) RefreshSession(ctx context.Context, token string) (*store.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	row, err := s.getSession(token)
+	if err != nil {
+		return nil, err
+	}
+	row.LastSeenAt = time.Now().UnixMilli()
+	return row.session(), nil
+}
@@ -0,0 +1,18 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/senomas/gohtmx/store/storetest"
+)
+
+func TestMemoryAccountStoreSuite(t *testing.T) {
+	storetest.RunAccountStoreSuite(t, func(t *testing.T, cfg store.Config) store.AccountStore {
+		accountStore, err := store.GetAccountStore("memory", cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return accountStore
+	})
+}
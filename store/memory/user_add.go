@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// AddUsers implements store.store.
+func (s *MemoryAccountStore) AddUsers(users []*store.User) ([]*store.User, error) {
+	return s.AddUsersContext(context.Background(), users)
+}
+
+// AddUsersContext implements store.store.
+func (s *MemoryAccountStore) AddUsersContext(ctx context.Context, users []*store.User) ([]*store.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// every user is checked before any is stored, like a rolled back transaction
+	rows := []*userRow{}
+	privilegeIDs := [][]int64{}
+	roleIDs := [][]int64{}
+	names := map[string]bool{}
+	emails := map[string]bool{}
+	for i, user := range users {
+		if password, ok := user.PlainPassword(); ok {
+			if err := s.passwordPolicy.Validate(password); err != nil {
+				return nil, fmt.Errorf("error insert user%s password: %w", s.ValueString(user), err)
+			}
+			hash, err := store.HashPasswordWith(s.passwordHasher, password)
+			if err != nil {
+				return nil, fmt.Errorf("error insert user%s password: %w", s.ValueString(user), err)
+			}
+			user.Password = &hash
+		}
+		if user.Name == nil || user.Email == nil || user.Password == nil {
+			return nil, fmt.Errorf("error insert user%s: name, email and password are required", s.ValueString(user))
+		}
+		if names[*user.Name] || s.userByName(*user.Name) != nil {
+			return nil, fmt.Errorf("error insert user%s: %w", s.ValueString(user),
+				&store.DuplicateError{Table: "user", Field: "name", Value: *user.Name})
+		}
+		if emails[*user.Email] || s.userByEmail(*user.Email) != nil {
+			return nil, fmt.Errorf("error insert user%s: %w", s.ValueString(user),
+				&store.DuplicateError{Table: "user", Field: "email", Value: *user.Email})
+		}
+		names[*user.Name] = true
+		emails[*user.Email] = true
+		row := &userRow{id: s.lastUserID + int64(i) + 1, name: *user.Name, email: *user.Email, password: *user.Password, version: 1}
+		pids := []int64{}
+		if user.Privileges != nil {
+			for _, p := range *user.Privileges {
+				privilege := s.privilegeByName(*p.Name)
+				if privilege == nil {
+					return nil, fmt.Errorf("error get privilege name '%s': %w", *p.Name, store.ErrNotFound)
+				}
+				if containsID(pids, privilege.id) {
+					return nil, fmt.Errorf("error insert user_privilege%s: %w", s.ValueString(p),
+						&store.DuplicateError{Table: "user_privilege", Field: "privilege", Value: *p.Name})
+				}
+				pids = append(pids, privilege.id)
+			}
+		}
+		rids := []int64{}
+		if user.Roles != nil {
+			var err error
+			if rids, err = s.roleIDsByName(*user.Roles); err != nil {
+				return nil, err
+			}
+		}
+		rows = append(rows, row)
+		privilegeIDs = append(privilegeIDs, pids)
+		roleIDs = append(roleIDs, rids)
+	}
+	res := []*store.User{}
+	events := []*store.AuditEvent{}
+	for i, user := range users {
+		row := rows[i]
+		s.users[row.id] = row
+		s.lastUserID = row.id
+		id, version := row.id, row.version
+		user.ID = &id
+		user.Version = &version
+		if user.Privileges != nil {
+			privileges := []*store.Privilege{}
+			for _, pid := range privilegeIDs[i] {
+				s.userPrivileges[row.id] = appendID(s.userPrivileges[row.id], pid)
+				privileges = append(privileges, s.privileges[pid].privilege())
+			}
+			user.Privileges = &privileges
+		}
+		if user.Roles != nil {
+			roles := []*store.Role{}
+			for _, rid := range roleIDs[i] {
+				s.userRoles[row.id] = appendID(s.userRoles[row.id], rid)
+				roles = append(roles, s.roles[rid].role())
+			}
+			user.Roles = &roles
+		}
+		res = append(res, user)
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_USER_ADD, "user", id, nil, store.UserAuditFields(user)))
+	}
+	if err := s.audit(events...); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// removeIDs returns ids without those in removed.
+func removeIDs(ids []int64, removed map[int64]bool) []int64 {
+	res := []int64{}
+	for _, id := range ids {
+		if !removed[id] {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// appendID inserts id into the ordered ids unless it is already there.
+func appendID(ids []int64, id int64) []int64 {
+	for i, v := range ids {
+		if v == id {
+			return ids
+		}
+		if v > id {
+			return append(ids[:i], append([]int64{id}, ids[i:]...)...)
+		}
+	}
+	return append(ids, id)
+}
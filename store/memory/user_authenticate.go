@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// Authenticate implements store.store.
+func (s *MemoryAccountStore) Authenticate(nameOrEmail string, password string) (*store.User, error) {
+	return s.AuthenticateContext(context.Background(), nameOrEmail, password)
+}
+
+// AuthenticateContext implements store.store.
+func (s *MemoryAccountStore) AuthenticateContext(ctx context.Context, nameOrEmail string, password string) (*store.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// an overlong password is rejected before it is hashed
+	if s.passwordPolicy.CheckLength(password) != nil {
+		return nil, fmt.Errorf("error authenticate '%s': %w", nameOrEmail, store.ErrInvalidCredentials)
+	}
+	// the password is verified and rehashed outside the lock, a slow hasher
+	// does not hold up the other callers
+	s.mutex.RLock()
+	// a name match wins over an email match
+	row := s.userByName(nameOrEmail)
+	if row == nil {
+		row = s.userByEmail(nameOrEmail)
+	}
+	var id int64
+	var current string
+	if row != nil {
+		id, current = row.id, row.password
+	}
+	s.mutex.RUnlock()
+	if row == nil {
+		store.DummyVerifyPassword(s.passwordHasher, password)
+		return nil, fmt.Errorf("error authenticate '%s': %w", nameOrEmail, store.ErrInvalidCredentials)
+	}
+	ok, err := store.VerifyPassword(password, current)
+	if err != nil {
+		return nil, fmt.Errorf("error authenticate user.id %d: %w", id, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("error authenticate '%s': %w", nameOrEmail, store.ErrInvalidCredentials)
+	}
+	rehash := ""
+	if s.passwordHasher.NeedsRehash(current) {
+		rehash, err = store.HashPasswordWith(s.passwordHasher, password)
+		if err != nil {
+			return nil, fmt.Errorf("error authenticate user.id %d: %w", id, err)
+		}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// the user may be deleted or its password changed since it was verified
+	row = s.liveUser(id)
+	if row == nil || row.password != current {
+		return nil, fmt.Errorf("error authenticate '%s': %w", nameOrEmail, store.ErrInvalidCredentials)
+	}
+	if rehash != "" {
+		row.password = rehash
+	}
+	lastLogin := time.Now().UnixMilli()
+	row.lastLogin = &lastLogin
+	user := row.user()
+	user.Privileges = s.userPrivilegeList(row.id)
+	user.Roles = s.userRoleList(row.id)
+	privileges := store.EffectivePrivileges(s.effectivePrivileges(row.id))
+	user.EffectivePrivileges = &privileges
+	return user, nil
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// DeleteUsers implements store.store.
+func (s *MemoryAccountStore) DeleteUsers(ids []int64) error {
+	return s.DeleteUsersContext(context.Background(), ids)
+}
+
+// DeleteUsersContext implements store.store.
+func (s *MemoryAccountStore) DeleteUsersContext(ctx context.Context, ids []int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	found := map[int64]bool{}
+	for _, id := range ids {
+		if s.liveUser(id) != nil {
+			found[id] = true
+		}
+	}
+	if len(found) != len(ids) {
+		return fmt.Errorf("error delete user.id%s affected %v: %w", s.ValueString(ids), len(found), store.ErrNotFound)
+	}
+	events := []*store.AuditEvent{}
+	for _, id := range ids {
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_USER_DELETE, "user", id, s.auditUser(id), nil))
+	}
+	// the privileges and roles are kept for RestoreUsers
+	deletedAt := time.Now().UnixMilli()
+	for id := range found {
+		s.users[id].deletedAt = &deletedAt
+		s.deleteUserSessions(id)
+	}
+	return s.audit(events...)
+}
+
+// deleteUserSessions deletes the sessions of the user id.
+func (s *MemoryAccountStore) deleteUserSessions(id int64) {
+	for hash, session := range s.sessions {
+		if session.User == id {
+			delete(s.sessions, hash)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+func (r *userRow) values() map[string]interface{} {
+	values := map[string]interface{}{"id": r.id, "name": r.name, "email": r.email}
+	if r.deletedAt != nil {
+		values["deleted_at"] = *r.deletedAt
+	}
+	return values
+}
+
+// FindUsers implements store.store.
+func (s *MemoryAccountStore) FindUsers(f *store.UserFilter, offset int64, limit int) ([]*store.User, int64, error) {
+	return s.FindUsersContext(context.Background(), f, offset, limit)
+}
+
+// FindUsersContext implements store.store.
+func (s *MemoryAccountStore) FindUsersContext(ctx context.Context, f *store.UserFilter, offset int64, limit int) ([]*store.User, int64, error) {
+	where := filter{}
+	s.userWhere(&where, f)
+	liveUserWhere(&where, f)
+
+	if !s.ValidLimit(limit) {
+		return nil, 0, fmt.Errorf("%w %d", store.ErrInvalidLimit, limit)
+	}
+	sort, err := f.Sort.Resolve(store.UserSortFields)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	rows := []*userRow{}
+	for _, id := range sortedIDs(s.users) {
+		row := s.users[id]
+		if where.Match(row.values()) {
+			rows = append(rows, row)
+		}
+	}
+	sortRows(rows, (*userRow).values, sort)
+	users := []*store.User{}
+	for i := max(offset, 0); i < int64(len(rows)) && len(users) < limit; i++ {
+		users = append(users, s.findUser(rows[i], f))
+	}
+	return users, int64(len(rows)), nil
+}
+
+// userWhere adds the conditions of f and its nested groups to where.
+func (s *MemoryAccountStore) userWhere(where *filter, f *store.UserFilter) {
+	where.Int64("id", f.ID)
+	where.String("name", f.Name)
+	where.String("email", f.Email)
+	s.userPrivilegeWhere(where, f.Privilege)
+	for _, g := range f.And {
+		s.userWhere(where, g)
+	}
+	ors := []func(where *filter){}
+	for _, g := range f.Or {
+		g := g
+		ors = append(ors, func(where *filter) { s.userWhere(where, g) })
+	}
+	where.Or(ors...)
+	if f.Not != nil {
+		where.Not(func(where *filter) { s.userWhere(where, f.Not) })
+	}
+}
+
+// liveUserWhere hides the soft deleted users unless f includes them.
+func liveUserWhere(where *filter, f *store.UserFilter) {
+	if !f.WithDeleted {
+		where.null("deleted_at", true)
+	}
+}
+
+// userPrivilegeWhere adds the conditions of f on the privileges the user
+// holds, see heldPrivileges, the matcher reads the store and runs under its
+// lock.
+func (s *MemoryAccountStore) userPrivilegeWhere(where *filter, f store.FilterPrivilege) {
+	switch f.Op {
+	case store.OP_NOP:
+		return
+	case store.OP_ANY, store.OP_ALL, store.OP_NONE:
+	default:
+		panic(fmt.Errorf("invalid op privilege: %+v", f))
+	}
+	where.add(func(values map[string]interface{}) bool {
+		names := map[string]bool{}
+		ids := s.heldPrivileges(values["id"].(int64))
+		for id := range ids {
+			names[s.privileges[id].name] = true
+		}
+		held := 0
+		for _, v := range f.Names {
+			if names[v] {
+				held++
+			}
+		}
+		for _, v := range f.IDs {
+			if ids[v] {
+				held++
+			}
+		}
+		switch f.Op {
+		case store.OP_ANY:
+			return held > 0
+		case store.OP_ALL:
+			return held == len(f.Names)+len(f.IDs)
+		}
+		return held == 0
+	})
+}
+
+// heldPrivileges returns the ids of the privileges a user holds, granted
+// directly, through a role or implied by one held.
+func (s *MemoryAccountStore) heldPrivileges(userID int64) map[int64]bool {
+	held := map[int64]bool{}
+	pending := append([]int64{}, s.userPrivileges[userID]...)
+	for _, rid := range s.userRoles[userID] {
+		pending = append(pending, s.rolePrivileges[rid]...)
+	}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if held[id] {
+			continue
+		}
+		held[id] = true
+		pending = append(pending, s.privilegeImplies[id]...)
+	}
+	return held
+}
+
+// findUser returns the user of row, with its privileges when f asks for them.
+func (s *MemoryAccountStore) findUser(row *userRow, f *store.UserFilter) *store.User {
+	u := row.user()
+	if f.WithPrivileges {
+		u.Privileges = s.userPrivilegeList(row.id)
+	}
+	return u
+}
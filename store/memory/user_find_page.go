@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// FindUsersPage implements store.store.
+func (s *MemoryAccountStore) FindUsersPage(f *store.UserFilter, page store.Page) (*store.UserPage, error) {
+	return s.FindUsersPageContext(context.Background(), f, page)
+}
+
+// FindUsersPageContext implements store.store, the total is always exact.
+func (s *MemoryAccountStore) FindUsersPageContext(ctx context.Context, f *store.UserFilter, page store.Page) (*store.UserPage, error) {
+	if !s.ValidLimit(page.Limit) {
+		return nil, fmt.Errorf("%w %d", store.ErrInvalidLimit, page.Limit)
+	}
+	sort, err := f.Sort.Resolve(store.UserSortFields)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := page.ParseCursor(sort)
+	if err != nil {
+		return nil, err
+	}
+	where := filter{}
+	s.userWhere(&where, f)
+	liveUserWhere(&where, f)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var total int64
+	keyset := filter{}
+	order := sort
+	if cursor != nil {
+		keyset.Keyset(sort, cursor)
+		if cursor.Prev {
+			order = sort.Reverse()
+		}
+	}
+	rows := []*userRow{}
+	for _, id := range sortedIDs(s.users) {
+		row := s.users[id]
+		if !where.Match(row.values()) {
+			continue
+		}
+		total++
+		if keyset.Match(row.values()) {
+			rows = append(rows, row)
+		}
+	}
+	sortRows(rows, (*userRow).values, order)
+	users := []*store.User{}
+	for i := 0; i < len(rows) && i <= page.Limit; i++ {
+		users = append(users, s.findUser(rows[i], f))
+	}
+	res := store.NewUserPage(users, sort, cursor, page.Limit)
+	if page.Count != store.COUNT_NONE {
+		res.Total = &total
+	}
+	return res, nil
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// GetUser implements store.store.
+func (s *MemoryAccountStore) GetUser(id int64) (*store.User, error) {
+	return s.GetUserContext(context.Background(), id)
+}
+
+// GetUserContext implements store.store.
+func (s *MemoryAccountStore) GetUserContext(ctx context.Context, id int64) (*store.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	row := s.liveUser(id)
+	if row == nil {
+		return nil, fmt.Errorf("error get user.id %d: %w", id, store.ErrNotFound)
+	}
+	user := row.user()
+	user.Privileges = s.userPrivilegeList(row.id)
+	user.Roles = s.userRoleList(row.id)
+	privileges := store.EffectivePrivileges(s.effectivePrivileges(row.id))
+	user.EffectivePrivileges = &privileges
+	return user, nil
+}
+
+// GetUserByName implements store.store.
+func (s *MemoryAccountStore) GetUserByName(name string) (*store.User, error) {
+	return s.GetUserByNameContext(context.Background(), name)
+}
+
+// GetUserByNameContext implements store.store.
+func (s *MemoryAccountStore) GetUserByNameContext(ctx context.Context, name string) (*store.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	row := s.userByName(name)
+	if row == nil {
+		return nil, fmt.Errorf("error get user.name '%s': %w", name, store.ErrNotFound)
+	}
+	user := row.user()
+	user.Privileges = s.userPrivilegeList(row.id)
+	user.Roles = s.userRoleList(row.id)
+	privileges := store.EffectivePrivileges(s.effectivePrivileges(row.id))
+	user.EffectivePrivileges = &privileges
+	return user, nil
+}
+
+// GetUserByEmail implements store.store.
+func (s *MemoryAccountStore) GetUserByEmail(email string) (*store.User, error) {
+	return s.GetUserByEmailContext(context.Background(), email)
+}
+
+// GetUserByEmailContext implements store.store.
+func (s *MemoryAccountStore) GetUserByEmailContext(ctx context.Context, email string) (*store.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	row := s.userByEmail(email)
+	if row == nil {
+		return nil, fmt.Errorf("error get user.email '%s': %w", email, store.ErrNotFound)
+	}
+	user := row.user()
+	user.Privileges = s.userPrivilegeList(row.id)
+	user.Roles = s.userRoleList(row.id)
+	privileges := store.EffectivePrivileges(s.effectivePrivileges(row.id))
+	user.EffectivePrivileges = &privileges
+	return user, nil
+}
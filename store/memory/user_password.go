@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// ChangePassword implements store.store.
+func (s *MemoryAccountStore) ChangePassword(userID int64, oldPassword string, newPassword string) error {
+	return s.ChangePasswordContext(context.Background(), userID, oldPassword, newPassword)
+}
+
+// ChangePasswordContext implements store.store.
+func (s *MemoryAccountStore) ChangePasswordContext(ctx context.Context, userID int64, oldPassword string, newPassword string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// the lengths are checked before the old password is verified
+	if s.passwordPolicy.CheckLength(oldPassword) != nil {
+		return fmt.Errorf("error verify user.id %d password: %w", userID, store.ErrInvalidCredentials)
+	}
+	if err := s.passwordPolicy.CheckLength(newPassword); err != nil {
+		return fmt.Errorf("error update user.id %d password: %w", userID, err)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	row := s.liveUser(userID)
+	if row == nil {
+		return fmt.Errorf("error get user.id %d: %w", userID, store.ErrNotFound)
+	}
+	ok, err := store.VerifyPassword(oldPassword, row.password)
+	if err != nil {
+		return fmt.Errorf("error verify user.id %d password: %w", userID, err)
+	}
+	if !ok {
+		return fmt.Errorf("error verify user.id %d password: %w", userID, store.ErrInvalidCredentials)
+	}
+	return s.updatePassword(ctx, row, newPassword)
+}
+
+// SetPassword implements store.store.
+func (s *MemoryAccountStore) SetPassword(userID int64, newPassword string) error {
+	return s.SetPasswordContext(context.Background(), userID, newPassword)
+}
+
+// SetPasswordContext implements store.store.
+func (s *MemoryAccountStore) SetPasswordContext(ctx context.Context, userID int64, newPassword string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	row := s.liveUser(userID)
+	if row == nil {
+		return fmt.Errorf("error get user.id %d: %w", userID, store.ErrNotFound)
+	}
+	return s.updatePassword(ctx, row, newPassword)
+}
+
+// updatePassword replaces the current password hash of a user by the hash of
+// newPassword, enforcing the password policy, keeping the password history
+// and auditing the change.
+func (s *MemoryAccountStore) updatePassword(ctx context.Context, row *userRow, newPassword string) error {
+	policy := s.passwordPolicy
+	err := policy.Validate(newPassword)
+	if err != nil {
+		return fmt.Errorf("error update user.id %d password: %w", row.id, err)
+	}
+	if policy.History > 0 {
+		err = policy.ValidateHistory(newPassword, append([]string{row.password}, s.passwordHistory[row.id]...))
+		if err != nil {
+			return fmt.Errorf("error update user.id %d password: %w", row.id, err)
+		}
+	}
+	// hashed before the history changes, a failed update leaves it as it was
+	hash, err := store.HashPasswordWith(s.passwordHasher, newPassword)
+	if err != nil {
+		return fmt.Errorf("error update user.id %d password: %w", row.id, err)
+	}
+	if policy.History > 1 {
+		history := append([]string{row.password}, s.passwordHistory[row.id]...)
+		if len(history) > policy.History-1 {
+			history = history[:policy.History-1]
+		}
+		s.passwordHistory[row.id] = history
+	}
+	row.password = hash
+	return s.audit(store.NewPasswordAuditEvent(ctx, row.id))
+}
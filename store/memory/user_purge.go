@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// PurgeUsers implements store.store.
+func (s *MemoryAccountStore) PurgeUsers(olderThan time.Duration) (int64, error) {
+	return s.PurgeUsersContext(context.Background(), olderThan)
+}
+
+// PurgeUsersContext implements store.store.
+func (s *MemoryAccountStore) PurgeUsersContext(ctx context.Context, olderThan time.Duration) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	before := time.Now().Add(-olderThan).UnixMilli()
+	ids := []int64{}
+	for _, id := range sortedIDs(s.users) {
+		if row := s.users[id]; row.deletedAt != nil && *row.deletedAt <= before {
+			ids = append(ids, id)
+		}
+	}
+	events := []*store.AuditEvent{}
+	for _, id := range ids {
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_USER_PURGE, "user", id, s.auditUserRow(s.users[id]), nil))
+	}
+	for _, id := range ids {
+		delete(s.users, id)
+		delete(s.userPrivileges, id)
+		delete(s.userRoles, id)
+		delete(s.passwordHistory, id)
+		s.deleteUserSessions(id)
+	}
+	return int64(len(ids)), s.audit(events...)
+}
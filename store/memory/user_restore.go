@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// RestoreUsers implements store.store.
+func (s *MemoryAccountStore) RestoreUsers(ids []int64) error {
+	return s.RestoreUsersContext(context.Background(), ids)
+}
+
+// RestoreUsersContext implements store.store.
+func (s *MemoryAccountStore) RestoreUsersContext(ctx context.Context, ids []int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// every user is checked before any is restored, like a rolled back
+	// transaction
+	restored := map[int64]bool{}
+	names := map[string]bool{}
+	emails := map[string]bool{}
+	for _, id := range ids {
+		row, ok := s.users[id]
+		if !ok || row.deletedAt == nil || restored[id] {
+			return fmt.Errorf("error restore user.id %d: %w", id, store.ErrNotFound)
+		}
+		if names[row.name] || s.userByName(row.name) != nil {
+			return fmt.Errorf("error restore user.id %d: %w", id,
+				&store.DuplicateError{Table: "user", Field: "name", Value: row.name})
+		}
+		if emails[row.email] || s.userByEmail(row.email) != nil {
+			return fmt.Errorf("error restore user.id %d: %w", id,
+				&store.DuplicateError{Table: "user", Field: "email", Value: row.email})
+		}
+		restored[id] = true
+		names[row.name] = true
+		emails[row.email] = true
+	}
+	events := []*store.AuditEvent{}
+	for _, id := range ids {
+		s.users[id].deletedAt = nil
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_USER_RESTORE, "user", id, nil, s.auditUser(id)))
+	}
+	return s.audit(events...)
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// SearchUsers implements store.store.
+func (s *MemoryAccountStore) SearchUsers(query string, limit int) ([]*store.User, error) {
+	return s.SearchUsersContext(context.Background(), query, limit)
+}
+
+// SearchUsersContext implements store.store, a term found in the name scores
+// 2 and one found in the email 1.
+func (s *MemoryAccountStore) SearchUsersContext(ctx context.Context, query string, limit int) ([]*store.User, error) {
+	if !s.ValidLimit(limit) {
+		return nil, fmt.Errorf("%w %d", store.ErrInvalidLimit, limit)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	users := []*store.User{}
+	terms := store.SearchTerms(query)
+	if len(terms) == 0 {
+		return users, nil
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	type match struct {
+		row   *userRow
+		score int
+	}
+	matches := []match{}
+	for _, id := range sortedIDs(s.users) {
+		row := s.users[id]
+		if row.deletedAt != nil {
+			continue
+		}
+		name, email := store.SearchTerms(row.name), store.SearchTerms(row.email)
+		score := 0
+		for _, t := range terms {
+			ts := 0
+			if hasPrefix(name, t) {
+				ts += 2
+			}
+			if hasPrefix(email, t) {
+				ts++
+			}
+			if ts == 0 {
+				score = 0
+				break
+			}
+			score += ts
+		}
+		if score > 0 {
+			matches = append(matches, match{row: row, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	for i := 0; i < len(matches) && i < limit; i++ {
+		users = append(users, matches[i].row.user())
+	}
+	return users, nil
+}
+
+// hasPrefix reports whether one of words starts with prefix.
+func hasPrefix(words []string, prefix string) bool {
+	for _, w := range words {
+		if strings.HasPrefix(w, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// UpdateUser implements store.store.
+func (s *MemoryAccountStore) UpdateUser(user *store.User) error {
+	return s.UpdateUserContext(context.Background(), user)
+}
+
+// UpdateUserContext implements store.store.
+func (s *MemoryAccountStore) UpdateUserContext(ctx context.Context, user *store.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if user.ID == nil {
+		return fmt.Errorf("error update user%s: %w", s.ValueString(user), store.ErrNotFound)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	row := s.liveUser(*user.ID)
+	if row == nil {
+		return fmt.Errorf("error update user%s: %w", s.ValueString(user), store.ErrNotFound)
+	}
+	if user.Version != nil && *user.Version != row.version {
+		return fmt.Errorf("error update user%s: %w", s.ValueString(user),
+			&store.ConflictError{Table: "user", ID: row.id, Version: *user.Version})
+	}
+	if user.Name != nil {
+		if u := s.userByName(*user.Name); u != nil && u.id != row.id {
+			return fmt.Errorf("error update user%s: %w", s.ValueString(user),
+				&store.DuplicateError{Table: "user", Field: "name", Value: *user.Name})
+		}
+	}
+	if user.Email != nil {
+		if u := s.userByEmail(*user.Email); u != nil && u.id != row.id {
+			return fmt.Errorf("error update user%s: %w", s.ValueString(user),
+				&store.DuplicateError{Table: "user", Field: "email", Value: *user.Email})
+		}
+	}
+	rids := []int64{}
+	if user.Roles != nil {
+		var err error
+		if rids, err = s.roleIDsByName(*user.Roles); err != nil {
+			return err
+		}
+	}
+	before := s.auditUser(row.id)
+	if user.Name != nil {
+		row.name = *user.Name
+	}
+	if user.Email != nil {
+		row.email = *user.Email
+	}
+	if user.Privileges != nil {
+		// unknown privilege names are skipped, as the sql backends do
+		pids := []int64{}
+		for _, p := range *user.Privileges {
+			if privilege := s.privilegeByName(*p.Name); privilege != nil {
+				pids = appendID(pids, privilege.id)
+			}
+		}
+		s.userPrivileges[row.id] = pids
+	}
+	if user.Roles != nil {
+		roles := []int64{}
+		for _, rid := range rids {
+			roles = appendID(roles, rid)
+		}
+		s.userRoles[row.id] = roles
+	}
+	row.version++
+	version := row.version
+	user.Version = &version
+	return s.audit(store.NewAuditEvent(ctx, store.AUDIT_USER_UPDATE, "user", row.id, before, s.auditUser(row.id)))
+}
@@ -0,0 +1,302 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrMigrationLocked is returned when another instance holds the migration
+// lock for longer than Migrator.LockTimeout.
+var ErrMigrationLocked = errors.New("schema migration locked")
+
+// Migration is a numbered schema change, Up statements apply it and Down
+// statements revert it. Migrations without Down statements can not be reverted.
+type Migration struct {
+	Name    string
+	Up      []string
+	Down    []string
+	Version int64
+}
+
+// Migrator applies migrations and records them in the schema_migrations table.
+// Only one Migrator, across every process sharing the database, runs at a time.
+type Migrator struct {
+	db         *sqlx.DB
+	owner      string
+	migrations []Migration
+	// LockTimeout is how long to wait for the migration lock.
+	LockTimeout time.Duration
+	// LockExpiry is the age after which a lock left by a crashed instance is
+	// taken over.
+	LockExpiry time.Duration
+}
+
+func NewMigrator(db *sqlx.DB, migrations []Migration) *Migrator {
+	ms := append([]Migration{}, migrations...)
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Version < ms[j].Version })
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return &Migrator{
+		db:          db,
+		owner:       hex.EncodeToString(b),
+		migrations:  ms,
+		LockTimeout: time.Minute,
+		LockExpiry:  15 * time.Minute,
+	}
+}
+
+// Latest returns the highest known migration version.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the current schema version, 0 when nothing is applied.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	if err := m.setup(ctx); err != nil {
+		return 0, err
+	}
+	var version int64
+	err := m.db.GetContext(ctx, &version, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations")
+	if err != nil {
+		return 0, fmt.Errorf("error get schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.Migrate(ctx, m.Latest())
+}
+
+// Down reverts the last applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	current, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	var target int64
+	for _, mg := range m.migrations {
+		if mg.Version < current {
+			target = mg.Version
+		}
+	}
+	return m.Migrate(ctx, target)
+}
+
+// Migrate applies or reverts migrations until the schema is at version.
+func (m *Migrator) Migrate(ctx context.Context, version int64) error {
+	for i := 1; i < len(m.migrations); i++ {
+		if m.migrations[i].Version == m.migrations[i-1].Version {
+			return fmt.Errorf("error migrate: duplicate migration version %d", m.migrations[i].Version)
+		}
+	}
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("error migrate: unknown migration version %d", version)
+	}
+	if err := m.setup(ctx); err != nil {
+		return err
+	}
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.unlock()
+	// a migration may outlast LockExpiry, the lock is refreshed meanwhile so
+	// no other instance takes it over
+	stop := make(chan struct{})
+	defer close(stop)
+	go m.refresh(stop)
+
+	applied := []int64{}
+	err := m.db.SelectContext(ctx, &applied, "SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return fmt.Errorf("error select schema_migrations: %w", err)
+	}
+	done := map[int64]bool{}
+	for _, v := range applied {
+		if m.find(v) == nil {
+			return fmt.Errorf("error migrate: applied migration version %d is unknown", v)
+		}
+		done[v] = true
+	}
+	for _, mg := range m.migrations {
+		if mg.Version <= version && !done[mg.Version] {
+			if err := m.touch(ctx); err != nil {
+				return err
+			}
+			if err := m.apply(ctx, mg, mg.Up, true); err != nil {
+				return err
+			}
+		}
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mg := m.migrations[i]
+		if mg.Version > version && done[mg.Version] {
+			if mg.Down == nil {
+				return fmt.Errorf("error migrate down %d %s: migration is irreversible", mg.Version, mg.Name)
+			}
+			if err := m.touch(ctx); err != nil {
+				return err
+			}
+			if err := m.apply(ctx, mg, mg.Down, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// apply runs the statements of one step of mg in a transaction with its
+// schema_migrations change. A database committing DDL implicitly, as mariadb
+// does, keeps the statements run before a failure, its migrations must be
+// safe to run again.
+func (m *Migrator) apply(ctx context.Context, mg Migration, stmts []string, up bool) error {
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for _, qry := range stmts {
+		_, err := tx.ExecContext(ctx, qry)
+		if err != nil {
+			return fmt.Errorf("error migrate %s %d %s: %w\n\n%s", direction, mg.Version, mg.Name, err, qry)
+		}
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, tx.Rebind("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+			mg.Version, mg.Name, time.Now().Unix())
+	} else {
+		_, err = tx.ExecContext(ctx, tx.Rebind("DELETE FROM schema_migrations WHERE version = ?"), mg.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("error migrate %s %d %s: %w", direction, mg.Version, mg.Name, err)
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) setup(ctx context.Context) error {
+	for _, qry := range []string{
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied_at BIGINT NOT NULL
+  )`,
+		`CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+    id INTEGER NOT NULL PRIMARY KEY,
+    owner VARCHAR(64) NOT NULL,
+    locked_at BIGINT NOT NULL
+  )`,
+	} {
+		_, err := m.db.ExecContext(ctx, qry)
+		if err != nil {
+			return fmt.Errorf("error creating table: %w\n\n%s", err, qry)
+		}
+	}
+	return nil
+}
+
+// lock takes the single row of schema_migrations_lock, the primary key makes
+// the insert fail while another instance holds it.
+func (m *Migrator) lock(ctx context.Context) error {
+	deadline := time.Now().Add(m.LockTimeout)
+	for {
+		now := time.Now()
+		_, err := m.db.ExecContext(ctx, m.db.Rebind("DELETE FROM schema_migrations_lock WHERE id = 1 AND locked_at < ?"),
+			now.Add(-m.LockExpiry).Unix())
+		if err != nil {
+			return fmt.Errorf("error expire schema_migrations_lock: %w", err)
+		}
+		_, err = m.db.ExecContext(ctx, m.db.Rebind("INSERT INTO schema_migrations_lock (id, owner, locked_at) VALUES (1, ?, ?)"),
+			m.owner, now.Unix())
+		if err == nil {
+			return nil
+		}
+		var owner string
+		if gerr := m.db.GetContext(ctx, &owner, "SELECT owner FROM schema_migrations_lock WHERE id = 1"); gerr != nil {
+			return fmt.Errorf("error insert schema_migrations_lock: %w", err)
+		}
+		if now.After(deadline) {
+			return fmt.Errorf("error lock schema_migrations by %s: %w", owner, ErrMigrationLocked)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// touch renews the lock of m, it fails when the lock expired and was taken
+// over by another instance.
+func (m *Migrator) touch(ctx context.Context) error {
+	rs, err := m.db.ExecContext(ctx, m.db.Rebind("UPDATE schema_migrations_lock SET locked_at = ? WHERE id = 1 AND owner = ?"),
+		time.Now().Unix(), m.owner)
+	if err != nil {
+		return fmt.Errorf("error update schema_migrations_lock: %w", err)
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error update schema_migrations_lock affected: %w", err)
+	}
+	if affected == 1 {
+		return nil
+	}
+	// mariadb counts changed rows only, a lock taken in the same second is
+	// left as is
+	owned := 0
+	err = m.db.GetContext(ctx, &owned, m.db.Rebind("SELECT COUNT(*) FROM schema_migrations_lock WHERE id = 1 AND owner = ?"), m.owner)
+	if err != nil {
+		return fmt.Errorf("error select schema_migrations_lock: %w", err)
+	}
+	if owned != 1 {
+		return fmt.Errorf("error lock schema_migrations lost: %w", ErrMigrationLocked)
+	}
+	return nil
+}
+
+// refresh touches the lock of m every third of LockExpiry until stop is
+// closed.
+func (m *Migrator) refresh(stop <-chan struct{}) {
+	interval := m.LockExpiry / 3
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			m.touch(context.Background())
+		}
+	}
+}
+
+func (m *Migrator) unlock() {
+	m.db.Exec(m.db.Rebind("DELETE FROM schema_migrations_lock WHERE id = 1 AND owner = ?"), m.owner)
+}
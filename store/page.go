@@ -0,0 +1,205 @@
+package store
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// COUNT_NONE leaves the page total unset.
+	COUNT_NONE = iota
+	// COUNT_EXACT counts the rows matching the filter.
+	COUNT_EXACT
+	// COUNT_ESTIMATE reads the row count from the table statistics when the
+	// filter is empty, where the backend keeps any, and counts otherwise. The
+	// live users are estimated as the table less the soft deleted users.
+	COUNT_ESTIMATE
+)
+
+// Page selects find results by cursor, rows past a cursor stay put when rows
+// are added or removed before it, unlike an offset.
+type Page struct {
+	// Cursor is the Next or Prev cursor of a previous page, empty for the
+	// first page.
+	Cursor string
+	Limit  int
+	Count  int
+}
+
+// Set sets the page from the query values cursor, limit and count, count is
+// "exact" or "estimate".
+func (p *Page) Set(values map[string][]string) {
+	if v, ok := first(values, "cursor"); ok {
+		p.Cursor = v
+	}
+	if v, ok := first(values, "limit"); ok {
+		if vi, err := strconv.Atoi(v); err == nil {
+			p.Limit = vi
+		}
+	}
+	if v, ok := first(values, "count"); ok {
+		switch v {
+		case "exact":
+			p.Count = COUNT_EXACT
+		case "estimate":
+			p.Count = COUNT_ESTIMATE
+		}
+	}
+}
+
+// Cursor is the position after, or before when Prev is set, the row whose
+// sort field values are Values.
+type Cursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+	Prev   bool          `json:"p,omitempty"`
+}
+
+// Encode returns the opaque cursor string.
+func (c *Cursor) Encode() string {
+	bstr, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(bstr)
+}
+
+// ParseCursor returns the cursor of the page, nil for the first page, or
+// ErrInvalidCursor when it is malformed, was made for another sort or holds
+// a value of another type than its sort field.
+func (p Page) ParseCursor(sort Sort) (*Cursor, error) {
+	if p.Cursor == "" {
+		return nil, nil
+	}
+	bstr, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(bstr))
+	dec.UseNumber()
+	c := &Cursor{}
+	if err := dec.Decode(c); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	if c.Sort != sort.String() || len(c.Values) != len(sort) {
+		return nil, fmt.Errorf("%w: sort %q", ErrInvalidCursor, c.Sort)
+	}
+	// the values are compared with the columns, id is the only integer one
+	for i, v := range c.Values {
+		switch v := v.(type) {
+		case string:
+			if sort[i].Field == "id" {
+				return nil, fmt.Errorf("%w: %s value %q", ErrInvalidCursor, sort[i].Field, v)
+			}
+		case json.Number:
+			if sort[i].Field != "id" {
+				return nil, fmt.Errorf("%w: %s value %v", ErrInvalidCursor, sort[i].Field, v)
+			}
+			vi, err := v.Int64()
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+			}
+			c.Values[i] = vi
+		default:
+			return nil, fmt.Errorf("%w: %s value %v", ErrInvalidCursor, sort[i].Field, v)
+		}
+	}
+	return c, nil
+}
+
+// String returns the sort in the query syntax read by Set.
+func (s Sort) String() string {
+	fields := []string{}
+	for _, f := range s {
+		if f.Desc {
+			fields = append(fields, "-"+f.Field)
+		} else {
+			fields = append(fields, f.Field)
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// Reverse returns the sort with every field in the opposite direction.
+func (s Sort) Reverse() Sort {
+	res := Sort{}
+	for _, f := range s {
+		res = append(res, SortField{Field: f.Field, Desc: !f.Desc})
+	}
+	return res
+}
+
+// UserPage is a page of FindUsersPage, Next and Prev are empty when there is
+// no page after or before it.
+type UserPage struct {
+	Total     *int64  `json:"total,omitempty"`
+	Next      string  `json:"next,omitempty"`
+	Prev      string  `json:"prev,omitempty"`
+	Users     []*User `json:"users"`
+	Estimated bool    `json:"estimated,omitempty"`
+}
+
+// PrivilegePage is a page of FindPrivilegesPage.
+type PrivilegePage struct {
+	Total      *int64       `json:"total,omitempty"`
+	Next       string       `json:"next,omitempty"`
+	Prev       string       `json:"prev,omitempty"`
+	Privileges []*Privilege `json:"privileges"`
+	Estimated  bool         `json:"estimated,omitempty"`
+}
+
+// NewUserPage returns the page of users, up to limit+1 rows found in sort
+// order after cursor, or in reverse order before it when cursor is Prev.
+func NewUserPage(users []*User, sort Sort, cursor *Cursor, limit int) *UserPage {
+	res := &UserPage{}
+	res.Users, res.Next, res.Prev = paginate(users, func(u *User) map[string]interface{} {
+		return map[string]interface{}{"id": *u.ID, "name": *u.Name, "email": *u.Email}
+	}, sort, cursor, limit)
+	return res
+}
+
+// NewPrivilegePage returns the page of privileges, see NewUserPage.
+func NewPrivilegePage(privileges []*Privilege, sort Sort, cursor *Cursor, limit int) *PrivilegePage {
+	res := &PrivilegePage{}
+	res.Privileges, res.Next, res.Prev = paginate(privileges, func(p *Privilege) map[string]interface{} {
+		return map[string]interface{}{"id": *p.ID, "name": *p.Name, "description": *p.Description}
+	}, sort, cursor, limit)
+	return res
+}
+
+// paginate trims rows to limit, puts them in sort order and returns the
+// cursors after the last and before the first row. The extra row tells
+// whether there is a page further on, coming back from a cursor there is
+// always a page on its other side. An empty page has no cursors.
+func paginate[T any](rows []T, values func(T) map[string]interface{}, sort Sort, cursor *Cursor, limit int) ([]T, string, string) {
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+	prev := cursor != nil && cursor.Prev
+	if prev {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) == 0 {
+		return rows, "", ""
+	}
+	encode := func(row T, prev bool) string {
+		v := values(row)
+		c := &Cursor{Sort: sort.String(), Prev: prev}
+		for _, f := range sort {
+			c.Values = append(c.Values, v[f.Field])
+		}
+		return c.Encode()
+	}
+	next, before := "", ""
+	if prev || more {
+		next = encode(rows[len(rows)-1], false)
+	}
+	if (prev && more) || (!prev && cursor != nil) {
+		before = encode(rows[0], true)
+	}
+	return rows, next, before
+}
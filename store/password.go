@@ -0,0 +1,288 @@
+package store
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher hashes and verifies passwords in one encoded hash format.
+type PasswordHasher interface {
+	// Hash returns the encoded hash of password.
+	Hash(password string) (string, error)
+	// Verify reports whether password matches encodedHash.
+	Verify(password string, encodedHash string) (bool, error)
+	// NeedsRehash reports whether encodedHash was not made by the hasher with
+	// its current parameters, true for hashes of any other format.
+	NeedsRehash(encodedHash string) bool
+}
+
+// PasswordHasherValidator is a PasswordHasher that checks its parameters,
+// Config.Validate calls it so a bad hasher fails when the store is opened
+// instead of on the first password.
+type PasswordHasherValidator interface {
+	PasswordHasher
+	Validate() error
+}
+
+var passwordHashers = struct {
+	sync.RWMutex
+	defaultHasher PasswordHasher
+	prefixes      []string
+	hashers       map[string]PasswordHasher
+}{
+	defaultHasher: Argon2idHasher{Params: DefaultArgon2Params},
+	hashers:       map[string]PasswordHasher{},
+}
+
+func init() {
+	RegisterPasswordHasher(Argon2idHasher{}, "$argon2id$")
+	RegisterPasswordHasher(BcryptHasher{Cost: bcrypt.DefaultCost}, "$2a$", "$2b$", "$2y$")
+	RegisterPasswordHasher(ScryptHasher{Params: DefaultScryptParams}, "$scrypt$")
+}
+
+// RegisterPasswordHasher registers h to verify encoded hashes starting with
+// any of prefixes.
+func RegisterPasswordHasher(h PasswordHasher, prefixes ...string) {
+	passwordHashers.Lock()
+	defer passwordHashers.Unlock()
+	for _, prefix := range prefixes {
+		if _, ok := passwordHashers.hashers[prefix]; !ok {
+			passwordHashers.prefixes = append(passwordHashers.prefixes, prefix)
+		}
+		passwordHashers.hashers[prefix] = h
+	}
+	// longest prefix first
+	sort.Slice(passwordHashers.prefixes, func(i, j int) bool {
+		return len(passwordHashers.prefixes[i]) > len(passwordHashers.prefixes[j])
+	})
+}
+
+// SetDefaultPasswordHasher sets the hasher of new passwords, hashes of any
+// other hasher are upgraded on the next successful Authenticate.
+func SetDefaultPasswordHasher(h PasswordHasher) {
+	passwordHashers.Lock()
+	defer passwordHashers.Unlock()
+	passwordHashers.defaultHasher = h
+}
+
+func GetDefaultPasswordHasher() PasswordHasher {
+	passwordHashers.RLock()
+	defer passwordHashers.RUnlock()
+	return passwordHashers.defaultHasher
+}
+
+// GetPasswordHasher returns the hasher registered for the format of encodedHash.
+func GetPasswordHasher(encodedHash string) (PasswordHasher, error) {
+	passwordHashers.RLock()
+	defer passwordHashers.RUnlock()
+	for _, prefix := range passwordHashers.prefixes {
+		if strings.HasPrefix(encodedHash, prefix) {
+			return passwordHashers.hashers[prefix], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown format", ErrInvalidHash)
+}
+
+// HashPassword hashes password with the default hasher.
+func HashPassword(password string) (string, error) {
+	return HashPasswordWith(GetDefaultPasswordHasher(), password)
+}
+
+// HashPasswordWith hashes password with h.
+func HashPasswordWith(h PasswordHasher, password string) (string, error) {
+	hash, err := h.Hash(password)
+	if err != nil {
+		return "", fmt.Errorf("error hash password: %w", err)
+	}
+	return hash, nil
+}
+
+// VerifyPassword reports whether password matches encodedHash, an unknown or
+// malformed encodedHash is an ErrInvalidHash error.
+func VerifyPassword(password string, encodedHash string) (bool, error) {
+	h, err := GetPasswordHasher(encodedHash)
+	if err != nil {
+		return false, err
+	}
+	return h.Verify(password, encodedHash)
+}
+
+// NeedsRehash reports whether encodedHash is not made by the default hasher
+// with its current parameters.
+func NeedsRehash(encodedHash string) bool {
+	return GetDefaultPasswordHasher().NeedsRehash(encodedHash)
+}
+
+// Argon2Params are the argon2id parameters of new password hashes.
+type Argon2Params struct {
+	// Memory in KiB.
+	Memory      uint32
+	Iterations  uint32
+	SaltLength  uint32
+	KeyLength   uint32
+	Parallelism uint8
+}
+
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  1,
+	SaltLength:  16,
+	KeyLength:   32,
+	Parallelism: 4,
+}
+
+// Argon2idHasher hashes with Params, DefaultArgon2Params when zero. A store
+// takes its own through Config.PasswordHasher, existing hashes are upgraded on
+// the next successful Authenticate.
+type Argon2idHasher struct {
+	Params Argon2Params
+}
+
+func (h Argon2idHasher) params() Argon2Params {
+	if h.Params == (Argon2Params{}) {
+		return DefaultArgon2Params
+	}
+	return h.Params
+}
+
+// Validate checks the parameters of new hashes.
+func (h Argon2idHasher) Validate() error {
+	return h.params().validate()
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	p := h.params()
+	b := make([]byte, p.SaltLength)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	hash := argon2.IDKey([]byte(password), b, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	b64Salt := base64.StdEncoding.EncodeToString(b)
+	b64Hash := base64.StdEncoding.EncodeToString(hash)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Memory, p.Iterations, p.Parallelism, b64Salt, b64Hash), nil
+}
+
+func (Argon2idHasher) Verify(password string, encodedHash string) (bool, error) {
+	p, salt, hash, err := decodeArgon2id(encodedHash)
+	if err != nil {
+		return false, err
+	}
+	comparisonHash := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return subtle.ConstantTimeCompare(hash, comparisonHash) == 1, nil
+}
+
+func (h Argon2idHasher) NeedsRehash(encodedHash string) bool {
+	p, _, _, err := decodeArgon2id(encodedHash)
+	return err != nil || p != h.params()
+}
+
+// Bounds of the argon2id parameters of a stored hash, argon2.IDKey panics
+// outside some and a hash past them costs more than any we make.
+const (
+	maxArgon2Memory     = 1024 * 1024 // KiB
+	maxArgon2Iterations = 64
+	minArgon2Length     = 8
+	maxArgon2Length     = 1024
+)
+
+func decodeArgon2id(encodedHash string) (Argon2Params, []byte, []byte, error) {
+	p := Argon2Params{Memory: 64 * 1024, Iterations: 1, Parallelism: 4}
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, fmt.Errorf("%w: argon2id", ErrInvalidHash)
+	}
+	if parts[1] != "argon2id" {
+		return p, nil, nil, fmt.Errorf("%w: algorithm '%s'", ErrInvalidHash, parts[1])
+	}
+	if parts[2] != fmt.Sprintf("v=%d", argon2.Version) {
+		return p, nil, nil, fmt.Errorf("%w: version '%s' != 'v=%v'", ErrInvalidHash, parts[2], argon2.Version)
+	}
+	for _, pp := range strings.Split(parts[3], ",") {
+		ps := strings.Split(pp, "=")
+		if len(ps) == 2 {
+			v, err := strconv.ParseUint(ps[1], 10, 32)
+			if err != nil {
+				return p, nil, nil, fmt.Errorf("%w: '%s'", ErrInvalidHash, pp)
+			}
+			switch ps[0] {
+			case "m":
+				p.Memory = uint32(v)
+			case "t":
+				p.Iterations = uint32(v)
+			case "p":
+				if v > 255 {
+					return p, nil, nil, fmt.Errorf("%w: '%s'", ErrInvalidHash, pp)
+				}
+				p.Parallelism = uint8(v)
+			}
+		}
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("%w: salt %v", ErrInvalidHash, err)
+	}
+	hash, err := base64.StdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("%w: hash %v", ErrInvalidHash, err)
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(hash))
+	if p.validate() != nil {
+		return p, nil, nil, fmt.Errorf("%w: argon2id parameters '%s'", ErrInvalidHash, parts[3])
+	}
+	return p, salt, hash, nil
+}
+
+// validate checks p against the bounds of the argon2id parameters.
+func (p Argon2Params) validate() error {
+	if p.Iterations < 1 || p.Iterations > maxArgon2Iterations || p.Parallelism < 1 ||
+		p.Memory < 8*uint32(p.Parallelism) || p.Memory > maxArgon2Memory ||
+		p.SaltLength < minArgon2Length || p.SaltLength > maxArgon2Length ||
+		p.KeyLength < minArgon2Length || p.KeyLength > maxArgon2Length {
+		return fmt.Errorf("invalid argon2id parameters %+v", p)
+	}
+	return nil
+}
+
+var dummyHashes = struct {
+	sync.Mutex
+	hashes map[PasswordHasher]string
+}{hashes: map[PasswordHasher]string{}}
+
+// DummyVerifyPassword takes as long as VerifyPassword against a hash of h,
+// call it when there is no user so the timing does not reveal that.
+func DummyVerifyPassword(h PasswordHasher, password string) {
+	// the hash is kept per hasher, a hasher of an incomparable type can not
+	// be a map key and hashes every time
+	keyed := reflect.TypeOf(h).Comparable()
+	dummyHashes.Lock()
+	hash, ok := "", false
+	if keyed {
+		hash, ok = dummyHashes.hashes[h]
+	}
+	if !ok {
+		var err error
+		hash, err = HashPasswordWith(h, "dummy password")
+		if err != nil {
+			// a hasher failing here fails the users it hashes as well
+			dummyHashes.Unlock()
+			return
+		}
+		if keyed {
+			dummyHashes.hashes[h] = hash
+		}
+	}
+	dummyHashes.Unlock()
+	VerifyPassword(password, hash)
+}
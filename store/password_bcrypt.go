@@ -0,0 +1,42 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher verifies the $2a$, $2b$ and $2y$ hashes of imported users.
+type BcryptHasher struct {
+	Cost int
+}
+
+// Validate checks the cost of new hashes.
+func (h BcryptHasher) Validate() error {
+	if h.Cost < bcrypt.MinCost || h.Cost > bcrypt.MaxCost {
+		return fmt.Errorf("invalid bcrypt cost %d", h.Cost)
+	}
+	return nil
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(hash), err
+}
+
+func (h BcryptHasher) Verify(password string, encodedHash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%w: bcrypt %v", ErrInvalidHash, err)
+	}
+	return true, nil
+}
+
+func (h BcryptHasher) NeedsRehash(encodedHash string) bool {
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	return err != nil || cost != h.Cost
+}
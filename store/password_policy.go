@@ -0,0 +1,163 @@
+package store
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	RULE_MIN_LENGTH = "min_length"
+	RULE_MAX_LENGTH = "max_length"
+	RULE_UPPER      = "upper"
+	RULE_LOWER      = "lower"
+	RULE_DIGIT      = "digit"
+	RULE_SYMBOL     = "symbol"
+	RULE_HISTORY    = "history"
+	RULE_BREACHED   = "breached"
+)
+
+// PasswordPolicy is enforced on the plain passwords given to AddUsers,
+// ChangePassword and SetPassword.
+type PasswordPolicy struct {
+	// Breached holds known breached passwords, see LoadBreachedPasswords.
+	Breached map[string]struct{}
+	// MinLength is the minimum number of characters.
+	MinLength int
+	// MaxLength is the maximum number of bytes, it bounds the work of hashing.
+	MaxLength int
+	// History rejects reuse of the last History passwords of a user, the
+	// current one included.
+	History       int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// DefaultPasswordPolicy only rejects empty and overlong passwords.
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength: 1,
+		MaxLength: 1024,
+	}
+}
+
+// PasswordViolation is one failed rule of a PasswordPolicy.
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a password fails,
+// errors.Is(err, ErrPasswordPolicy) reports true for it.
+type PasswordPolicyError struct {
+	Violations []PasswordViolation `json:"violations"`
+}
+
+func (e *PasswordPolicyError) Error() string {
+	msgs := []string{}
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.Message)
+	}
+	return fmt.Sprintf("%v: %s", ErrPasswordPolicy, strings.Join(msgs, ", "))
+}
+
+func (e *PasswordPolicyError) Is(target error) bool {
+	return target == ErrPasswordPolicy
+}
+
+// Validate checks password against every rule but History.
+func (p *PasswordPolicy) Validate(password string) error {
+	violations := []PasswordViolation{}
+	if p.MinLength > 0 && utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, PasswordViolation{
+			Rule: RULE_MIN_LENGTH, Message: fmt.Sprintf("must be at least %d characters", p.MinLength),
+		})
+	}
+	if err := p.CheckLength(password); err != nil {
+		violations = append(violations, err.(*PasswordPolicyError).Violations...)
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		violations = append(violations, PasswordViolation{Rule: RULE_UPPER, Message: "must contain an upper case letter"})
+	}
+	if p.RequireLower && !lower {
+		violations = append(violations, PasswordViolation{Rule: RULE_LOWER, Message: "must contain a lower case letter"})
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, PasswordViolation{Rule: RULE_DIGIT, Message: "must contain a digit"})
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, PasswordViolation{Rule: RULE_SYMBOL, Message: "must contain a symbol"})
+	}
+	if _, ok := p.Breached[password]; ok {
+		violations = append(violations, PasswordViolation{Rule: RULE_BREACHED, Message: "is a known breached password"})
+	}
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// CheckLength checks password against MaxLength only, it is cheap enough to
+// run before a password is hashed or verified.
+func (p *PasswordPolicy) CheckLength(password string) error {
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		return &PasswordPolicyError{Violations: []PasswordViolation{{
+			Rule: RULE_MAX_LENGTH, Message: fmt.Sprintf("must be at most %d bytes", p.MaxLength),
+		}}}
+	}
+	return nil
+}
+
+// ValidateHistory checks password against hashes, the current and previous
+// password hashes of a user, newest first.
+func (p *PasswordPolicy) ValidateHistory(password string, hashes []string) error {
+	for i, hash := range hashes {
+		if i >= p.History {
+			break
+		}
+		if ok, _ := VerifyPassword(password, hash); ok {
+			return &PasswordPolicyError{Violations: []PasswordViolation{{
+				Rule: RULE_HISTORY, Message: fmt.Sprintf("must not be one of the last %d passwords", p.History),
+			}}}
+		}
+	}
+	return nil
+}
+
+// LoadBreachedPasswords reads a breached password list, one password per line.
+func LoadBreachedPasswords(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error open breached passwords [%s]: %w", path, err)
+	}
+	defer f.Close()
+	breached := map[string]struct{}{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			breached[line] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error read breached passwords [%s]: %w", path, err)
+	}
+	return breached, nil
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ScryptParams are the scrypt parameters, N is 1<<LogN.
+type ScryptParams struct {
+	LogN       int
+	R          int
+	P          int
+	SaltLength int
+	KeyLength  int
+}
+
+var DefaultScryptParams = ScryptParams{
+	LogN:       15,
+	R:          8,
+	P:          1,
+	SaltLength: 16,
+	KeyLength:  32,
+}
+
+// ScryptHasher verifies hashes of imported users encoded as
+// $scrypt$ln=<log N>,r=<r>,p=<p>$<base64 salt>$<base64 hash>.
+type ScryptHasher struct {
+	Params ScryptParams
+}
+
+// Validate checks the parameters of new hashes.
+func (h ScryptHasher) Validate() error {
+	return h.Params.validate()
+}
+
+func (h ScryptHasher) Hash(password string) (string, error) {
+	p := h.Params
+	salt := make([]byte, p.SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+	hash, err := scrypt.Key([]byte(password), salt, 1<<p.LogN, p.R, p.P, p.KeyLength)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", p.LogN, p.R, p.P,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash)), nil
+}
+
+func (h ScryptHasher) Verify(password string, encodedHash string) (bool, error) {
+	p, salt, hash, err := decodeScrypt(encodedHash)
+	if err != nil {
+		return false, err
+	}
+	comparisonHash, err := scrypt.Key([]byte(password), salt, 1<<p.LogN, p.R, p.P, p.KeyLength)
+	if err != nil {
+		return false, fmt.Errorf("%w: scrypt %v", ErrInvalidHash, err)
+	}
+	return subtle.ConstantTimeCompare(hash, comparisonHash) == 1, nil
+}
+
+func (h ScryptHasher) NeedsRehash(encodedHash string) bool {
+	p, _, _, err := decodeScrypt(encodedHash)
+	return err != nil || p != h.Params
+}
+
+// Bounds of the scrypt parameters of a stored hash, scrypt takes 128*r<<ln
+// bytes of memory and p times the time.
+const (
+	maxScryptMemory = 1 << 30
+	maxScryptP      = 16
+	maxScryptLength = 1024
+)
+
+func decodeScrypt(encodedHash string) (ScryptParams, []byte, []byte, error) {
+	p := ScryptParams{}
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 5 || parts[1] != "scrypt" {
+		return p, nil, nil, fmt.Errorf("%w: scrypt", ErrInvalidHash)
+	}
+	for _, pp := range strings.Split(parts[2], ",") {
+		ps := strings.Split(pp, "=")
+		if len(ps) != 2 {
+			return p, nil, nil, fmt.Errorf("%w: '%s'", ErrInvalidHash, pp)
+		}
+		v, err := strconv.Atoi(ps[1])
+		if err != nil || v <= 0 {
+			return p, nil, nil, fmt.Errorf("%w: '%s'", ErrInvalidHash, pp)
+		}
+		switch ps[0] {
+		case "ln":
+			p.LogN = v
+		case "r":
+			p.R = v
+		case "p":
+			p.P = v
+		}
+	}
+	if p.LogN == 0 || p.LogN > 30 || p.R == 0 || p.P == 0 || p.P > maxScryptP ||
+		p.R > maxScryptMemory>>(7+p.LogN) {
+		return p, nil, nil, fmt.Errorf("%w: scrypt parameters '%s'", ErrInvalidHash, parts[2])
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(parts[3], "="))
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("%w: salt %v", ErrInvalidHash, err)
+	}
+	hash, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(parts[4], "="))
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("%w: hash %v", ErrInvalidHash, err)
+	}
+	p.SaltLength = len(salt)
+	p.KeyLength = len(hash)
+	if p.SaltLength > maxScryptLength || p.KeyLength == 0 || p.KeyLength > maxScryptLength {
+		return p, nil, nil, fmt.Errorf("%w: scrypt lengths", ErrInvalidHash)
+	}
+	return p, salt, hash, nil
+}
+
+// validate checks p against the bounds of the scrypt parameters of a stored
+// hash.
+func (p ScryptParams) validate() error {
+	if p.LogN <= 0 || p.LogN > 30 || p.R <= 0 || p.P <= 0 || p.P > maxScryptP ||
+		p.R > maxScryptMemory>>(7+p.LogN) ||
+		p.SaltLength < 0 || p.SaltLength > maxScryptLength || p.KeyLength <= 0 || p.KeyLength > maxScryptLength {
+		return fmt.Errorf("invalid scrypt parameters %+v", p)
+	}
+	return nil
+}
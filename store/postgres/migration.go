@@ -0,0 +1,217 @@
+package postgres
+
+import "github.com/senomas/gohtmx/store"
+
+// user is a reserved word in postgres, the user table and columns are quoted.
+var migrations = []store.Migration{
+	{
+		Version: 1,
+		Name:    "create user and privilege",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS "user" (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    password TEXT NOT NULL,
+    UNIQUE(name),
+    UNIQUE(email)
+  )`,
+			`CREATE TABLE IF NOT EXISTS privilege (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    UNIQUE(name)
+  )`,
+			`CREATE TABLE IF NOT EXISTS user_privilege (
+    "user" BIGINT NOT NULL,
+    privilege BIGINT NOT NULL,
+    UNIQUE("user", privilege),
+    FOREIGN KEY("user") REFERENCES "user"(id) ON DELETE CASCADE,
+    FOREIGN KEY(privilege) REFERENCES privilege(id)
+  )`,
+		},
+		Down: []string{
+			`DROP TABLE user_privilege`,
+			`DROP TABLE privilege`,
+			`DROP TABLE "user"`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "create session",
+		Up: []string{
+			`CREATE TABLE session (
+    id BIGSERIAL PRIMARY KEY,
+    "user" BIGINT NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    created_at BIGINT NOT NULL,
+    expires_at BIGINT NOT NULL,
+    last_seen_at BIGINT NOT NULL,
+    idle_timeout BIGINT NOT NULL,
+    ip_address VARCHAR(64) NOT NULL,
+    user_agent TEXT NOT NULL,
+    UNIQUE(token_hash),
+    FOREIGN KEY("user") REFERENCES "user"(id) ON DELETE CASCADE
+  )`,
+			`CREATE INDEX session_expires_at ON session(expires_at)`,
+		},
+		Down: []string{
+			`DROP TABLE session`,
+		},
+	},
+	{
+		Version: 3,
+		Name:    "add user last_login",
+		Up: []string{
+			`ALTER TABLE "user" ADD COLUMN last_login BIGINT NULL`,
+		},
+		Down: []string{
+			`ALTER TABLE "user" DROP COLUMN last_login`,
+		},
+	},
+	{
+		Version: 4,
+		Name:    "create password_history",
+		Up: []string{
+			`CREATE TABLE password_history (
+    id BIGSERIAL PRIMARY KEY,
+    "user" BIGINT NOT NULL,
+    password TEXT NOT NULL,
+    created_at BIGINT NOT NULL,
+    FOREIGN KEY("user") REFERENCES "user"(id) ON DELETE CASCADE
+  )`,
+			`CREATE INDEX password_history_user ON password_history("user")`,
+		},
+		Down: []string{
+			`DROP TABLE password_history`,
+		},
+	},
+	{
+		Version: 5,
+		Name:    "create user_search",
+		Up: []string{
+			`CREATE INDEX user_search ON "user" USING GIN ((` + userSearchVector + `))`,
+		},
+		Down: []string{
+			`DROP INDEX user_search`,
+		},
+	},
+	{
+		Version: 6,
+		Name:    "create role",
+		Up: []string{
+			`CREATE TABLE role (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    UNIQUE(name)
+  )`,
+			`CREATE TABLE role_privilege (
+    role BIGINT NOT NULL,
+    privilege BIGINT NOT NULL,
+    UNIQUE(role, privilege),
+    FOREIGN KEY(role) REFERENCES role(id) ON DELETE CASCADE,
+    FOREIGN KEY(privilege) REFERENCES privilege(id)
+  )`,
+			`CREATE TABLE user_role (
+    "user" BIGINT NOT NULL,
+    role BIGINT NOT NULL,
+    UNIQUE("user", role),
+    FOREIGN KEY("user") REFERENCES "user"(id) ON DELETE CASCADE,
+    FOREIGN KEY(role) REFERENCES role(id)
+  )`,
+			`CREATE INDEX user_role_role ON user_role(role)`,
+		},
+		Down: []string{
+			`DROP TABLE user_role`,
+			`DROP TABLE role_privilege`,
+			`DROP TABLE role`,
+		},
+	},
+	{
+		Version: 7,
+		Name:    "create privilege_implies",
+		Up: []string{
+			`CREATE TABLE privilege_implies (
+    privilege BIGINT NOT NULL,
+    implies BIGINT NOT NULL,
+    UNIQUE(privilege, implies),
+    FOREIGN KEY(privilege) REFERENCES privilege(id) ON DELETE CASCADE,
+    FOREIGN KEY(implies) REFERENCES privilege(id)
+  )`,
+			`CREATE INDEX privilege_implies_implies ON privilege_implies(implies)`,
+		},
+		Down: []string{
+			`DROP TABLE privilege_implies`,
+		},
+	},
+	{
+		Version: 8,
+		Name:    "create audit_log",
+		Up: []string{
+			`CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    created_at BIGINT NOT NULL,
+    actor BIGINT,
+    action TEXT NOT NULL,
+    target TEXT NOT NULL,
+    target_id BIGINT NOT NULL,
+    changes TEXT NOT NULL
+  )`,
+			`CREATE INDEX audit_log_actor ON audit_log(actor)`,
+			`CREATE INDEX audit_log_target ON audit_log(target, target_id)`,
+			`CREATE INDEX audit_log_created_at ON audit_log(created_at)`,
+			`CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+  BEGIN RAISE EXCEPTION 'audit_log is append-only'; END
+  $$ LANGUAGE plpgsql`,
+			`CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+  FOR EACH ROW EXECUTE PROCEDURE audit_log_append_only()`,
+		},
+		Down: []string{
+			`DROP TABLE audit_log`,
+			`DROP FUNCTION audit_log_append_only`,
+		},
+	},
+	{
+		Version: 9,
+		Name:    "add user deleted_at",
+		Up: []string{
+			`ALTER TABLE "user" ADD COLUMN deleted_at BIGINT NULL`,
+			`ALTER TABLE "user" DROP CONSTRAINT user_name_key`,
+			`ALTER TABLE "user" DROP CONSTRAINT user_email_key`,
+			`CREATE UNIQUE INDEX user_name_key ON "user"(name) WHERE deleted_at IS NULL`,
+			`CREATE UNIQUE INDEX user_email_key ON "user"(email) WHERE deleted_at IS NULL`,
+		},
+		Down: []string{
+			`DELETE FROM "user" WHERE deleted_at IS NOT NULL`,
+			`DROP INDEX user_email_key`,
+			`DROP INDEX user_name_key`,
+			`ALTER TABLE "user" ADD CONSTRAINT user_name_key UNIQUE(name)`,
+			`ALTER TABLE "user" ADD CONSTRAINT user_email_key UNIQUE(email)`,
+			`ALTER TABLE "user" DROP COLUMN deleted_at`,
+		},
+	},
+	{
+		Version: 10,
+		Name:    "add user and privilege version",
+		Up: []string{
+			`ALTER TABLE "user" ADD COLUMN version BIGINT NOT NULL DEFAULT 1`,
+			`ALTER TABLE privilege ADD COLUMN version BIGINT NOT NULL DEFAULT 1`,
+		},
+		Down: []string{
+			`ALTER TABLE privilege DROP COLUMN version`,
+			`ALTER TABLE "user" DROP COLUMN version`,
+		},
+	},
+	{
+		Version: 11,
+		Name:    "add user deleted_at index",
+		// the live user estimate counts the soft deleted users
+		Up: []string{
+			`CREATE INDEX user_deleted_at ON "user"(deleted_at) WHERE deleted_at IS NOT NULL`,
+		},
+		Down: []string{
+			`DROP INDEX user_deleted_at`,
+		},
+	},
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// AddPrivileges implements store.Store.
+func (s *PostgresAccountStore) AddPrivileges(privileges []*store.Privilege) ([]*store.Privilege, error) {
+	return s.AddPrivilegesContext(context.Background(), privileges)
+}
+
+// AddPrivilegesContext implements store.Store.
+func (s *PostgresAccountStore) AddPrivilegesContext(ctx context.Context, privileges []*store.Privilege) ([]*store.Privilege, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	ps, err := tx.PrepareNamedContext(ctx, "INSERT INTO privilege (name, description) VALUES (:name, :description) RETURNING id")
+	if err != nil {
+		return nil, fmt.Errorf("error creating PrepareNamed: %w", err)
+	}
+	res := []*store.Privilege{}
+	for _, privilege := range privileges {
+		var id int64
+		err := ps.GetContext(ctx, &id, privilege)
+		if err != nil {
+			if field, v, ok := uniqueViolation(err); ok {
+				return nil, fmt.Errorf("error insert privilege%s: %w", s.ValueString(privilege),
+					&store.DuplicateError{Table: "privilege", Field: field, Value: v})
+			}
+			return nil, fmt.Errorf("error insert privilege%s: %w", s.ValueString(privilege), err)
+		}
+		privilege.ID = &id
+		version := int64(1)
+		privilege.Version = &version
+		res = append(res, privilege)
+	}
+	// implied privileges are looked up once all are added, they may be added
+	// in the same call
+	implies := false
+	for _, privilege := range privileges {
+		if privilege.Implies != nil {
+			v, err := s.setPrivilegeImplies(ctx, tx, *privilege.ID, *privilege.Implies)
+			if err != nil {
+				return nil, err
+			}
+			privilege.Implies = &v
+			implies = true
+		}
+	}
+	if implies {
+		if err := s.checkImplicationCycle(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+	events := []*store.AuditEvent{}
+	for _, privilege := range privileges {
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_PRIVILEGE_ADD, "privilege", *privilege.ID, nil, store.PrivilegeAuditFields(privilege)))
+	}
+	if err := s.audit(ctx, tx, events...); err != nil {
+		return nil, err
+	}
+	err = tx.Commit()
+	return res, err
+}
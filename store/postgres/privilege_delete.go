@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// DeletePrivileges implements store.Store.
+func (s *PostgresAccountStore) DeletePrivileges(ids []int64) error {
+	return s.DeletePrivilegesContext(context.Background(), ids)
+}
+
+// DeletePrivilegesContext implements store.Store.
+func (s *PostgresAccountStore) DeletePrivilegesContext(ctx context.Context, ids []int64) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	events := []*store.AuditEvent{}
+	for _, id := range ids {
+		before, err := s.auditPrivilege(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_PRIVILEGE_DELETE, "privilege", id, before, nil))
+	}
+	args := []interface{}{}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	// the implications of the deleted privileges go first, so one implied by
+	// another deleted privilege is not in use, nor is one held by soft deleted
+	// users only
+	_, err = tx.ExecContext(ctx, "DELETE FROM privilege_implies WHERE privilege IN ("+placeholders(1, len(ids))+")", args...)
+	if err != nil {
+		return fmt.Errorf("error delete privilege_implies.privilege%s: %w", s.ValueString(ids), err)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM user_privilege WHERE privilege IN ("+placeholders(1, len(ids))+`) AND "user" IN (SELECT id FROM "user" WHERE deleted_at IS NOT NULL)`, args...)
+	if err != nil {
+		return fmt.Errorf("error delete user_privilege.privilege%s: %w", s.ValueString(ids), err)
+	}
+	qry := "DELETE FROM privilege WHERE id IN (" + placeholders(1, len(ids)) + ")"
+	rs, err := tx.ExecContext(ctx, qry, args...)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return fmt.Errorf("error delete privilege.id%s: %w", s.ValueString(ids), store.ErrInUse)
+		}
+		return fmt.Errorf("error delete privilege.id%s: %w", s.ValueString(ids), err)
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error delete privilege.id%s affected: %w", s.ValueString(ids), err)
+	}
+	if affected != int64(len(ids)) {
+		return fmt.Errorf("error delete privilege.id%s affected %v: %w", s.ValueString(ids), affected, store.ErrNotFound)
+	}
+	if err := s.audit(ctx, tx, events...); err != nil {
+		return err
+	}
+	err = tx.Commit()
+	return err
+}
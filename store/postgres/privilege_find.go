@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// FindPrivileges implements store.Store.
+func (s *PostgresAccountStore) FindPrivileges(
+	f *store.PrivilegeFilter, offset int64, limit int,
+) ([]*store.Privilege, int64, error) {
+	return s.FindPrivilegesContext(context.Background(), f, offset, limit)
+}
+
+// FindPrivilegesContext implements store.Store.
+func (s *PostgresAccountStore) FindPrivilegesContext(
+	ctx context.Context, f *store.PrivilegeFilter, offset int64, limit int,
+) ([]*store.Privilege, int64, error) {
+	where := filter{}
+	privilegeWhere(&where, f)
+
+	if !s.ValidLimit(limit) {
+		return nil, 0, fmt.Errorf("%w %d", store.ErrInvalidLimit, limit)
+	}
+	sort, err := f.Sort.Resolve(store.PrivilegeSortFields)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	qry := "SELECT count(id) FROM privilege"
+	qry = where.AppendWhere(qry)
+	var total int64
+	err = s.db.GetContext(ctx, &total, qry, where.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	privileges := []*store.Privilege{}
+	qry = "SELECT id, name, description, version FROM privilege"
+	qry = where.AppendWhere(qry)
+	qry += orderBy(sort)
+	qry += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(where.args)+1, len(where.args)+2)
+	args := append(where.args, limit, offset)
+	err = s.db.SelectContext(ctx, &privileges, qry, args...)
+	return privileges, total, err
+}
+
+// privilegeWhere adds the conditions of f and its nested groups to where.
+func privilegeWhere(where *filter, f *store.PrivilegeFilter) {
+	where.Int64("id", f.ID)
+	where.String("name", f.Name)
+	where.String("description", f.Description)
+	for _, g := range f.And {
+		privilegeWhere(where, g)
+	}
+	ors := []func(where *filter){}
+	for _, g := range f.Or {
+		g := g
+		ors = append(ors, func(where *filter) { privilegeWhere(where, g) })
+	}
+	where.Or(ors...)
+	if f.Not != nil {
+		where.Not(func(where *filter) { privilegeWhere(where, f.Not) })
+	}
+}
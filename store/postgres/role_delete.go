@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// DeleteRoles implements store.Store.
+func (s *PostgresAccountStore) DeleteRoles(ids []int64) error {
+	return s.DeleteRolesContext(context.Background(), ids)
+}
+
+// DeleteRolesContext implements store.Store.
+func (s *PostgresAccountStore) DeleteRolesContext(ctx context.Context, ids []int64) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	events := []*store.AuditEvent{}
+	for _, id := range ids {
+		before, err := s.auditRole(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_ROLE_DELETE, "role", id, before, nil))
+	}
+	args := []interface{}{}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	// the soft deleted users do not keep a role in use
+	_, err = tx.ExecContext(ctx, "DELETE FROM user_role WHERE role IN ("+placeholders(1, len(ids))+`) AND "user" IN (SELECT id FROM "user" WHERE deleted_at IS NOT NULL)`, args...)
+	if err != nil {
+		return fmt.Errorf("error delete user_role.role%s: %w", s.ValueString(ids), err)
+	}
+	qry := "DELETE FROM role WHERE id IN (" + placeholders(1, len(ids)) + ")"
+	rs, err := tx.ExecContext(ctx, qry, args...)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return fmt.Errorf("error delete role.id%s: %w", s.ValueString(ids), store.ErrInUse)
+		}
+		return fmt.Errorf("error delete role.id%s: %w", s.ValueString(ids), err)
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error delete role.id%s affected: %w", s.ValueString(ids), err)
+	}
+	if affected != int64(len(ids)) {
+		return fmt.Errorf("error delete role.id%s affected %v: %w", s.ValueString(ids), affected, store.ErrNotFound)
+	}
+	if err := s.audit(ctx, tx, events...); err != nil {
+		return err
+	}
+	err = tx.Commit()
+	return err
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// FindRoles implements store.Store.
+func (s *PostgresAccountStore) FindRoles(
+	f *store.RoleFilter, offset int64, limit int,
+) ([]*store.Role, int64, error) {
+	return s.FindRolesContext(context.Background(), f, offset, limit)
+}
+
+// FindRolesContext implements store.Store.
+func (s *PostgresAccountStore) FindRolesContext(
+	ctx context.Context, f *store.RoleFilter, offset int64, limit int,
+) ([]*store.Role, int64, error) {
+	where := filter{}
+	roleWhere(&where, f)
+
+	if !s.ValidLimit(limit) {
+		return nil, 0, fmt.Errorf("%w %d", store.ErrInvalidLimit, limit)
+	}
+	sort, err := f.Sort.Resolve(store.RoleSortFields)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	qry := "SELECT count(id) FROM role"
+	qry = where.AppendWhere(qry)
+	var total int64
+	err = s.db.GetContext(ctx, &total, qry, where.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	roles := []*store.Role{}
+	qry = "SELECT id, name, description FROM role"
+	qry = where.AppendWhere(qry)
+	qry += orderBy(sort)
+	qry += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(where.args)+1, len(where.args)+2)
+	args := append(where.args, limit, offset)
+	err = s.db.SelectContext(ctx, &roles, qry, args...)
+	return roles, total, err
+}
+
+// roleWhere adds the conditions of f and its nested groups to where.
+func roleWhere(where *filter, f *store.RoleFilter) {
+	where.Int64("id", f.ID)
+	where.String("name", f.Name)
+	where.String("description", f.Description)
+	for _, g := range f.And {
+		roleWhere(where, g)
+	}
+	ors := []func(where *filter){}
+	for _, g := range f.Or {
+		g := g
+		ors = append(ors, func(where *filter) { roleWhere(where, g) })
+	}
+	where.Or(ors...)
+	if f.Not != nil {
+		where.Not(func(where *filter) { roleWhere(where, f.Not) })
+	}
+}
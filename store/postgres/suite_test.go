@@ -0,0 +1,32 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/senomas/gohtmx/store/postgres"
+	"github.com/senomas/gohtmx/store/storetest"
+)
+
+func TestPostgresAccountStoreSuite(t *testing.T) {
+	dsn := startPostgres(t)
+	defer stopPostgres(t)
+
+	storetest.RunAccountStoreSuite(t, func(t *testing.T, cfg store.Config) store.AccountStore {
+		cfg.DSN = dsn
+		accountStore, err := postgres.Open(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// every group starts from an empty schema
+		ctx := context.Background()
+		if err := accountStore.Migrator().Migrate(ctx, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := accountStore.Migrator().Up(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return accountStore
+	})
+}
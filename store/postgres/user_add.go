@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// AddUsers implements store.store.
+func (s *PostgresAccountStore) AddUsers(users []*store.User) ([]*store.User, error) {
+	return s.AddUsersContext(context.Background(), users)
+}
+
+// AddUsersContext implements store.store.
+func (s *PostgresAccountStore) AddUsersContext(ctx context.Context, users []*store.User) ([]*store.User, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	ps, err := tx.PrepareNamedContext(ctx, `INSERT INTO "user" (name, email, password) VALUES (:name, :email, :password) RETURNING id`)
+	if err != nil {
+		return nil, fmt.Errorf("error prepare insert into user: %w", err)
+	}
+	psp, err := tx.PrepareNamedContext(ctx, `INSERT INTO user_privilege ("user", privilege) VALUES (:user, :privilege)`)
+	if err != nil {
+		return nil, fmt.Errorf("error prepare insert into user_privilege: %w", err)
+	}
+	res := []*store.User{}
+	events := []*store.AuditEvent{}
+	for _, user := range users {
+		if password, ok := user.PlainPassword(); ok {
+			if err := s.passwordPolicy.Validate(password); err != nil {
+				return nil, fmt.Errorf("error insert user%s password: %w", s.ValueString(user), err)
+			}
+			hash, err := store.HashPasswordWith(s.passwordHasher, password)
+			if err != nil {
+				return nil, fmt.Errorf("error insert user%s password: %w", s.ValueString(user), err)
+			}
+			user.Password = &hash
+		}
+		var id int64
+		err := ps.GetContext(ctx, &id, user)
+		if err != nil {
+			if field, v, ok := uniqueViolation(err); ok {
+				return nil, fmt.Errorf("error insert user%s: %w", s.ValueString(user),
+					&store.DuplicateError{Table: "user", Field: field, Value: v})
+			}
+			return nil, fmt.Errorf("error insert user%s: %w", s.ValueString(user), err)
+		}
+		user.ID = &id
+		version := int64(1)
+		user.Version = &version
+		if user.Privileges != nil {
+			privileges := []*store.Privilege{}
+			type UserPrivilege struct {
+				User      int64
+				Privilege int64
+			}
+			for _, p := range *user.Privileges {
+				privilege := store.Privilege{}
+				err := tx.GetContext(ctx, &privilege, "SELECT id, name, description, version FROM privilege WHERE name = $1", p.Name)
+				if errors.Is(err, sql.ErrNoRows) {
+					return res, fmt.Errorf("error get privilege name '%s': %w", *p.Name, store.ErrNotFound)
+				}
+				if err != nil {
+					return res, fmt.Errorf("error get privilege name '%s': %w", *p.Name, err)
+				}
+				up := UserPrivilege{User: *user.ID, Privilege: *privilege.ID}
+				rs, err := psp.ExecContext(ctx, up)
+				if err != nil {
+					if _, _, ok := uniqueViolation(err); ok {
+						return nil, fmt.Errorf("error insert user_privilege%s: %w", s.ValueString(up),
+							&store.DuplicateError{Table: "user_privilege", Field: "privilege", Value: *p.Name})
+					}
+					return nil, fmt.Errorf("error insert user_privilege%s: %w", s.ValueString(up), err)
+				}
+				affected, err := rs.RowsAffected()
+				if err != nil {
+					return res, fmt.Errorf("error insert user_privilege%s affected %v: %w", s.ValueString(up), affected, err)
+				}
+				if affected != 1 {
+					return res, fmt.Errorf("error insert user_privilege%s affected %v", s.ValueString(up), affected)
+				}
+				privileges = append(privileges, &privilege)
+			}
+			user.Privileges = &privileges
+		}
+		if user.Roles != nil {
+			roles, err := s.setUserRoles(ctx, tx, id, *user.Roles)
+			if err != nil {
+				return nil, err
+			}
+			user.Roles = &roles
+		}
+		res = append(res, user)
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_USER_ADD, "user", id, nil, store.UserAuditFields(user)))
+	}
+	if err := s.audit(ctx, tx, events...); err != nil {
+		return nil, err
+	}
+	err = tx.Commit()
+	return res, err
+}
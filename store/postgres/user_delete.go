@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// DeleteUsers implements store.store.
+func (s *PostgresAccountStore) DeleteUsers(ids []int64) error {
+	return s.DeleteUsersContext(context.Background(), ids)
+}
+
+// DeleteUsersContext implements store.store.
+func (s *PostgresAccountStore) DeleteUsersContext(ctx context.Context, ids []int64) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	events := []*store.AuditEvent{}
+	for _, id := range ids {
+		before, err := s.auditUser(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_USER_DELETE, "user", id, before, nil))
+	}
+	args := []interface{}{time.Now().UnixMilli()}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	// the privileges and roles are kept for RestoreUsers
+	rs, err := tx.ExecContext(ctx, `UPDATE "user" SET deleted_at = $1 WHERE id IN (`+placeholders(2, len(ids))+") AND deleted_at IS NULL", args...)
+	if err != nil {
+		return fmt.Errorf("error delete user.id%s: %w", s.ValueString(ids), err)
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error delete user.id%s affected: %w", s.ValueString(ids), err)
+	}
+	if affected != int64(len(ids)) {
+		return fmt.Errorf("error delete user.id%s affected %v: %w", s.ValueString(ids), affected, store.ErrNotFound)
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM session WHERE "user" IN (`+placeholders(1, len(ids))+")", args[1:]...)
+	if err != nil {
+		return fmt.Errorf("error delete session.user%s: %w", s.ValueString(ids), err)
+	}
+	if err := s.audit(ctx, tx, events...); err != nil {
+		return err
+	}
+	err = tx.Commit()
+	return err
+}
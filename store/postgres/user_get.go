@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// GetUser implements store.store.
+func (s *PostgresAccountStore) GetUser(id int64) (*store.User, error) {
+	return s.GetUserContext(context.Background(), id)
+}
+
+// GetUserContext implements store.store.
+func (s *PostgresAccountStore) GetUserContext(ctx context.Context, id int64) (*store.User, error) {
+	var user store.User
+	err := s.db.GetContext(ctx, &user, `SELECT id, name, email, password, version FROM "user" WHERE id = $1 AND deleted_at IS NULL`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error get user.id %d: %w", id, store.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	privileges := []*store.Privilege{}
+	err = s.db.SelectContext(ctx, &privileges, `SELECT p.id, p.name, p.description, p.version FROM privilege p JOIN user_privilege up ON p.id = up.privilege WHERE up."user" = $1`, id)
+	user.Privileges = &privileges
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRoles(ctx, &user); err != nil {
+		return nil, err
+	}
+	return &user, s.userEffectivePrivileges(ctx, &user)
+}
+
+// GetUserByName implements store.store.
+func (s *PostgresAccountStore) GetUserByName(name string) (*store.User, error) {
+	return s.GetUserByNameContext(context.Background(), name)
+}
+
+// GetUserByNameContext implements store.store.
+func (s *PostgresAccountStore) GetUserByNameContext(ctx context.Context, name string) (*store.User, error) {
+	var user store.User
+	err := s.db.GetContext(ctx, &user, `SELECT id, name, email, password, version FROM "user" WHERE name = $1 AND deleted_at IS NULL`, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error get user.name '%s': %w", name, store.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	privileges := []*store.Privilege{}
+	err = s.db.SelectContext(ctx, &privileges, `SELECT p.id, p.name, p.description, p.version FROM privilege p JOIN user_privilege up ON p.id = up.privilege WHERE up."user" = $1`, user.ID)
+	user.Privileges = &privileges
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRoles(ctx, &user); err != nil {
+		return nil, err
+	}
+	return &user, s.userEffectivePrivileges(ctx, &user)
+}
+
+// GetUserByEmail implements store.store.
+func (s *PostgresAccountStore) GetUserByEmail(email string) (*store.User, error) {
+	return s.GetUserByEmailContext(context.Background(), email)
+}
+
+// GetUserByEmailContext implements store.store.
+func (s *PostgresAccountStore) GetUserByEmailContext(ctx context.Context, email string) (*store.User, error) {
+	var user store.User
+	err := s.db.GetContext(ctx, &user, `SELECT id, name, email, password, version FROM "user" WHERE email = $1 AND deleted_at IS NULL`, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error get user.email '%s': %w", email, store.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	privileges := []*store.Privilege{}
+	err = s.db.SelectContext(ctx, &privileges, `SELECT p.id, p.name, p.description, p.version FROM privilege p JOIN user_privilege up ON p.id = up.privilege WHERE up."user" = $1`, user.ID)
+	user.Privileges = &privileges
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRoles(ctx, &user); err != nil {
+		return nil, err
+	}
+	return &user, s.userEffectivePrivileges(ctx, &user)
+}
+
+// userRoles sets the roles of user, ordered by id, without their privileges.
+func (s *PostgresAccountStore) userRoles(ctx context.Context, user *store.User) error {
+	roles := []*store.Role{}
+	err := s.db.SelectContext(ctx, &roles, `SELECT r.id, r.name, r.description FROM role r JOIN user_role ur ON r.id = ur.role WHERE ur."user" = $1 ORDER BY r.id`, user.ID)
+	user.Roles = &roles
+	return err
+}
+
+// userEffectivePrivileges sets the effective privileges of user, see
+// store.User.
+func (s *PostgresAccountStore) userEffectivePrivileges(ctx context.Context, user *store.User) error {
+	ups, err := s.GetUserPrivilegesContext(ctx, *user.ID)
+	if err != nil {
+		return err
+	}
+	privileges := store.EffectivePrivileges(ups)
+	user.EffectivePrivileges = &privileges
+	return nil
+}
@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// userSearchVector is the expression of the user_search index, the simple
+// parser keeps an email as one word so its punctuation is turned to spaces
+// first, and the name words weigh more than the email ones.
+const userSearchVector = `setweight(to_tsvector('simple', name), 'A') || ` +
+	`setweight(to_tsvector('simple', translate(email, '@.+-_', '     ')), 'B')`
+
+// SearchUsers implements store.store.
+func (s *PostgresAccountStore) SearchUsers(query string, limit int) ([]*store.User, error) {
+	return s.SearchUsersContext(context.Background(), query, limit)
+}
+
+// SearchUsersContext implements store.store.
+func (s *PostgresAccountStore) SearchUsersContext(ctx context.Context, query string, limit int) ([]*store.User, error) {
+	if !s.ValidLimit(limit) {
+		return nil, fmt.Errorf("%w %d", store.ErrInvalidLimit, limit)
+	}
+	users := []*store.User{}
+	terms := store.SearchTerms(query)
+	if len(terms) == 0 {
+		return users, nil
+	}
+	// the terms are letters and digits only, term:* matches a prefix
+	match := strings.Join(terms, ":* & ") + ":*"
+	qry := `SELECT id, name, email, password, version FROM "user" WHERE ` + userSearchVector + ` @@ to_tsquery('simple', $1) AND deleted_at IS NULL` +
+		` ORDER BY ts_rank(` + userSearchVector + `, to_tsquery('simple', $1)) DESC, id LIMIT $2`
+	err := s.db.SelectContext(ctx, &users, qry, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error search user '%s': %w", query, err)
+	}
+	return users, nil
+}
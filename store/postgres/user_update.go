@@ -0,0 +1,225 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/senomas/gohtmx/store"
+)
+
+// UpdateUser implements store.store.
+func (s *PostgresAccountStore) UpdateUser(user *store.User) error {
+	return s.UpdateUserContext(context.Background(), user)
+}
+
+// UpdateUserContext implements store.store.
+func (s *PostgresAccountStore) UpdateUserContext(ctx context.Context, user *store.User) error {
+	if user.ID == nil {
+		return fmt.Errorf("error update user%s: %w", s.ValueString(user), store.ErrNotFound)
+	}
+	updates := []string{"version = version + 1"}
+	args := []interface{}{}
+	if user.Name != nil {
+		args = append(args, *user.Name)
+		updates = append(updates, fmt.Sprintf("name = $%d", len(args)))
+	}
+	if user.Email != nil {
+		args = append(args, *user.Email)
+		updates = append(updates, fmt.Sprintf("email = $%d", len(args)))
+	}
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	before, err := s.auditUser(ctx, tx, *user.ID)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return fmt.Errorf("error update user%s: %w", s.ValueString(user), store.ErrNotFound)
+	}
+	args = append(args, user.ID)
+	qry := `UPDATE "user" SET ` + strings.Join(updates, ", ") + fmt.Sprintf(" WHERE id = $%d AND deleted_at IS NULL", len(args))
+	if user.Version != nil {
+		args = append(args, *user.Version)
+		qry += fmt.Sprintf(" AND version = $%d", len(args))
+	}
+	rs, err := tx.ExecContext(ctx, qry, args...)
+	if err != nil {
+		if field, v, ok := uniqueViolation(err); ok {
+			return fmt.Errorf("error update user%s: %w", s.ValueString(user),
+				&store.DuplicateError{Table: "user", Field: field, Value: v})
+		}
+		return fmt.Errorf("error update user %s: %w", qry, err)
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error update user%s affected: %w", s.ValueString(user), err)
+	}
+	if affected != 1 {
+		// the user was read above, it was updated since user.Version or
+		// deleted by another writer since
+		var found int64
+		err := tx.GetContext(ctx, &found, `SELECT count(id) FROM "user" WHERE id = $1 AND deleted_at IS NULL`, user.ID)
+		if err != nil {
+			return fmt.Errorf("error select user.id %v: %w", *user.ID, err)
+		}
+		if found == 1 && user.Version != nil {
+			return fmt.Errorf("error update user%s: %w", s.ValueString(user),
+				&store.ConflictError{Table: "user", ID: *user.ID, Version: *user.Version})
+		}
+		return fmt.Errorf("error update user%s affected %v: %w", s.ValueString(user), affected, store.ErrNotFound)
+	}
+	if user.Privileges != nil {
+		npname := []interface{}{}
+		npid := []int64{}
+		for _, privilege := range *user.Privileges {
+			npname = append(npname, *privilege.Name)
+		}
+		// an empty IN list is a syntax error
+		if len(npname) > 0 {
+			qry := "SELECT id FROM privilege WHERE name IN (" + placeholders(1, len(npname)) + ")"
+			err := tx.SelectContext(ctx, &npid, qry, npname...)
+			if err != nil {
+				return fmt.Errorf("error select privilege '%s' %+v: %w", qry, npname, err)
+			}
+		}
+		opid := []int64{}
+		qry := `SELECT privilege FROM user_privilege WHERE "user" = $1`
+		err := tx.SelectContext(ctx, &opid, qry, user.ID)
+		if err != nil {
+			return fmt.Errorf("error select user_privilege '%s' %+v: %w", qry, user.ID, err)
+		}
+		ipid := []int64{}
+		rpid := []int64{}
+		for _, n := range npid {
+			found := false
+			for _, o := range opid {
+				if n == o {
+					found = true
+				}
+			}
+			if !found {
+				ipid = append(ipid, n)
+			}
+		}
+		for _, o := range opid {
+			found := false
+			for _, n := range npid {
+				if n == o {
+					found = true
+				}
+			}
+			if !found {
+				rpid = append(rpid, o)
+			}
+		}
+		type UserPrivilege struct {
+			User      int64
+			Privilege int64
+		}
+		if len(ipid) > 0 {
+			ps, err := tx.PrepareNamedContext(ctx, `INSERT INTO user_privilege ("user", privilege) VALUES (:user, :privilege)`)
+			if err != nil {
+				return fmt.Errorf("error prepare insert into user_privilege: %w", err)
+			}
+			for _, n := range ipid {
+				up := UserPrivilege{User: *user.ID, Privilege: n}
+				rs, err := ps.ExecContext(ctx, up)
+				if err != nil {
+					if _, _, ok := uniqueViolation(err); ok {
+						return fmt.Errorf("error insert user_privilege%s: %w", s.ValueString(up), store.ErrConflict)
+					}
+					return fmt.Errorf("error insert user_privilege%s: %w", s.ValueString(up), err)
+				}
+				affected, err := rs.RowsAffected()
+				if err != nil {
+					return fmt.Errorf("error insert user_privilege%s affected %v: %w", s.ValueString(up), affected, err)
+				}
+				if affected != 1 {
+					return fmt.Errorf("error insert user_privilege%s affected %v", s.ValueString(up), affected)
+				}
+			}
+		}
+		if len(rpid) > 0 {
+			ps, err := tx.PrepareNamedContext(ctx, `DELETE FROM user_privilege WHERE "user" = :user AND privilege = :privilege`)
+			if err != nil {
+				return fmt.Errorf("error prepare delete user_privilege: %w", err)
+			}
+			for _, r := range rpid {
+				up := UserPrivilege{User: *user.ID, Privilege: r}
+				rs, err := ps.ExecContext(ctx, up)
+				if err != nil {
+					return fmt.Errorf("error delete user_privilege%s: %w", s.ValueString(up), err)
+				}
+				affected, err := rs.RowsAffected()
+				if err != nil {
+					return fmt.Errorf("error delete user_privilege%s affected %v: %w", s.ValueString(up), affected, err)
+				}
+				if affected != 1 {
+					return fmt.Errorf("error delete user_privilege%s affected %v: %w", s.ValueString(up), affected, store.ErrConflict)
+				}
+			}
+		}
+	}
+	if user.Roles != nil {
+		if _, err := s.setUserRoles(ctx, tx, *user.ID, *user.Roles); err != nil {
+			return err
+		}
+	}
+	after, err := s.auditUser(ctx, tx, *user.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.audit(ctx, tx, store.NewAuditEvent(ctx, store.AUDIT_USER_UPDATE, "user", *user.ID, before, after)); err != nil {
+		return err
+	}
+	var version int64
+	err = tx.GetContext(ctx, &version, `SELECT version FROM "user" WHERE id = $1`, user.ID)
+	if err != nil {
+		return fmt.Errorf("error get user.id %d version: %w", *user.ID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	user.Version = &version
+	return nil
+}
+
+// setUserRoles replaces the roles of a user with roles, looked up by name,
+// and returns them.
+func (s *PostgresAccountStore) setUserRoles(ctx context.Context, tx *sqlx.Tx, userID int64, roles []*store.Role) ([]*store.Role, error) {
+	_, err := tx.ExecContext(ctx, `DELETE FROM user_role WHERE "user" = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error delete user_role.user %d: %w", userID, err)
+	}
+	res := []*store.Role{}
+	for _, r := range roles {
+		role := store.Role{}
+		err := tx.GetContext(ctx, &role, "SELECT id, name, description FROM role WHERE name = $1", r.Name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get role name '%s': %w", *r.Name, store.ErrNotFound)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error get role name '%s': %w", *r.Name, err)
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO user_role ("user", role) VALUES ($1, $2)`, userID, *role.ID)
+		if err != nil {
+			if _, _, ok := uniqueViolation(err); ok {
+				return nil, fmt.Errorf("error insert user_role(%d, %d): %w", userID, *role.ID,
+					&store.DuplicateError{Table: "user_role", Field: "role", Value: *r.Name})
+			}
+			if foreignKeyViolation(err) {
+				return nil, fmt.Errorf("error insert user_role(%d, %d): %w", userID, *role.ID, store.ErrNotFound)
+			}
+			return nil, fmt.Errorf("error insert user_role(%d, %d): %w", userID, *role.ID, err)
+		}
+		res = append(res, &role)
+	}
+	return res, nil
+}
@@ -0,0 +1,84 @@
+package postgres_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var postgresDataDir string
+
+// postgresBin returns the path of a postgres server binary, looked up in
+// POSTGRES_BIN, then in the output of pg_config --bindir, then in PATH.
+func postgresBin(name string) (string, error) {
+	if dir := os.Getenv("POSTGRES_BIN"); dir != "" {
+		return filepath.Join(dir, name), nil
+	}
+	if out, err := exec.Command("pg_config", "--bindir").Output(); err == nil {
+		bin := filepath.Join(strings.TrimSpace(string(out)), name)
+		if _, err := os.Stat(bin); err == nil {
+			return bin, nil
+		}
+	}
+	return exec.LookPath(name)
+}
+
+// startPostgres initializes a throwaway cluster in a temporary directory,
+// starts it on localhost:15432 with a "test" database and returns its DSN.
+// The test is skipped without the postgres binaries, unless POSTGRES_REQUIRED
+// is set as in CI.
+func startPostgres(t *testing.T) string {
+	missing := t.Skipf
+	if os.Getenv("POSTGRES_REQUIRED") != "" {
+		missing = t.Fatalf
+	}
+	initdb, err := postgresBin("initdb")
+	if err != nil {
+		missing("postgres binaries not found: %v", err)
+	}
+	pgctl, err := postgresBin("pg_ctl")
+	if err != nil {
+		missing("postgres binaries not found: %v", err)
+	}
+	createdb, err := postgresBin("createdb")
+	if err != nil {
+		missing("postgres binaries not found: %v", err)
+	}
+
+	postgresDataDir = t.TempDir()
+	data := filepath.Join(postgresDataDir, "data")
+	fmt.Println("initdb", data)
+	cmd := exec.Command(initdb, "-D", data, "-U", "postgres", "--auth=trust")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("initdb: %v\n%s", err, out)
+	}
+	fmt.Println("pg_ctl start", data)
+	cmd = exec.Command(pgctl, "start", "-w",
+		"-D", data,
+		"-l", filepath.Join(postgresDataDir, "postgres.log"),
+		"-o", fmt.Sprintf("-p 15432 -k %s -c listen_addresses=localhost", postgresDataDir))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("pg_ctl start: %v\n%s", err, out)
+	}
+	cmd = exec.Command(createdb, "-h", "localhost", "-p", "15432", "-U", "postgres", "test")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		stopPostgres(t)
+		t.Fatalf("createdb: %v\n%s", err, out)
+	}
+	return "postgres://postgres@localhost:15432/test?sslmode=disable"
+}
+
+func stopPostgres(t *testing.T) {
+	pgctl, err := postgresBin("pg_ctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(pgctl, "stop", "-w", "-m", "fast", "-D", filepath.Join(postgresDataDir, "data"))
+	cmd.Stdout = os.Stdout
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+}
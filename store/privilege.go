@@ -1,22 +1,64 @@
 package store
 
+import "sort"
+
+// Privilege is a named permission, holding it also grants the privileges it
+// Implies. Version counts the updates of the privilege, UpdatePrivilege fails
+// with a ConflictError when it is set and stale.
 type Privilege struct {
+	Implies     *[]*Privilege
 	Name        *string
 	Description *string
 	ID          *int64
+	Version     *int64
 }
 
+// PrivilegeFilter matches the privileges passing every field filter and every
+// And group, at least one Or group when there are any, and not the Not group.
+// Sort orders the results, it is ignored in nested groups.
 type PrivilegeFilter struct {
+	Not         *PrivilegeFilter
+	And         []*PrivilegeFilter
+	Or          []*PrivilegeFilter
 	Name        FilterString
 	Description FilterString
+	Sort        Sort
 	ID          FilterInt64
 }
 
+// Set sets the filter from query values, see UserFilter.Set.
+func (f *PrivilegeFilter) Set(values map[string][]string) {
+	f.ID.Set("id", values)
+	f.Name.Set("name", values)
+	f.Description.Set("description", values)
+	f.Sort.Set("sort", values)
+	q := splitFilterQuery(values)
+	for _, v := range q.And {
+		g := &PrivilegeFilter{}
+		g.Set(v)
+		f.And = append(f.And, g)
+	}
+	for _, v := range q.Or {
+		g := &PrivilegeFilter{}
+		g.Set(v)
+		f.Or = append(f.Or, g)
+	}
+	if q.Not != nil {
+		f.Not = &PrivilegeFilter{}
+		f.Not.Set(q.Not)
+	}
+}
+
 func (p *Privilege) SetID(v int64) *Privilege {
 	p.ID = &v
 	return p
 }
 
+func (p *Privilege) SetVersion(v int64) *Privilege {
+	p.Version = &v
+	return p
+}
+
 func (p *Privilege) SetName(v string) *Privilege {
 	p.Name = &v
 	return p
@@ -26,3 +68,76 @@ func (p *Privilege) SetDescription(v string) *Privilege {
 	p.Description = &v
 	return p
 }
+
+func (p *Privilege) SetImplies(v []*Privilege) *Privilege {
+	p.Implies = &v
+	return p
+}
+
+func (p *Privilege) AddImplies(v *Privilege) *Privilege {
+	if p.Implies == nil {
+		p.Implies = &[]*Privilege{v}
+	} else {
+		*p.Implies = append(*p.Implies, v)
+	}
+	return p
+}
+
+// Implication is a privilege implied by the privilege of id ImpliedBy.
+type Implication struct {
+	Privilege
+	ImpliedBy int64 `db:"implied_by"`
+}
+
+// ImplicationCycle returns the names of the privileges along a cycle of
+// implications, the first name repeated last, or nil when there is none.
+func ImplicationCycle(implications []Implication) []string {
+	names := map[int64]string{}
+	implies := map[int64][]int64{}
+	for _, im := range implications {
+		names[*im.ID] = *im.Name
+		implies[im.ImpliedBy] = append(implies[im.ImpliedBy], *im.ID)
+	}
+	ids := []int64{}
+	for id := range implies {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	// visited is 1 while id is on the path and 2 once all it implies is done
+	visited := map[int64]int{}
+	path := []int64{}
+	var visit func(id int64) []string
+	visit = func(id int64) []string {
+		switch visited[id] {
+		case 1:
+			cycle := []string{}
+			for i := len(path) - 1; i >= 0; i-- {
+				if path[i] == id {
+					for _, pid := range path[i:] {
+						cycle = append(cycle, names[pid])
+					}
+					break
+				}
+			}
+			return append(cycle, names[id])
+		case 2:
+			return nil
+		}
+		visited[id] = 1
+		path = append(path, id)
+		for _, next := range implies[id] {
+			if cycle := visit(next); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		visited[id] = 2
+		return nil
+	}
+	for _, id := range ids {
+		if cycle := visit(id); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package store
+
+import "sort"
+
+// Role is a named bundle of privileges, a user holds the privileges of its
+// roles along with its own.
+type Role struct {
+	Privileges  *[]*Privilege
+	Name        *string
+	Description *string
+	ID          *int64
+}
+
+// RoleFilter matches roles like PrivilegeFilter matches privileges.
+type RoleFilter struct {
+	Not         *RoleFilter
+	And         []*RoleFilter
+	Or          []*RoleFilter
+	Name        FilterString
+	Description FilterString
+	Sort        Sort
+	ID          FilterInt64
+}
+
+// RoleSortFields are the fields FindRoles sorts by.
+var RoleSortFields = []string{"id", "name", "description"}
+
+// Set sets the filter from query values, see UserFilter.Set.
+func (f *RoleFilter) Set(values map[string][]string) {
+	f.ID.Set("id", values)
+	f.Name.Set("name", values)
+	f.Description.Set("description", values)
+	f.Sort.Set("sort", values)
+	q := splitFilterQuery(values)
+	for _, v := range q.And {
+		g := &RoleFilter{}
+		g.Set(v)
+		f.And = append(f.And, g)
+	}
+	for _, v := range q.Or {
+		g := &RoleFilter{}
+		g.Set(v)
+		f.Or = append(f.Or, g)
+	}
+	if q.Not != nil {
+		f.Not = &RoleFilter{}
+		f.Not.Set(q.Not)
+	}
+}
+
+func (r *Role) SetID(v int64) *Role {
+	r.ID = &v
+	return r
+}
+
+func (r *Role) SetName(v string) *Role {
+	r.Name = &v
+	return r
+}
+
+func (r *Role) SetDescription(v string) *Role {
+	r.Description = &v
+	return r
+}
+
+func (r *Role) SetPrivileges(v []*Privilege) *Role {
+	r.Privileges = &v
+	return r
+}
+
+func (r *Role) AddPrivilege(p *Privilege) *Role {
+	if r.Privileges == nil {
+		r.Privileges = &[]*Privilege{}
+	}
+	*r.Privileges = append(*r.Privileges, p)
+	return r
+}
+
+// PrivilegeGrant is a privilege held by a user directly, Role nil, or through
+// the role named Role.
+type PrivilegeGrant struct {
+	Privilege
+	Role *string `db:"role"`
+}
+
+// NewUserPrivileges merges the grants of a user, ordered by privilege id,
+// into one UserPrivilege per privilege and adds the privileges they imply,
+// transitively, through implications.
+func NewUserPrivileges(userID int64, grants []PrivilegeGrant, implications []Implication) []UserPrivilege {
+	res := []UserPrivilege{}
+	for _, g := range grants {
+		if len(res) == 0 || *res[len(res)-1].ID != *g.ID {
+			uid := userID
+			res = append(res, UserPrivilege{ID: g.ID, Name: g.Name, Description: g.Description, UserID: &uid, Roles: []string{}, ImpliedBy: []string{}})
+		}
+		up := &res[len(res)-1]
+		if g.Role == nil {
+			up.Direct = true
+		} else {
+			up.Roles = append(up.Roles, *g.Role)
+		}
+	}
+	implies := map[int64][]Implication{}
+	for _, im := range implications {
+		implies[im.ImpliedBy] = append(implies[im.ImpliedBy], im)
+	}
+	index := map[int64]int{}
+	queue := []int64{}
+	for i, up := range res {
+		index[*up.ID] = i
+		queue = append(queue, *up.ID)
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		by := *res[index[id]].Name
+		for _, im := range implies[id] {
+			i, ok := index[*im.ID]
+			if !ok {
+				uid := userID
+				i = len(res)
+				index[*im.ID] = i
+				res = append(res, UserPrivilege{ID: im.ID, Name: im.Name, Description: im.Description, UserID: &uid, Roles: []string{}, ImpliedBy: []string{}})
+				queue = append(queue, *im.ID)
+			}
+			res[i].ImpliedBy = append(res[i].ImpliedBy, by)
+		}
+	}
+	for _, up := range res {
+		sort.Strings(up.ImpliedBy)
+	}
+	sort.Slice(res, func(i, j int) bool { return *res[i].ID < *res[j].ID })
+	return res
+}
+
+// EffectivePrivileges returns the privileges of ups, see User.
+func EffectivePrivileges(ups []UserPrivilege) []*Privilege {
+	res := []*Privilege{}
+	for _, up := range ups {
+		res = append(res, &Privilege{ID: up.ID, Name: up.Name, Description: up.Description})
+	}
+	return res
+}
@@ -0,0 +1,16 @@
+package store
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SearchTerms splits a search box query into lower case words, every run of
+// letters and digits is a word so "jo gmail.com" searches jo, gmail and com.
+// SearchUsers matches users with a word starting with each of the terms and
+// ranks a match in the name above one in the email.
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
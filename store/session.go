@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// Session is a logged-in user, identified by an opaque token that is only
+// stored hashed.
+type Session struct {
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	LastSeenAt  time.Time
+	IPAddress   *string
+	UserAgent   *string
+	ID          *int64
+	UserID      *int64
+	IdleTimeout time.Duration
+}
+
+// SessionMeta is the client metadata recorded with a new session.
+type SessionMeta struct {
+	IPAddress string
+	UserAgent string
+}
+
+type SessionStore interface {
+	// CreateSession returns the token of a new session for user.
+	CreateSession(ctx context.Context, user *User, meta SessionMeta) (string, *Session, error)
+	// GetSession returns the session of token, ErrSessionExpired when it is
+	// past its expiry or idle timeout.
+	GetSession(ctx context.Context, token string) (*Session, error)
+	// RefreshSession marks the session of token as seen now.
+	RefreshSession(ctx context.Context, token string) (*Session, error)
+	RevokeSession(ctx context.Context, token string) error
+	// RevokeUserSessions revokes every session of a user, returning how many.
+	RevokeUserSessions(ctx context.Context, userID int64) (int64, error)
+	// PurgeExpiredSessions deletes expired sessions, returning how many.
+	PurgeExpiredSessions(ctx context.Context) (int64, error)
+}
+
+type SessionConfig struct {
+	// TTL is the absolute lifetime of a session, default 24 hours.
+	TTL time.Duration
+	// IdleTimeout expires a session not refreshed for that long, default 30 minutes.
+	IdleTimeout time.Duration
+}
+
+func (c SessionConfig) GetTTL() time.Duration {
+	if c.TTL > 0 {
+		return c.TTL
+	}
+	return 24 * time.Hour
+}
+
+func (c SessionConfig) GetIdleTimeout() time.Duration {
+	if c.IdleTimeout > 0 {
+		return c.IdleTimeout
+	}
+	return 30 * time.Minute
+}
+
+// Expired reports whether the session is past its expiry or idle timeout at now.
+func (s *Session) Expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt) || !now.Before(s.LastSeenAt.Add(s.IdleTimeout))
+}
+
+// NewSessionToken returns a random session token and its hash.
+func NewSessionToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, SessionTokenHash(token), nil
+}
+
+// SessionTokenHash returns the hash stored in place of token.
+func SessionTokenHash(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
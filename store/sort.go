@@ -0,0 +1,71 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortField orders by Field, descending when Desc is set.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Sort orders find results by each field in turn.
+type Sort []SortField
+
+var (
+	// UserSortFields are the fields FindUsers sorts by.
+	UserSortFields = []string{"id", "name", "email"}
+	// PrivilegeSortFields are the fields FindPrivileges sorts by.
+	PrivilegeSortFields = []string{"id", "name", "description"}
+)
+
+// Set sets the sort from the query value id, comma separated fields each
+// descending when prefixed with "-", as in "sort=name,-email".
+func (s *Sort) Set(id string, values map[string][]string) {
+	v, ok := values[id]
+	if !ok {
+		return
+	}
+	res := Sort{}
+	for _, value := range v {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimLeft(field, "+-")
+			if field != "" {
+				res = append(res, SortField{Field: field, Desc: desc})
+			}
+		}
+	}
+	*s = res
+}
+
+// Resolve returns the sort ending with id, the tiebreak that keeps pages
+// stable, or ErrInvalidSort for a field not in fields. A field repeated is
+// sorted by its first occurrence only.
+func (s Sort) Resolve(fields []string) (Sort, error) {
+	res := Sort{}
+	seen := map[string]bool{}
+	for _, f := range s {
+		valid := false
+		for _, field := range fields {
+			if f.Field == field {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w %q", ErrInvalidSort, f.Field)
+		}
+		if !seen[f.Field] {
+			seen[f.Field] = true
+			res = append(res, f)
+		}
+	}
+	if !seen["id"] {
+		res = append(res, SortField{Field: "id"})
+	}
+	return res, nil
+}
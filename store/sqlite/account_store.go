@@ -1,10 +1,9 @@
 package sqlite
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
@@ -13,94 +12,97 @@ import (
 )
 
 type SqliteAccountStore struct {
-	db       *sqlx.DB
-	maxLimit int
+	db             *sqlx.DB
+	migrator       *store.Migrator
+	session        store.SessionConfig
+	passwordPolicy *store.PasswordPolicy
+	passwordHasher store.PasswordHasher
+	maxLimit       int
 }
 
 func init() {
-	store.AddAccountStore("sqlite", func() store.AccountStore {
-		v := SqliteAccountStore{}
-		return v.init()
+	store.AddAccountStore("sqlite", func(cfg store.Config) (store.AccountStore, error) {
+		s, err := Open(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
 	})
 }
 
-func (s *SqliteAccountStore) init() store.AccountStore {
-	url := os.Getenv("DB_URL")
+// Open opens the sqlite database cfg.DSN, ":memory:" when empty, and migrates
+// its schema.
+func Open(cfg store.Config) (*SqliteAccountStore, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	url := cfg.DSN
 	if url == "" {
 		url = ":memory:"
 	}
-	db, err := sqlx.Open("sqlite3", url)
-	if err != nil {
-		panic(fmt.Errorf("error opening database [%s]: %v", url, err))
+	dsn := url
+	if !strings.Contains(dsn, "_foreign_keys=") && !strings.Contains(dsn, "_fk=") {
+		if strings.Contains(dsn, "?") {
+			dsn += "&_foreign_keys=1"
+		} else {
+			dsn += "?_foreign_keys=1"
+		}
 	}
-
-	err = db.Ping()
+	db, err := sqlx.Open("sqlite3", dsn)
 	if err != nil {
-		panic(fmt.Errorf("error ping database [%s]: %v", url, err))
+		return nil, fmt.Errorf("error opening database [%s]: %w", url, err)
 	}
-
-	qry := `PRAGMA foreign_keys = ON`
-	_, err = db.Exec(qry)
-	if err != nil {
-		panic(fmt.Errorf("error setup conn : %v\n\n%s", err, qry))
-	}
-
-	qry = `CREATE TABLE IF NOT EXISTS user (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    name TEXT NOT NULL,
-    email TEXT NOT NULL,
-    password TEXT NOT NULL,
-    UNIQUE(name),
-    UNIQUE(email)
-  )`
-	_, err = db.Exec(qry)
-	if err != nil {
-		panic(fmt.Errorf("error creating table: %v\n\n%s", err, qry))
+	cfg.SetupPool(db)
+	if strings.HasPrefix(url, ":memory:") || strings.Contains(url, "mode=memory") {
+		// every connection opens its own in-memory database
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		db.SetConnMaxLifetime(0)
+		db.SetConnMaxIdleTime(0)
 	}
 
-	qry = `CREATE TABLE IF NOT EXISTS privilege (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    name TEXT NOT NULL,
-    description TEXT NOT NULL,
-    UNIQUE(name)
-  )`
-	_, err = db.Exec(qry)
-	if err != nil {
-		panic(fmt.Errorf("error creating table: %v\n\n%s", err, qry))
+	ctx := context.Background()
+	if cfg.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.ConnectTimeout)
+		defer cancel()
 	}
-
-	qry = `CREATE TABLE IF NOT EXISTS user_privilege (
-    user INTEGER NOT NULL,
-    privilege INTEGER NOT NULL,
-    UNIQUE(user, privilege),
-    FOREIGN KEY(user) REFERENCES user(id) ON DELETE CASCADE,
-    FOREIGN KEY(privilege) REFERENCES privilege(id)
-  )`
-	_, err = db.Exec(qry)
+	err = db.PingContext(ctx)
 	if err != nil {
-		panic(fmt.Errorf("error creating table: %v\n\n%s", err, qry))
-	}
-	ctx := SqliteAccountStore{
-		db: db,
+		db.Close()
+		return nil, fmt.Errorf("error ping database [%s]: %w", url, err)
 	}
 
-	maxLimit := os.Getenv("DB_MAX_LIMIT")
-	if maxLimit == "" {
-		ctx.maxLimit = 100
-	} else {
-		v, err := strconv.ParseInt(maxLimit, 10, 32)
-		if err != nil {
-			panic(fmt.Errorf("invalid DB_MAX_LIMIT '%s': %v", maxLimit, err))
-		}
-		ctx.maxLimit = int(v)
+	s := &SqliteAccountStore{
+		db:             db,
+		migrator:       store.NewMigrator(db, migrations),
+		session:        cfg.Session,
+		passwordPolicy: cfg.GetPasswordPolicy(),
+		passwordHasher: cfg.GetPasswordHasher(),
+		maxLimit:       cfg.GetMaxLimit(),
 	}
-	return &ctx
+	err = cfg.Migration.Run(context.Background(), s.migrator)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error migrating database [%s]: %w", url, err)
+	}
+	return s, nil
 }
 
 func (s *SqliteAccountStore) Close() error {
 	return s.db.Close()
 }
 
+// Migrator returns the schema migrator of the store database.
+func (s *SqliteAccountStore) Migrator() *store.Migrator {
+	return s.migrator
+}
+
+// SchemaVersion implements store.AccountStore.
+func (s *SqliteAccountStore) SchemaVersion(ctx context.Context) (int64, error) {
+	return s.migrator.Version(ctx)
+}
+
 func (s *SqliteAccountStore) ValidLimit(limit int) bool {
 	return limit > 0 && limit <= s.maxLimit
 }
@@ -114,6 +116,24 @@ func (s *SqliteAccountStore) ValueString(v interface{}) string {
 	return str
 }
 
+// uniqueViolation reports the table and field of a failed UNIQUE constraint.
+func uniqueViolation(err error) (string, string, bool) {
+	em := err.Error()
+	if !strings.HasPrefix(em, "UNIQUE constraint failed: ") {
+		return "", "", false
+	}
+	ka := strings.SplitN(em[26:], ".", 2)
+	if len(ka) != 2 {
+		return em[26:], "", true
+	}
+	return ka[0], ka[1], true
+}
+
+// foreignKeyViolation reports whether err is a failed FOREIGN KEY constraint.
+func foreignKeyViolation(err error) bool {
+	return err.Error() == "FOREIGN KEY constraint failed"
+}
+
 type filter struct {
 	filters []string
 	args    []interface{}
@@ -125,6 +145,28 @@ func (ctx *filter) Int64(field string, f store.FilterInt64) {
 	case store.OP_EQ:
 		ctx.filters = append(ctx.filters, field+" = ?")
 		ctx.args = append(ctx.args, f.Value)
+	case store.OP_NE:
+		ctx.filters = append(ctx.filters, field+" <> ?")
+		ctx.args = append(ctx.args, f.Value)
+	case store.OP_IN, store.OP_NIN:
+		values := []interface{}{}
+		for _, v := range f.Values {
+			values = append(values, v)
+		}
+		ctx.in(field, f.Op == store.OP_NIN, values)
+	case store.OP_GTE:
+		ctx.filters = append(ctx.filters, field+" >= ?")
+		ctx.args = append(ctx.args, f.Value)
+	case store.OP_LTE:
+		ctx.filters = append(ctx.filters, field+" <= ?")
+		ctx.args = append(ctx.args, f.Value)
+	case store.OP_BETWEEN:
+		ctx.filters = append(ctx.filters, field+" BETWEEN ? AND ?")
+		ctx.args = append(ctx.args, f.Value, f.To)
+	case store.OP_NULL:
+		ctx.filters = append(ctx.filters, field+" IS NULL")
+	case store.OP_NOT_NULL:
+		ctx.filters = append(ctx.filters, field+" IS NOT NULL")
 	default:
 		panic(fmt.Errorf("invalid op %s: %+v", field, f))
 	}
@@ -136,17 +178,131 @@ func (ctx *filter) String(field string, f store.FilterString) {
 	case store.OP_EQ:
 		ctx.filters = append(ctx.filters, field+" = ?")
 		ctx.args = append(ctx.args, f.Value)
+	case store.OP_NE:
+		ctx.filters = append(ctx.filters, field+" <> ?")
+		ctx.args = append(ctx.args, f.Value)
 	case store.OP_LIKE:
 		ctx.filters = append(ctx.filters, field+" like ?")
 		ctx.args = append(ctx.args, f.Value)
+	case store.OP_IN, store.OP_NIN:
+		values := []interface{}{}
+		for _, v := range f.Values {
+			values = append(values, v)
+		}
+		ctx.in(field, f.Op == store.OP_NIN, values)
+	case store.OP_NULL:
+		ctx.filters = append(ctx.filters, field+" IS NULL")
+	case store.OP_NOT_NULL:
+		ctx.filters = append(ctx.filters, field+" IS NOT NULL")
+	case store.OP_EMPTY:
+		ctx.filters = append(ctx.filters, "("+field+" IS NULL OR "+field+" = '')")
+	case store.OP_NOT_EMPTY:
+		ctx.filters = append(ctx.filters, "("+field+" IS NOT NULL AND "+field+" <> '')")
 	default:
 		panic(fmt.Errorf("invalid op %s: %+v", field, f))
 	}
 }
 
+// in adds field IN (values), or NOT IN when not is set. An empty list, a
+// syntax error in sql, matches nothing, or everything when negated.
+func (ctx *filter) in(field string, not bool, values []interface{}) {
+	if len(values) == 0 {
+		if !not {
+			ctx.filters = append(ctx.filters, "1 = 0")
+		}
+		return
+	}
+	op := " IN ("
+	if not {
+		op = " NOT IN ("
+	}
+	ctx.filters = append(ctx.filters, field+op+strings.TrimSuffix(strings.Repeat("?,", len(values)), ",")+")")
+	ctx.args = append(ctx.args, values...)
+}
+
+// group returns the conditions add appends, joined with AND, an empty group
+// is always true.
+func (ctx *filter) group(add func(where *filter)) string {
+	sub := filter{args: ctx.args}
+	add(&sub)
+	ctx.args = sub.args
+	if len(sub.filters) == 0 {
+		return "1 = 1"
+	}
+	return "(" + strings.Join(sub.filters, " AND ") + ")"
+}
+
+// Or adds the groups joined with OR, no groups adds no condition.
+func (ctx *filter) Or(groups ...func(where *filter)) {
+	if len(groups) == 0 {
+		return
+	}
+	ors := []string{}
+	for _, g := range groups {
+		ors = append(ors, ctx.group(g))
+	}
+	ctx.filters = append(ctx.filters, "("+strings.Join(ors, " OR ")+")")
+}
+
+// Not adds the negated group.
+func (ctx *filter) Not(group func(where *filter)) {
+	ctx.filters = append(ctx.filters, "NOT "+ctx.group(group))
+}
+
+// Keyset adds the rows after cursor in sort order, or before it when cursor
+// is Prev, sort ends with the unique id.
+func (ctx *filter) Keyset(sort store.Sort, cursor *store.Cursor) {
+	groups := []func(where *filter){}
+	for i, f := range sort {
+		i, f := i, f
+		groups = append(groups, func(where *filter) {
+			for j := 0; j < i; j++ {
+				where.filters = append(where.filters, sort[j].Field+" = ?")
+				where.args = append(where.args, cursor.Values[j])
+			}
+			op := " > "
+			if f.Desc != cursor.Prev {
+				op = " < "
+			}
+			where.filters = append(where.filters, f.Field+op+"?")
+			where.args = append(where.args, cursor.Values[i])
+		})
+	}
+	ctx.Or(groups...)
+}
+
 func (ctx *filter) AppendWhere(query string) string {
 	if len(ctx.filters) > 0 {
 		return query + " WHERE " + strings.Join(ctx.filters, " AND ")
 	}
 	return query
 }
+
+// orderBy returns the ORDER BY clause of sort, its fields are checked against
+// the entity sort fields by Sort.Resolve and are safe to inline.
+func orderBy(sort store.Sort) string {
+	fields := []string{}
+	for _, f := range sort {
+		if f.Desc {
+			fields = append(fields, f.Field+" DESC")
+		} else {
+			fields = append(fields, f.Field+" ASC")
+		}
+	}
+	return " ORDER BY " + strings.Join(fields, ", ")
+}
+
+// count returns the number of rows of table matching where as asked by
+// count, sqlite keeps no row count statistics so an estimate is exact.
+func (s *SqliteAccountStore) count(ctx context.Context, table string, where *filter, count int) (*int64, bool, error) {
+	if count == store.COUNT_NONE {
+		return nil, false, nil
+	}
+	var total int64
+	qry := where.AppendWhere("SELECT count(id) FROM " + table)
+	err := s.db.GetContext(ctx, &total, qry, where.args...)
+	if err != nil {
+		return nil, false, err
+	}
+	return &total, false, nil
+}
@@ -0,0 +1,66 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSqliteConfig(t *testing.T) {
+	t.Run("unknown implementation", func(t *testing.T) {
+		_, err := store.GetAccountStore("oracle", store.Config{})
+		assert.ErrorContains(t, err, "no implementation for 'oracle'")
+	})
+
+	t.Run("invalid dsn", func(t *testing.T) {
+		_, err := store.GetAccountStore("sqlite", store.Config{DSN: filepath.Join(t.TempDir(), "missing", "test.db")})
+		assert.Error(t, err)
+	})
+
+	t.Run("two stores", func(t *testing.T) {
+		file, err := store.GetAccountStore("sqlite", store.Config{
+			DSN:      filepath.Join(t.TempDir(), "test.db"),
+			MaxLimit: 5,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		memory, err := store.GetAccountStore("sqlite", store.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer memory.Close()
+
+		_, err = file.AddPrivileges([]*store.Privilege{
+			(&store.Privilege{}).SetName("Admin").SetDescription("Administrator"),
+		})
+		assert.NoError(t, err)
+
+		_, total, err := file.FindPrivileges(&store.PrivilegeFilter{}, 0, 5)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+		_, _, err = file.FindPrivileges(&store.PrivilegeFilter{}, 0, 10)
+		assert.ErrorIs(t, err, store.ErrInvalidLimit)
+
+		_, total, err = memory.FindPrivileges(&store.PrivilegeFilter{}, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, total)
+	})
+
+	t.Run("migration disabled", func(t *testing.T) {
+		accountStore, err := store.GetAccountStore("sqlite", store.Config{
+			Migration: store.MigrationConfig{Disabled: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer accountStore.Close()
+		version, err := accountStore.SchemaVersion(context.Background())
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, version)
+	})
+}
@@ -0,0 +1,130 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/senomas/gohtmx/store"
+)
+
+type auditRow struct {
+	ActorID   *int64 `db:"actor"`
+	Action    string `db:"action"`
+	Target    string `db:"target"`
+	Changes   string `db:"changes"`
+	ID        int64  `db:"id"`
+	CreatedAt int64  `db:"created_at"`
+	TargetID  int64  `db:"target_id"`
+}
+
+func (r *auditRow) event() (*store.AuditEvent, error) {
+	changes := map[string]store.AuditChange{}
+	if err := json.Unmarshal([]byte(r.Changes), &changes); err != nil {
+		return nil, fmt.Errorf("error decode audit_log.changes %d: %w", r.ID, err)
+	}
+	return &store.AuditEvent{
+		ID:        &r.ID,
+		CreatedAt: time.UnixMilli(r.CreatedAt),
+		ActorID:   r.ActorID,
+		Action:    r.Action,
+		Target:    r.Target,
+		TargetID:  r.TargetID,
+		Changes:   changes,
+	}, nil
+}
+
+// audit appends events, skipping nil ones, to the audit log in tx.
+func (s *SqliteAccountStore) audit(ctx context.Context, tx *sqlx.Tx, events ...*store.AuditEvent) error {
+	for _, e := range events {
+		if e == nil {
+			continue
+		}
+		changes, err := json.Marshal(e.Changes)
+		if err != nil {
+			return fmt.Errorf("error encode audit_log.changes: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO audit_log (created_at, actor, action, target, target_id, changes) VALUES (?, ?, ?, ?, ?, ?)",
+			e.CreatedAt.UnixMilli(), e.ActorID, e.Action, e.Target, e.TargetID, string(changes))
+		if err != nil {
+			return fmt.Errorf("error insert audit_log %s %s.id %d: %w", e.Action, e.Target, e.TargetID, err)
+		}
+	}
+	return nil
+}
+
+// auditUser returns the audited fields of the user id, nil when there is no
+// such live user.
+func (s *SqliteAccountStore) auditUser(ctx context.Context, tx *sqlx.Tx, id int64) (map[string]interface{}, error) {
+	user := store.User{}
+	err := tx.GetContext(ctx, &user, "SELECT id, name, email FROM user WHERE id = ? AND deleted_at IS NULL", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error get user.id %d: %w", id, err)
+	}
+	return s.auditUserRow(ctx, tx, &user)
+}
+
+// auditUserRow returns the audited fields of user, read by id, name and
+// email, with its privileges and roles.
+func (s *SqliteAccountStore) auditUserRow(ctx context.Context, tx *sqlx.Tx, user *store.User) (map[string]interface{}, error) {
+	privileges := []*store.Privilege{}
+	err := tx.SelectContext(ctx, &privileges, "SELECT p.id, p.name, p.description, p.version FROM privilege p JOIN user_privilege up ON p.id = up.privilege WHERE up.user = ?", *user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error select user_privilege.user %d: %w", *user.ID, err)
+	}
+	roles := []*store.Role{}
+	err = tx.SelectContext(ctx, &roles, "SELECT r.id, r.name, r.description FROM role r JOIN user_role ur ON r.id = ur.role WHERE ur.user = ?", *user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error select user_role.user %d: %w", *user.ID, err)
+	}
+	user.Privileges = &privileges
+	user.Roles = &roles
+	return store.UserAuditFields(user), nil
+}
+
+// auditPrivilege returns the audited fields of the privilege id, nil when
+// there is no such privilege.
+func (s *SqliteAccountStore) auditPrivilege(ctx context.Context, tx *sqlx.Tx, id int64) (map[string]interface{}, error) {
+	privilege := store.Privilege{}
+	err := tx.GetContext(ctx, &privilege, "SELECT id, name, description, version FROM privilege WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error get privilege.id %d: %w", id, err)
+	}
+	implies := []*store.Privilege{}
+	err = tx.SelectContext(ctx, &implies, "SELECT p.id, p.name, p.description, p.version FROM privilege p JOIN privilege_implies pi ON p.id = pi.implies WHERE pi.privilege = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("error select privilege_implies.privilege %d: %w", id, err)
+	}
+	privilege.Implies = &implies
+	return store.PrivilegeAuditFields(&privilege), nil
+}
+
+// auditRole returns the audited fields of the role id, nil when there is no
+// such role.
+func (s *SqliteAccountStore) auditRole(ctx context.Context, tx *sqlx.Tx, id int64) (map[string]interface{}, error) {
+	role := store.Role{}
+	err := tx.GetContext(ctx, &role, "SELECT id, name, description FROM role WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error get role.id %d: %w", id, err)
+	}
+	privileges := []*store.Privilege{}
+	err = tx.SelectContext(ctx, &privileges, "SELECT p.id, p.name, p.description, p.version FROM privilege p JOIN role_privilege rp ON p.id = rp.privilege WHERE rp.role = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("error select role_privilege.role %d: %w", id, err)
+	}
+	role.Privileges = &privileges
+	return store.RoleAuditFields(&role), nil
+}
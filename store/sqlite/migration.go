@@ -0,0 +1,248 @@
+package sqlite
+
+import (
+	"strings"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// migrations are the common ones and the searchMigrations of the build.
+var migrations = append([]store.Migration{
+	{
+		Version: 1,
+		Name:    "create user and privilege",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS user (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    password TEXT NOT NULL,
+    UNIQUE(name),
+    UNIQUE(email)
+  )`,
+			`CREATE TABLE IF NOT EXISTS privilege (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    UNIQUE(name)
+  )`,
+			`CREATE TABLE IF NOT EXISTS user_privilege (
+    user INTEGER NOT NULL,
+    privilege INTEGER NOT NULL,
+    UNIQUE(user, privilege),
+    FOREIGN KEY(user) REFERENCES user(id) ON DELETE CASCADE,
+    FOREIGN KEY(privilege) REFERENCES privilege(id)
+  )`,
+		},
+		Down: []string{
+			`DROP TABLE user_privilege`,
+			`DROP TABLE privilege`,
+			`DROP TABLE user`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "create session",
+		Up: []string{
+			`CREATE TABLE session (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user INTEGER NOT NULL,
+    token_hash TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL,
+    last_seen_at INTEGER NOT NULL,
+    idle_timeout INTEGER NOT NULL,
+    ip_address TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    UNIQUE(token_hash),
+    FOREIGN KEY(user) REFERENCES user(id) ON DELETE CASCADE
+  )`,
+			`CREATE INDEX session_user ON session(user)`,
+			`CREATE INDEX session_expires_at ON session(expires_at)`,
+		},
+		Down: []string{
+			`DROP TABLE session`,
+		},
+	},
+	{
+		Version: 3,
+		Name:    "add user last_login",
+		Up: []string{
+			`ALTER TABLE user ADD COLUMN last_login INTEGER`,
+		},
+		Down: []string{
+			`ALTER TABLE user DROP COLUMN last_login`,
+		},
+	},
+	{
+		Version: 4,
+		Name:    "create password_history",
+		Up: []string{
+			`CREATE TABLE password_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user INTEGER NOT NULL,
+    password TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    FOREIGN KEY(user) REFERENCES user(id) ON DELETE CASCADE
+  )`,
+			`CREATE INDEX password_history_user ON password_history(user)`,
+		},
+		Down: []string{
+			`DROP TABLE password_history`,
+		},
+	},
+	userSearchMigration,
+	{
+		Version: 6,
+		Name:    "create role",
+		Up: []string{
+			`CREATE TABLE role (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    UNIQUE(name)
+  )`,
+			`CREATE TABLE role_privilege (
+    role INTEGER NOT NULL,
+    privilege INTEGER NOT NULL,
+    UNIQUE(role, privilege),
+    FOREIGN KEY(role) REFERENCES role(id) ON DELETE CASCADE,
+    FOREIGN KEY(privilege) REFERENCES privilege(id)
+  )`,
+			`CREATE TABLE user_role (
+    user INTEGER NOT NULL,
+    role INTEGER NOT NULL,
+    UNIQUE(user, role),
+    FOREIGN KEY(user) REFERENCES user(id) ON DELETE CASCADE,
+    FOREIGN KEY(role) REFERENCES role(id)
+  )`,
+			`CREATE INDEX user_role_role ON user_role(role)`,
+		},
+		Down: []string{
+			`DROP TABLE user_role`,
+			`DROP TABLE role_privilege`,
+			`DROP TABLE role`,
+		},
+	},
+	{
+		Version: 7,
+		Name:    "create privilege_implies",
+		Up: []string{
+			`CREATE TABLE privilege_implies (
+    privilege INTEGER NOT NULL,
+    implies INTEGER NOT NULL,
+    UNIQUE(privilege, implies),
+    FOREIGN KEY(privilege) REFERENCES privilege(id) ON DELETE CASCADE,
+    FOREIGN KEY(implies) REFERENCES privilege(id)
+  )`,
+			`CREATE INDEX privilege_implies_implies ON privilege_implies(implies)`,
+		},
+		Down: []string{
+			`DROP TABLE privilege_implies`,
+		},
+	},
+	{
+		Version: 8,
+		Name:    "create audit_log",
+		Up: []string{
+			`CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at INTEGER NOT NULL,
+    actor INTEGER,
+    action TEXT NOT NULL,
+    target TEXT NOT NULL,
+    target_id INTEGER NOT NULL,
+    changes TEXT NOT NULL
+  )`,
+			`CREATE INDEX audit_log_actor ON audit_log(actor)`,
+			`CREATE INDEX audit_log_target ON audit_log(target, target_id)`,
+			`CREATE INDEX audit_log_created_at ON audit_log(created_at)`,
+			`CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+  BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END`,
+			`CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+  BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END`,
+		},
+		Down: []string{
+			`DROP TABLE audit_log`,
+		},
+	},
+	{
+		Version: 9,
+		Name:    "add user deleted_at",
+		Up: rebuildUser(`CREATE TABLE user_rebuild (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    password TEXT NOT NULL,
+    last_login INTEGER,
+    deleted_at INTEGER
+  )`,
+			"id, name, email, password, last_login",
+			`CREATE UNIQUE INDEX user_name ON user(name) WHERE deleted_at IS NULL`,
+			`CREATE UNIQUE INDEX user_email ON user(email) WHERE deleted_at IS NULL`,
+		),
+		Down: append([]string{
+			`DELETE FROM user WHERE deleted_at IS NOT NULL`,
+		}, rebuildUser(`CREATE TABLE user_rebuild (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    password TEXT NOT NULL,
+    last_login INTEGER,
+    UNIQUE(name),
+    UNIQUE(email)
+  )`,
+			"id, name, email, password, last_login",
+		)...),
+	},
+	{
+		Version: 10,
+		Name:    "add user and privilege version",
+		Up: []string{
+			`ALTER TABLE user ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+			`ALTER TABLE privilege ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+		},
+		Down: []string{
+			`ALTER TABLE privilege DROP COLUMN version`,
+			`ALTER TABLE user DROP COLUMN version`,
+		},
+	},
+}, searchMigrations...)
+
+// userTables reference the user table, they are rebuilt with it.
+var userTables = []string{"user_privilege", "user_role", "session", "password_history"}
+
+// rebuildUser returns the statements replacing the user table with the
+// user_rebuild table of create, copying columns and the last id, sqlite can
+// not drop a constraint. The migrations run in a transaction where foreign keys can not
+// be turned off, dropping user deletes the rows referencing it, they are kept
+// aside and put back. The user_search triggers are dropped with user and
+// created again.
+func rebuildUser(create string, columns string, indexes ...string) []string {
+	stmts := []string{
+		create,
+		"INSERT INTO user_rebuild (" + columns + ") SELECT " + columns + " FROM user",
+		`DELETE FROM sqlite_sequence WHERE name = 'user_rebuild'`,
+		`INSERT INTO sqlite_sequence (name, seq) SELECT 'user_rebuild', seq FROM sqlite_sequence WHERE name = 'user'`,
+	}
+	for _, t := range userTables {
+		stmts = append(stmts, "CREATE TEMP TABLE "+t+"_rebuild AS SELECT * FROM "+t)
+	}
+	stmts = append(stmts,
+		`DROP TABLE user`,
+		`ALTER TABLE user_rebuild RENAME TO user`,
+	)
+	for _, t := range userTables {
+		stmts = append(stmts,
+			"INSERT INTO "+t+" SELECT * FROM temp."+t+"_rebuild",
+			"DROP TABLE temp."+t+"_rebuild",
+		)
+	}
+	stmts = append(stmts, indexes...)
+	for _, qry := range userSearchMigration.Up {
+		if strings.HasPrefix(qry, "CREATE TRIGGER") {
+			stmts = append(stmts, qry)
+		}
+	}
+	return stmts
+}
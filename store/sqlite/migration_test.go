@@ -0,0 +1,173 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/senomas/gohtmx/store"
+	"github.com/senomas/gohtmx/store/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSqliteMigration(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("account store schema version", func(t *testing.T) {
+		accountStore, err := sqlite.Open(store.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer accountStore.Close()
+		version, err := accountStore.SchemaVersion(ctx)
+		assert.NoError(t, err)
+		assert.Greater(t, version, int64(0))
+		assert.Equal(t, accountStore.Migrator().Latest(), version)
+	})
+
+	t.Run("user rebuild keeps references", func(t *testing.T) {
+		accountStore, err := sqlite.Open(store.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer accountStore.Close()
+		_, err = accountStore.AddPrivileges([]*store.Privilege{(&store.Privilege{}).SetName("Admin").SetDescription("Administrator")})
+		assert.NoError(t, err)
+		users, err := accountStore.AddUsers([]*store.User{
+			(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1").
+				AddPrivilege((&store.Privilege{}).SetName("Admin")),
+			(&store.User{}).SetName("User 2").SetEmail("user2@foo.com").SetPassword("user2"),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, _, err := accountStore.CreateSession(ctx, users[0], store.SessionMeta{})
+		assert.NoError(t, err)
+		assert.NoError(t, accountStore.DeleteUsers([]int64{*users[1].ID}))
+
+		assert.NoError(t, accountStore.Migrator().Migrate(ctx, 8))
+		assert.NoError(t, accountStore.Migrator().Up(ctx))
+		user, err := accountStore.GetUser(*users[0].ID)
+		if assert.NoError(t, err) {
+			assert.Len(t, *user.Privileges, 1)
+		}
+		_, err = accountStore.GetSession(ctx, token)
+		assert.NoError(t, err)
+		found, err := accountStore.SearchUsers("user1", 10)
+		assert.NoError(t, err)
+		assert.Len(t, found, 1)
+		_, total, err := accountStore.FindUsers(&store.UserFilter{WithDeleted: true}, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total, "reverting drops the deleted users")
+
+		users, err = accountStore.AddUsers([]*store.User{
+			(&store.User{}).SetName("User 3").SetEmail("user3@foo.com").SetPassword("user3"),
+		})
+		if assert.NoError(t, err) {
+			assert.Greater(t, *users[0].ID, int64(2), "ids are not reused")
+		}
+	})
+
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	migrations := []store.Migration{
+		{
+			Version: 2,
+			Name:    "add phone",
+			Up:      []string{`ALTER TABLE demo ADD COLUMN phone TEXT`},
+			Down:    []string{`ALTER TABLE demo DROP COLUMN phone`},
+		},
+		{
+			Version: 1,
+			Name:    "create demo",
+			Up:      []string{`CREATE TABLE demo (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`},
+			Down:    []string{`DROP TABLE demo`},
+		},
+	}
+	migrator := store.NewMigrator(db, migrations)
+
+	t.Run("up", func(t *testing.T) {
+		version, err := migrator.Version(ctx)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, version)
+
+		assert.NoError(t, migrator.Up(ctx))
+		version, err = migrator.Version(ctx)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, version)
+		_, err = db.Exec("INSERT INTO demo (name, phone) VALUES ('foo', '123')")
+		assert.NoError(t, err)
+	})
+
+	t.Run("up is idempotent", func(t *testing.T) {
+		assert.NoError(t, migrator.Up(ctx))
+		version, err := migrator.Version(ctx)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, version)
+	})
+
+	t.Run("down", func(t *testing.T) {
+		assert.NoError(t, migrator.Down(ctx))
+		version, err := migrator.Version(ctx)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, version)
+		_, err = db.Exec("INSERT INTO demo (name, phone) VALUES ('bar', '456')")
+		assert.ErrorContains(t, err, "phone")
+
+		assert.NoError(t, migrator.Migrate(ctx, 0))
+		version, err = migrator.Version(ctx)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, version)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		assert.ErrorContains(t, migrator.Migrate(ctx, 3), "unknown migration version 3")
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		_, err := db.Exec("INSERT INTO schema_migrations_lock (id, owner, locked_at) VALUES (1, 'other', ?)", time.Now().Unix())
+		assert.NoError(t, err)
+		migrator.LockTimeout = 200 * time.Millisecond
+		assert.ErrorIs(t, migrator.Up(ctx), store.ErrMigrationLocked)
+
+		migrator.LockExpiry = 0
+		_, err = db.Exec("UPDATE schema_migrations_lock SET locked_at = ?", time.Now().Add(-time.Second).Unix())
+		assert.NoError(t, err)
+		assert.NoError(t, migrator.Up(ctx))
+		version, err := migrator.Version(ctx)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, version)
+	})
+	t.Run("lock lost", func(t *testing.T) {
+		db, err := sqlx.Open("sqlite3", ":memory:")
+		assert.NoError(t, err)
+		defer db.Close()
+		db.SetMaxOpenConns(1)
+
+		migrator := store.NewMigrator(db, []store.Migration{
+			{
+				Version: 1,
+				Name:    "take over lock",
+				Up:      []string{`UPDATE schema_migrations_lock SET owner = 'other'`},
+			},
+			{
+				Version: 2,
+				Name:    "create demo",
+				Up:      []string{`CREATE TABLE demo (id INTEGER PRIMARY KEY)`},
+				Down:    []string{`DROP TABLE demo`},
+			},
+		})
+		assert.ErrorIs(t, migrator.Up(ctx), store.ErrMigrationLocked)
+		version, err := migrator.Version(ctx)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, version, "stops before the next step")
+
+		var owner string
+		assert.NoError(t, db.Get(&owner, "SELECT owner FROM schema_migrations_lock"))
+		assert.Equal(t, "other", owner, "keeps the lock of the other owner")
+	})
+}
@@ -1,55 +1,86 @@
 package sqlite
 
 import (
+	"context"
 	"fmt"
-	"strings"
 
 	"github.com/senomas/gohtmx/store"
 )
 
 // AddPrivileges implements store.Store.
 func (s *SqliteAccountStore) AddPrivileges(privileges []*store.Privilege) ([]*store.Privilege, error) {
-	tx := s.db.MustBegin()
+	return s.AddPrivilegesContext(context.Background(), privileges)
+}
+
+// AddPrivilegesContext implements store.Store.
+func (s *SqliteAccountStore) AddPrivilegesContext(ctx context.Context, privileges []*store.Privilege) ([]*store.Privilege, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error begin transaction: %w", err)
+	}
 	defer tx.Rollback()
-	ps, err := tx.PrepareNamed("INSERT INTO privilege (name, description) VALUES (:name, :description)")
+	ps, err := tx.PrepareNamedContext(ctx, "INSERT INTO privilege (name, description) VALUES (:name, :description)")
 	if err != nil {
-		return nil, fmt.Errorf("error creating PrepareNamed: %v", err)
+		return nil, fmt.Errorf("error creating PrepareNamed: %w", err)
 	}
 	res := []*store.Privilege{}
 	for _, privilege := range privileges {
-		rs, err := ps.Exec(privilege)
+		rs, err := ps.ExecContext(ctx, privilege)
 		if err != nil {
-			em := err.Error()
-			if strings.HasPrefix(em, "UNIQUE constraint failed: ") {
-				ks := em[26:]
-				ka := strings.SplitN(ks, ".", 3)
+			if table, field, ok := uniqueViolation(err); ok {
 				var v interface{}
-				if len(ka) == 2 {
-					switch ka[1] {
-					case "name":
-						v = *privilege.Name
-					default:
-						v = s.ValueString(privilege)
-					}
+				switch field {
+				case "name":
+					v = *privilege.Name
+				default:
+					v = s.ValueString(privilege)
 				}
-				return nil, fmt.Errorf("error insert privilege%s: duplicate record %s '%v'", s.ValueString(privilege), ks, v)
+				return nil, fmt.Errorf("error insert privilege%s: %w", s.ValueString(privilege),
+					&store.DuplicateError{Table: table, Field: field, Value: v})
 			}
-			return nil, fmt.Errorf("error insert privilege%s: %v", s.ValueString(privilege), err)
+			return nil, fmt.Errorf("error insert privilege%s: %w", s.ValueString(privilege), err)
 		}
 		affected, err := rs.RowsAffected()
 		if err != nil {
-			return res, fmt.Errorf("error insert privilege%s affected %v: %v", s.ValueString(privilege), affected, err)
+			return res, fmt.Errorf("error insert privilege%s affected %v: %w", s.ValueString(privilege), affected, err)
 		}
 		if affected != 1 {
 			return res, fmt.Errorf("error insert privilege%s affected %v", s.ValueString(privilege), affected)
 		}
 		id, err := rs.LastInsertId()
 		if err != nil {
-			return res, fmt.Errorf("error insert privilege%s get id: %v", s.ValueString(privilege), err)
+			return res, fmt.Errorf("error insert privilege%s get id: %w", s.ValueString(privilege), err)
 		}
 		privilege.ID = &id
+		version := int64(1)
+		privilege.Version = &version
 		res = append(res, privilege)
 	}
+	// implied privileges are looked up once all are added, they may be added
+	// in the same call
+	implies := false
+	for _, privilege := range privileges {
+		if privilege.Implies != nil {
+			v, err := s.setPrivilegeImplies(ctx, tx, *privilege.ID, *privilege.Implies)
+			if err != nil {
+				return nil, err
+			}
+			privilege.Implies = &v
+			implies = true
+		}
+	}
+	if implies {
+		if err := s.checkImplicationCycle(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+	events := []*store.AuditEvent{}
+	for _, privilege := range privileges {
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_PRIVILEGE_ADD, "privilege", *privilege.ID, nil, store.PrivilegeAuditFields(privilege)))
+	}
+	if err := s.audit(ctx, tx, events...); err != nil {
+		return nil, err
+	}
 	err = tx.Commit()
 	return res, err
 }
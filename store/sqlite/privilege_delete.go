@@ -1,37 +1,69 @@
 package sqlite
 
 import (
+	"context"
 	"fmt"
+
+	"github.com/senomas/gohtmx/store"
 )
 
 // DeletePrivileges implements store.Store.
 func (s *SqliteAccountStore) DeletePrivileges(ids []int64) error {
-	tx := s.db.MustBegin()
+	return s.DeletePrivilegesContext(context.Background(), ids)
+}
+
+// DeletePrivilegesContext implements store.Store.
+func (s *SqliteAccountStore) DeletePrivilegesContext(ctx context.Context, ids []int64) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
 	defer tx.Rollback()
-	qry := "DELETE FROM privilege WHERE id IN ("
+	events := []*store.AuditEvent{}
+	for _, id := range ids {
+		before, err := s.auditPrivilege(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_PRIVILEGE_DELETE, "privilege", id, before, nil))
+	}
+	in := ""
 	args := []interface{}{}
 	for i, id := range ids {
 		if i > 0 {
-			qry += ","
+			in += ","
 		}
-		qry += "?"
+		in += "?"
 		args = append(args, id)
 	}
-	qry += ")"
-	rs, err := tx.Exec(qry, args...)
+	// the implications of the deleted privileges go first, so one implied by
+	// another deleted privilege is not in use, nor is one held by soft deleted
+	// users only
+	_, err = tx.ExecContext(ctx, "DELETE FROM privilege_implies WHERE privilege IN ("+in+")", args...)
+	if err != nil {
+		return fmt.Errorf("error delete privilege_implies.privilege%s: %w", s.ValueString(ids), err)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM user_privilege WHERE privilege IN ("+in+") AND user IN (SELECT id FROM user WHERE deleted_at IS NOT NULL)", args...)
 	if err != nil {
-		em := err.Error()
-		if em == "FOREIGN KEY constraint failed" {
-			return fmt.Errorf("error delete privilege.id%s: record in use", s.ValueString(ids))
+		return fmt.Errorf("error delete user_privilege.privilege%s: %w", s.ValueString(ids), err)
+	}
+	qry := "DELETE FROM privilege WHERE id IN (" + in + ")"
+	rs, err := tx.ExecContext(ctx, qry, args...)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return fmt.Errorf("error delete privilege.id%s: %w", s.ValueString(ids), store.ErrInUse)
 		}
-		return fmt.Errorf("error delete privilege.id%s: %v", s.ValueString(ids), err)
+		return fmt.Errorf("error delete privilege.id%s: %w", s.ValueString(ids), err)
 	}
 	affected, err := rs.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error delete privilege.id%s affected: %v", s.ValueString(ids), err)
+		return fmt.Errorf("error delete privilege.id%s affected: %w", s.ValueString(ids), err)
 	}
 	if affected != int64(len(ids)) {
-		return fmt.Errorf("error delete privilege.id%s affected %v", s.ValueString(ids), affected)
+		return fmt.Errorf("error delete privilege.id%s affected %v: %w", s.ValueString(ids), affected, store.ErrNotFound)
+	}
+	if err := s.audit(ctx, tx, events...); err != nil {
+		return err
 	}
 	err = tx.Commit()
 	return err
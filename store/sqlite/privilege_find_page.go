@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// FindPrivilegesPage implements store.Store.
+func (s *SqliteAccountStore) FindPrivilegesPage(
+	f *store.PrivilegeFilter, page store.Page,
+) (*store.PrivilegePage, error) {
+	return s.FindPrivilegesPageContext(context.Background(), f, page)
+}
+
+// FindPrivilegesPageContext implements store.Store.
+func (s *SqliteAccountStore) FindPrivilegesPageContext(
+	ctx context.Context, f *store.PrivilegeFilter, page store.Page,
+) (*store.PrivilegePage, error) {
+	if !s.ValidLimit(page.Limit) {
+		return nil, fmt.Errorf("%w %d", store.ErrInvalidLimit, page.Limit)
+	}
+	sort, err := f.Sort.Resolve(store.PrivilegeSortFields)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := page.ParseCursor(sort)
+	if err != nil {
+		return nil, err
+	}
+	where := filter{}
+	privilegeWhere(&where, f)
+
+	total, estimated, err := s.count(ctx, "privilege", &where, page.Count)
+	if err != nil {
+		return nil, err
+	}
+	order := sort
+	if cursor != nil {
+		where.Keyset(sort, cursor)
+		if cursor.Prev {
+			order = sort.Reverse()
+		}
+	}
+	privileges := []*store.Privilege{}
+	qry := "SELECT id, name, description, version FROM privilege"
+	qry = where.AppendWhere(qry)
+	qry += orderBy(order)
+	qry += " LIMIT ?"
+	args := append(where.args, page.Limit+1)
+	err = s.db.SelectContext(ctx, &privileges, qry, args...)
+	if err != nil {
+		return nil, err
+	}
+	res := store.NewPrivilegePage(privileges, sort, cursor, page.Limit)
+	res.Total, res.Estimated = total, estimated
+	return res, nil
+}
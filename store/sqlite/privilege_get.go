@@ -1,26 +1,94 @@
 package sqlite
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
 	"github.com/senomas/gohtmx/store"
 )
 
 // GetPrivilege implements store.Store.
 func (s *SqliteAccountStore) GetPrivilege(id int64) (*store.Privilege, error) {
+	return s.GetPrivilegeContext(context.Background(), id)
+}
+
+// GetPrivilegeContext implements store.Store.
+func (s *SqliteAccountStore) GetPrivilegeContext(ctx context.Context, id int64) (*store.Privilege, error) {
 	var privilege store.Privilege
-	err := s.db.Get(&privilege, "SELECT id, name, description FROM privilege WHERE id = ?", id)
-	return &privilege, err
+	err := s.db.GetContext(ctx, &privilege, "SELECT id, name, description, version FROM privilege WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error get privilege.id %d: %w", id, store.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &privilege, s.privilegeImplies(ctx, &privilege)
 }
 
 // GetPrivilegeByName implements store.Store.
 func (s *SqliteAccountStore) GetPrivilegeByName(name string) (*store.Privilege, error) {
+	return s.GetPrivilegeByNameContext(context.Background(), name)
+}
+
+// GetPrivilegeByNameContext implements store.Store.
+func (s *SqliteAccountStore) GetPrivilegeByNameContext(ctx context.Context, name string) (*store.Privilege, error) {
 	var privilege store.Privilege
-	err := s.db.Get(&privilege, "SELECT id, name, description FROM privilege WHERE name = ?", name)
-	return &privilege, err
+	err := s.db.GetContext(ctx, &privilege, "SELECT id, name, description, version FROM privilege WHERE name = ?", name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error get privilege.name '%s': %w", name, store.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &privilege, s.privilegeImplies(ctx, &privilege)
 }
 
 // GetUserPrivileges implements store.Store.
 func (s *SqliteAccountStore) GetUserPrivileges(userID int64) ([]store.UserPrivilege, error) {
-	privileges := []store.UserPrivilege{}
-	err := s.db.Select(&privileges, "SELECT p.id, p.name, p.description FROM privilege p JOIN user_privilege up ON p.id = up.privilege WHERE up.user = ?", userID)
-	return privileges, err
+	return s.GetUserPrivilegesContext(context.Background(), userID)
+}
+
+// GetUserPrivilegesContext implements store.Store.
+func (s *SqliteAccountStore) GetUserPrivilegesContext(ctx context.Context, userID int64) ([]store.UserPrivilege, error) {
+	grants := []store.PrivilegeGrant{}
+	qry := "SELECT p.id, p.name, p.description, NULL AS role FROM privilege p JOIN user_privilege up ON p.id = up.privilege WHERE up.user = ? AND up.user IN (SELECT id FROM user WHERE deleted_at IS NULL)" +
+		" UNION ALL SELECT p.id, p.name, p.description, r.name AS role FROM privilege p JOIN role_privilege rp ON p.id = rp.privilege" +
+		" JOIN role r ON r.id = rp.role JOIN user_role ur ON r.id = ur.role WHERE ur.user = ? AND ur.user IN (SELECT id FROM user WHERE deleted_at IS NULL)" +
+		" ORDER BY id, role"
+	err := s.db.SelectContext(ctx, &grants, qry, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	implications, err := s.implications(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	return store.NewUserPrivileges(userID, grants, implications), nil
+}
+
+// privilegeImplies sets the privileges privilege implies, ordered by id, it
+// keeps Implies nil when there are none.
+func (s *SqliteAccountStore) privilegeImplies(ctx context.Context, privilege *store.Privilege) error {
+	implies := []*store.Privilege{}
+	err := s.db.SelectContext(ctx, &implies, "SELECT p.id, p.name, p.description, p.version FROM privilege p JOIN privilege_implies pi ON p.id = pi.implies WHERE pi.privilege = ? ORDER BY p.id", privilege.ID)
+	if err != nil {
+		return err
+	}
+	if len(implies) > 0 {
+		privilege.Implies = &implies
+	}
+	return nil
+}
+
+// implications returns every implication between privileges.
+func (s *SqliteAccountStore) implications(ctx context.Context, q sqlx.QueryerContext) ([]store.Implication, error) {
+	implications := []store.Implication{}
+	err := sqlx.SelectContext(ctx, q, &implications, "SELECT pi.privilege AS implied_by, p.id, p.name, p.description, p.version FROM privilege p JOIN privilege_implies pi ON p.id = pi.implies ORDER BY pi.privilege, p.id")
+	if err != nil {
+		return nil, fmt.Errorf("error select privilege_implies: %w", err)
+	}
+	return implications, nil
 }
@@ -0,0 +1,141 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/senomas/gohtmx/store"
+)
+
+// UpdatePrivilege implements store.Store.
+func (s *SqliteAccountStore) UpdatePrivilege(privilege *store.Privilege) error {
+	return s.UpdatePrivilegeContext(context.Background(), privilege)
+}
+
+// UpdatePrivilegeContext implements store.Store.
+func (s *SqliteAccountStore) UpdatePrivilegeContext(ctx context.Context, privilege *store.Privilege) error {
+	if privilege.ID == nil {
+		return fmt.Errorf("error update privilege%s: %w", s.ValueString(privilege), store.ErrNotFound)
+	}
+	updates := []string{"version = version + 1"}
+	args := []interface{}{}
+	if privilege.Name != nil {
+		updates = append(updates, "name = ?")
+		args = append(args, *privilege.Name)
+	}
+	if privilege.Description != nil {
+		updates = append(updates, "description = ?")
+		args = append(args, *privilege.Description)
+	}
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	before, err := s.auditPrivilege(ctx, tx, *privilege.ID)
+	if err != nil {
+		return err
+	}
+	qry := "UPDATE privilege SET " + strings.Join(updates, ", ") + " WHERE id = ?"
+	args = append(args, *privilege.ID)
+	if privilege.Version != nil {
+		qry += " AND version = ?"
+		args = append(args, *privilege.Version)
+	}
+	rs, err := tx.ExecContext(ctx, qry, args...)
+	if err != nil {
+		if table, field, ok := uniqueViolation(err); ok {
+			return fmt.Errorf("error update privilege%s: %w", s.ValueString(privilege),
+				&store.DuplicateError{Table: table, Field: field, Value: *privilege.Name})
+		}
+		return fmt.Errorf("error update privilege %s: %w", qry, err)
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error update privilege%s affected: %w", s.ValueString(privilege), err)
+	}
+	if affected != 1 {
+		var found int64
+		err := tx.GetContext(ctx, &found, "SELECT count(id) FROM privilege WHERE id = ?", *privilege.ID)
+		if err != nil {
+			return fmt.Errorf("error select privilege.id %v: %w", *privilege.ID, err)
+		}
+		if found == 1 && privilege.Version != nil {
+			return fmt.Errorf("error update privilege%s: %w", s.ValueString(privilege),
+				&store.ConflictError{Table: "privilege", ID: *privilege.ID, Version: *privilege.Version})
+		}
+		return fmt.Errorf("error update privilege%s affected %v: %w", s.ValueString(privilege), affected, store.ErrNotFound)
+	}
+	if privilege.Implies != nil {
+		if _, err := s.setPrivilegeImplies(ctx, tx, *privilege.ID, *privilege.Implies); err != nil {
+			return err
+		}
+		if err := s.checkImplicationCycle(ctx, tx); err != nil {
+			return err
+		}
+	}
+	after, err := s.auditPrivilege(ctx, tx, *privilege.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.audit(ctx, tx, store.NewAuditEvent(ctx, store.AUDIT_PRIVILEGE_UPDATE, "privilege", *privilege.ID, before, after)); err != nil {
+		return err
+	}
+	var version int64
+	err = tx.GetContext(ctx, &version, "SELECT version FROM privilege WHERE id = ?", *privilege.ID)
+	if err != nil {
+		return fmt.Errorf("error get privilege.id %d version: %w", *privilege.ID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	privilege.Version = &version
+	return nil
+}
+
+// setPrivilegeImplies replaces the privileges a privilege implies with
+// implies, looked up by name, and returns them.
+func (s *SqliteAccountStore) setPrivilegeImplies(ctx context.Context, tx *sqlx.Tx, privilegeID int64, implies []*store.Privilege) ([]*store.Privilege, error) {
+	_, err := tx.ExecContext(ctx, "DELETE FROM privilege_implies WHERE privilege = ?", privilegeID)
+	if err != nil {
+		return nil, fmt.Errorf("error delete privilege_implies.privilege %d: %w", privilegeID, err)
+	}
+	res := []*store.Privilege{}
+	for _, p := range implies {
+		privilege := store.Privilege{}
+		err := tx.GetContext(ctx, &privilege, "SELECT id, name, description, version FROM privilege WHERE name = ?", p.Name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get privilege name '%s': %w", *p.Name, store.ErrNotFound)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error get privilege name '%s': %w", *p.Name, err)
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO privilege_implies (privilege, implies) VALUES (?, ?)", privilegeID, *privilege.ID)
+		if err != nil {
+			if _, _, ok := uniqueViolation(err); ok {
+				return nil, fmt.Errorf("error insert privilege_implies(%d, %d): %w", privilegeID, *privilege.ID,
+					&store.DuplicateError{Table: "privilege_implies", Field: "implies", Value: *p.Name})
+			}
+			return nil, fmt.Errorf("error insert privilege_implies(%d, %d): %w", privilegeID, *privilege.ID, err)
+		}
+		res = append(res, &privilege)
+	}
+	return res, nil
+}
+
+// checkImplicationCycle returns a *store.CycleError when a privilege implies
+// itself, tx is rolled back by the caller.
+func (s *SqliteAccountStore) checkImplicationCycle(ctx context.Context, tx *sqlx.Tx) error {
+	implications, err := s.implications(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if cycle := store.ImplicationCycle(implications); cycle != nil {
+		return fmt.Errorf("error update privilege_implies: %w", &store.CycleError{Path: cycle})
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// GetRole implements store.Store.
+func (s *SqliteAccountStore) GetRole(id int64) (*store.Role, error) {
+	return s.GetRoleContext(context.Background(), id)
+}
+
+// GetRoleContext implements store.Store.
+func (s *SqliteAccountStore) GetRoleContext(ctx context.Context, id int64) (*store.Role, error) {
+	var role store.Role
+	err := s.db.GetContext(ctx, &role, "SELECT id, name, description FROM role WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error get role.id %d: %w", id, store.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, s.rolePrivileges(ctx, &role)
+}
+
+// GetRoleByName implements store.Store.
+func (s *SqliteAccountStore) GetRoleByName(name string) (*store.Role, error) {
+	return s.GetRoleByNameContext(context.Background(), name)
+}
+
+// GetRoleByNameContext implements store.Store.
+func (s *SqliteAccountStore) GetRoleByNameContext(ctx context.Context, name string) (*store.Role, error) {
+	var role store.Role
+	err := s.db.GetContext(ctx, &role, "SELECT id, name, description FROM role WHERE name = ?", name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error get role.name '%s': %w", name, store.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, s.rolePrivileges(ctx, &role)
+}
+
+// rolePrivileges sets the privileges of role, ordered by id.
+func (s *SqliteAccountStore) rolePrivileges(ctx context.Context, role *store.Role) error {
+	privileges := []*store.Privilege{}
+	err := s.db.SelectContext(ctx, &privileges, "SELECT p.id, p.name, p.description, p.version FROM privilege p JOIN role_privilege rp ON p.id = rp.privilege WHERE rp.role = ? ORDER BY p.id", role.ID)
+	role.Privileges = &privileges
+	return err
+}
@@ -0,0 +1,39 @@
+package sqlite
+
+import "github.com/senomas/gohtmx/store"
+
+// userSearchMigration indexes the user name and email with fts4, built into
+// go-sqlite3 by default. The sqlite_fts5 build moves the index to fts5 in a
+// later migration, see searchMigrations.
+var userSearchMigration = store.Migration{
+	Version: 5,
+	Name:    "create user_search",
+	Up: []string{
+		`CREATE VIRTUAL TABLE user_search USING fts4(content="user", name, email, tokenize=unicode61)`,
+		// an fts4 content table reads the old values from user, so they are
+		// removed before user changes
+		`CREATE TRIGGER user_search_insert AFTER INSERT ON user BEGIN
+    INSERT INTO user_search(docid, name, email) VALUES (new.id, new.name, new.email);
+  END`,
+		`CREATE TRIGGER user_search_delete BEFORE DELETE ON user BEGIN
+    DELETE FROM user_search WHERE docid = old.id;
+  END`,
+		`CREATE TRIGGER user_search_update_before BEFORE UPDATE OF name, email ON user BEGIN
+    DELETE FROM user_search WHERE docid = old.id;
+  END`,
+		`CREATE TRIGGER user_search_update AFTER UPDATE OF name, email ON user BEGIN
+    INSERT INTO user_search(docid, name, email) VALUES (new.id, new.name, new.email);
+  END`,
+		`INSERT INTO user_search(user_search) VALUES ('rebuild')`,
+	},
+	Down: dropUserSearch,
+}
+
+// dropUserSearch drops the user_search index of either fts version.
+var dropUserSearch = []string{
+	`DROP TRIGGER IF EXISTS user_search_update`,
+	`DROP TRIGGER IF EXISTS user_search_update_before`,
+	`DROP TRIGGER IF EXISTS user_search_delete`,
+	`DROP TRIGGER IF EXISTS user_search_insert`,
+	`DROP TABLE IF EXISTS user_search`,
+}
@@ -0,0 +1,25 @@
+//go:build !sqlite_fts5
+
+package sqlite
+
+import (
+	"strings"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// searchMigrations keep the fts4 user_search of userSearchMigration.
+var searchMigrations = []store.Migration{}
+
+// searchRank returns the ORDER BY expression ranking the matches of terms,
+// fts4 has no ranking function so a term found in the name scores 2 and one
+// found in the email 1.
+func searchRank(terms []string) (string, []interface{}) {
+	scores := []string{}
+	args := []interface{}{}
+	for _, t := range terms {
+		scores = append(scores, "(CASE WHEN u.name LIKE ? THEN 2 ELSE 0 END) + (CASE WHEN u.email LIKE ? THEN 1 ELSE 0 END)")
+		args = append(args, "%"+t+"%", "%"+t+"%")
+	}
+	return "-(" + strings.Join(scores, " + ") + ")", args
+}
@@ -0,0 +1,36 @@
+//go:build sqlite_fts5
+
+package sqlite
+
+import "github.com/senomas/gohtmx/store"
+
+// searchMigrations replace the fts4 user_search with fts5, it needs the
+// sqlite_fts5 build tag of go-sqlite3. The version is known to this build
+// only, so a build without the tag refuses a database indexed with fts5.
+var searchMigrations = []store.Migration{
+	{
+		Version: 11,
+		Name:    "move user_search to fts5",
+		Up: append(append([]string{}, dropUserSearch...),
+			`CREATE VIRTUAL TABLE user_search USING fts5(name, email, content='user', content_rowid='id')`,
+			`CREATE TRIGGER user_search_insert AFTER INSERT ON user BEGIN
+    INSERT INTO user_search(rowid, name, email) VALUES (new.id, new.name, new.email);
+  END`,
+			`CREATE TRIGGER user_search_delete AFTER DELETE ON user BEGIN
+    INSERT INTO user_search(user_search, rowid, name, email) VALUES ('delete', old.id, old.name, old.email);
+  END`,
+			`CREATE TRIGGER user_search_update AFTER UPDATE OF name, email ON user BEGIN
+    INSERT INTO user_search(user_search, rowid, name, email) VALUES ('delete', old.id, old.name, old.email);
+    INSERT INTO user_search(rowid, name, email) VALUES (new.id, new.name, new.email);
+  END`,
+			`INSERT INTO user_search(user_search) VALUES ('rebuild')`,
+		),
+		Down: append(append([]string{}, dropUserSearch...), userSearchMigration.Up...),
+	},
+}
+
+// searchRank returns the ORDER BY expression ranking the matches of terms,
+// bm25 weighs a match in the name twice one in the email.
+func searchRank(terms []string) (string, []interface{}) {
+	return "bm25(user_search, 2.0, 1.0)", nil
+}
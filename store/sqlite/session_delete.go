@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// RevokeSession implements store.SessionStore.
+func (s *SqliteAccountStore) RevokeSession(ctx context.Context, token string) error {
+	rs, err := s.db.ExecContext(ctx, "DELETE FROM session WHERE token_hash = ?", store.SessionTokenHash(token))
+	if err != nil {
+		return fmt.Errorf("error delete session: %w", err)
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error delete session affected: %w", err)
+	}
+	if affected != 1 {
+		return fmt.Errorf("error delete session affected %v: %w", affected, store.ErrNotFound)
+	}
+	return nil
+}
+
+// RevokeUserSessions implements store.SessionStore.
+func (s *SqliteAccountStore) RevokeUserSessions(ctx context.Context, userID int64) (int64, error) {
+	rs, err := s.db.ExecContext(ctx, "DELETE FROM session WHERE user = ?", userID)
+	if err != nil {
+		return 0, fmt.Errorf("error delete session.user %d: %w", userID, err)
+	}
+	return rs.RowsAffected()
+}
+
+// PurgeExpiredSessions implements store.SessionStore.
+func (s *SqliteAccountStore) PurgeExpiredSessions(ctx context.Context) (int64, error) {
+	now := time.Now().UnixMilli()
+	rs, err := s.db.ExecContext(ctx, "DELETE FROM session WHERE expires_at <= ? OR last_seen_at + idle_timeout <= ?", now, now)
+	if err != nil {
+		return 0, fmt.Errorf("error delete expired session: %w", err)
+	}
+	return rs.RowsAffected()
+}
@@ -0,0 +1,18 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/senomas/gohtmx/store/storetest"
+)
+
+func TestSqliteAccountStoreSuite(t *testing.T) {
+	storetest.RunAccountStoreSuite(t, func(t *testing.T, cfg store.Config) store.AccountStore {
+		accountStore, err := store.GetAccountStore("sqlite", cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return accountStore
+	})
+}
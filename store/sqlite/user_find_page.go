@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// FindUsersPage implements store.store.
+func (s *SqliteAccountStore) FindUsersPage(f *store.UserFilter, page store.Page) (*store.UserPage, error) {
+	return s.FindUsersPageContext(context.Background(), f, page)
+}
+
+// FindUsersPageContext implements store.store.
+func (s *SqliteAccountStore) FindUsersPageContext(ctx context.Context, f *store.UserFilter, page store.Page) (*store.UserPage, error) {
+	if !s.ValidLimit(page.Limit) {
+		return nil, fmt.Errorf("%w %d", store.ErrInvalidLimit, page.Limit)
+	}
+	sort, err := f.Sort.Resolve(store.UserSortFields)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := page.ParseCursor(sort)
+	if err != nil {
+		return nil, err
+	}
+	where := filter{}
+	userWhere(&where, f)
+	liveUserWhere(&where, f)
+
+	total, estimated, err := s.count(ctx, "user", &where, page.Count)
+	if err != nil {
+		return nil, err
+	}
+	order := sort
+	if cursor != nil {
+		where.Keyset(sort, cursor)
+		if cursor.Prev {
+			order = sort.Reverse()
+		}
+	}
+	users := []*store.User{}
+	qry := "SELECT id, name, email, password, deleted_at, version FROM user"
+	qry = where.AppendWhere(qry)
+	qry += orderBy(order)
+	qry += " LIMIT ?"
+	args := append(where.args, page.Limit+1)
+	err = s.db.SelectContext(ctx, &users, qry, args...)
+	if err != nil {
+		return nil, err
+	}
+	res := store.NewUserPage(users, sort, cursor, page.Limit)
+	if f.WithPrivileges {
+		err = s.userPrivileges(ctx, res.Users)
+		if err != nil {
+			return nil, err
+		}
+	}
+	res.Total, res.Estimated = total, estimated
+	return res, nil
+}
@@ -1,44 +1,111 @@
 package sqlite
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
 	"github.com/senomas/gohtmx/store"
 )
 
 // GetUser implements store.store.
 func (s *SqliteAccountStore) GetUser(id int64) (*store.User, error) {
+	return s.GetUserContext(context.Background(), id)
+}
+
+// GetUserContext implements store.store.
+func (s *SqliteAccountStore) GetUserContext(ctx context.Context, id int64) (*store.User, error) {
 	var user store.User
-	err := s.db.Get(&user, "SELECT id, name, email, password FROM user WHERE id = ?", id)
+	err := s.db.GetContext(ctx, &user, "SELECT id, name, email, password, version FROM user WHERE id = ? AND deleted_at IS NULL", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error get user.id %d: %w", id, store.ErrNotFound)
+	}
 	if err != nil {
 		return nil, err
 	}
 	privileges := []*store.Privilege{}
-	err = s.db.Select(&privileges, "SELECT p.id, p.name, p.description FROM privilege p JOIN user_privilege up ON p.id = up.privilege WHERE up.user = ?", id)
+	err = s.db.SelectContext(ctx, &privileges, "SELECT p.id, p.name, p.description, p.version FROM privilege p JOIN user_privilege up ON p.id = up.privilege WHERE up.user = ?", id)
 	user.Privileges = &privileges
-	return &user, err
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRoles(ctx, &user); err != nil {
+		return nil, err
+	}
+	return &user, s.userEffectivePrivileges(ctx, &user)
 }
 
 // GetUserByName implements store.store.
 func (s *SqliteAccountStore) GetUserByName(name string) (*store.User, error) {
+	return s.GetUserByNameContext(context.Background(), name)
+}
+
+// GetUserByNameContext implements store.store.
+func (s *SqliteAccountStore) GetUserByNameContext(ctx context.Context, name string) (*store.User, error) {
 	var user store.User
-	err := s.db.Get(&user, "SELECT id, name, email, password FROM user WHERE name = ?", name)
+	err := s.db.GetContext(ctx, &user, "SELECT id, name, email, password, version FROM user WHERE name = ? AND deleted_at IS NULL", name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error get user.name '%s': %w", name, store.ErrNotFound)
+	}
 	if err != nil {
 		return nil, err
 	}
 	privileges := []*store.Privilege{}
-	err = s.db.Select(&privileges, "SELECT p.id, p.name, p.description FROM privilege p JOIN user_privilege up ON p.id = up.privilege WHERE up.user = ?", user.ID)
+	err = s.db.SelectContext(ctx, &privileges, "SELECT p.id, p.name, p.description, p.version FROM privilege p JOIN user_privilege up ON p.id = up.privilege WHERE up.user = ?", user.ID)
 	user.Privileges = &privileges
-	return &user, err
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRoles(ctx, &user); err != nil {
+		return nil, err
+	}
+	return &user, s.userEffectivePrivileges(ctx, &user)
 }
 
 // GetUserByEmail implements store.store.
 func (s *SqliteAccountStore) GetUserByEmail(email string) (*store.User, error) {
+	return s.GetUserByEmailContext(context.Background(), email)
+}
+
+// GetUserByEmailContext implements store.store.
+func (s *SqliteAccountStore) GetUserByEmailContext(ctx context.Context, email string) (*store.User, error) {
 	var user store.User
-	err := s.db.Get(&user, "SELECT id, name, email, password FROM user WHERE email = ?", email)
+	err := s.db.GetContext(ctx, &user, "SELECT id, name, email, password, version FROM user WHERE email = ? AND deleted_at IS NULL", email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error get user.email '%s': %w", email, store.ErrNotFound)
+	}
 	if err != nil {
 		return nil, err
 	}
 	privileges := []*store.Privilege{}
-	err = s.db.Select(&privileges, "SELECT p.id, p.name, p.description FROM privilege p JOIN user_privilege up ON p.id = up.privilege WHERE up.user = ?", user.ID)
+	err = s.db.SelectContext(ctx, &privileges, "SELECT p.id, p.name, p.description, p.version FROM privilege p JOIN user_privilege up ON p.id = up.privilege WHERE up.user = ?", user.ID)
 	user.Privileges = &privileges
-	return &user, err
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRoles(ctx, &user); err != nil {
+		return nil, err
+	}
+	return &user, s.userEffectivePrivileges(ctx, &user)
+}
+
+// userRoles sets the roles of user, ordered by id, without their privileges.
+func (s *SqliteAccountStore) userRoles(ctx context.Context, user *store.User) error {
+	roles := []*store.Role{}
+	err := s.db.SelectContext(ctx, &roles, "SELECT r.id, r.name, r.description FROM role r JOIN user_role ur ON r.id = ur.role WHERE ur.user = ? ORDER BY r.id", user.ID)
+	user.Roles = &roles
+	return err
+}
+
+// userEffectivePrivileges sets the effective privileges of user, see
+// store.User.
+func (s *SqliteAccountStore) userEffectivePrivileges(ctx context.Context, user *store.User) error {
+	ups, err := s.GetUserPrivilegesContext(ctx, *user.ID)
+	if err != nil {
+		return err
+	}
+	privileges := store.EffectivePrivileges(ups)
+	user.EffectivePrivileges = &privileges
+	return nil
 }
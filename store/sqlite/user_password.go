@@ -0,0 +1,132 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/senomas/gohtmx/store"
+)
+
+// ChangePassword implements store.store.
+func (s *SqliteAccountStore) ChangePassword(userID int64, oldPassword string, newPassword string) error {
+	return s.ChangePasswordContext(context.Background(), userID, oldPassword, newPassword)
+}
+
+// ChangePasswordContext implements store.store.
+func (s *SqliteAccountStore) ChangePasswordContext(ctx context.Context, userID int64, oldPassword string, newPassword string) error {
+	// the lengths are checked before the old password is verified
+	if s.passwordPolicy.CheckLength(oldPassword) != nil {
+		return fmt.Errorf("error verify user.id %d password: %w", userID, store.ErrInvalidCredentials)
+	}
+	if err := s.passwordPolicy.CheckLength(newPassword); err != nil {
+		return fmt.Errorf("error update user.id %d password: %w", userID, err)
+	}
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	password, err := s.getPassword(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+	ok, err := store.VerifyPassword(oldPassword, password)
+	if err != nil {
+		return fmt.Errorf("error verify user.id %d password: %w", userID, err)
+	}
+	if !ok {
+		return fmt.Errorf("error verify user.id %d password: %w", userID, store.ErrInvalidCredentials)
+	}
+	err = s.updatePassword(ctx, tx, userID, password, newPassword)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetPassword implements store.store.
+func (s *SqliteAccountStore) SetPassword(userID int64, newPassword string) error {
+	return s.SetPasswordContext(context.Background(), userID, newPassword)
+}
+
+// SetPasswordContext implements store.store.
+func (s *SqliteAccountStore) SetPasswordContext(ctx context.Context, userID int64, newPassword string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	password, err := s.getPassword(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+	err = s.updatePassword(ctx, tx, userID, password, newPassword)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SqliteAccountStore) getPassword(ctx context.Context, tx *sqlx.Tx, userID int64) (string, error) {
+	var password string
+	err := tx.GetContext(ctx, &password, "SELECT password FROM user WHERE id = ? AND deleted_at IS NULL", userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("error get user.id %d: %w", userID, store.ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error get user.id %d: %w", userID, err)
+	}
+	return password, nil
+}
+
+// updatePassword replaces the current password hash of a user by the hash of
+// newPassword, enforcing the password policy, keeping the password history
+// and auditing the change.
+func (s *SqliteAccountStore) updatePassword(ctx context.Context, tx *sqlx.Tx, userID int64, current string, newPassword string) error {
+	policy := s.passwordPolicy
+	err := policy.Validate(newPassword)
+	if err != nil {
+		return fmt.Errorf("error update user.id %d password: %w", userID, err)
+	}
+	if policy.History > 0 {
+		history := []string{}
+		err = tx.SelectContext(ctx, &history, "SELECT password FROM password_history WHERE user = ? ORDER BY id DESC LIMIT ?", userID, policy.History-1)
+		if err != nil {
+			return fmt.Errorf("error select password_history user.id %d: %w", userID, err)
+		}
+		err = policy.ValidateHistory(newPassword, append([]string{current}, history...))
+		if err != nil {
+			return fmt.Errorf("error update user.id %d password: %w", userID, err)
+		}
+	}
+	if policy.History > 1 {
+		_, err = tx.ExecContext(ctx, "INSERT INTO password_history (user, password, created_at) VALUES (?, ?, ?)", userID, current, time.Now().UnixMilli())
+		if err != nil {
+			return fmt.Errorf("error insert password_history user.id %d: %w", userID, err)
+		}
+		ids := []int64{}
+		err = tx.SelectContext(ctx, &ids, "SELECT id FROM password_history WHERE user = ? ORDER BY id DESC LIMIT 1 OFFSET ?", userID, policy.History-1)
+		if err != nil {
+			return fmt.Errorf("error select password_history user.id %d: %w", userID, err)
+		}
+		if len(ids) > 0 {
+			_, err = tx.ExecContext(ctx, "DELETE FROM password_history WHERE user = ? AND id <= ?", userID, ids[0])
+			if err != nil {
+				return fmt.Errorf("error delete password_history user.id %d: %w", userID, err)
+			}
+		}
+	}
+	hash, err := store.HashPasswordWith(s.passwordHasher, newPassword)
+	if err != nil {
+		return fmt.Errorf("error update user.id %d password: %w", userID, err)
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE user SET password = ? WHERE id = ?", hash, userID)
+	if err != nil {
+		return fmt.Errorf("error update user.id %d password: %w", userID, err)
+	}
+	return s.audit(ctx, tx, store.NewPasswordAuditEvent(ctx, userID))
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// PurgeUsers implements store.store.
+func (s *SqliteAccountStore) PurgeUsers(olderThan time.Duration) (int64, error) {
+	return s.PurgeUsersContext(context.Background(), olderThan)
+}
+
+// PurgeUsersContext implements store.store.
+func (s *SqliteAccountStore) PurgeUsersContext(ctx context.Context, olderThan time.Duration) (int64, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	users := []*store.User{}
+	err = tx.SelectContext(ctx, &users, "SELECT id, name, email FROM user WHERE deleted_at <= ?", time.Now().Add(-olderThan).UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("error select deleted user: %w", err)
+	}
+	if len(users) == 0 {
+		return 0, tx.Commit()
+	}
+	events := []*store.AuditEvent{}
+	in := ""
+	args := []interface{}{}
+	for i, user := range users {
+		before, err := s.auditUserRow(ctx, tx, user)
+		if err != nil {
+			return 0, err
+		}
+		events = append(events, store.NewAuditEvent(ctx, store.AUDIT_USER_PURGE, "user", *user.ID, before, nil))
+		if i > 0 {
+			in += ","
+		}
+		in += "?"
+		args = append(args, *user.ID)
+	}
+	rs, err := tx.ExecContext(ctx, "DELETE FROM user WHERE id IN ("+in+") AND deleted_at IS NOT NULL", args...)
+	if err != nil {
+		return 0, fmt.Errorf("error purge deleted user: %w", err)
+	}
+	purged, err := rs.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error purge deleted user affected: %w", err)
+	}
+	if err := s.audit(ctx, tx, events...); err != nil {
+		return 0, err
+	}
+	return purged, tx.Commit()
+}
@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/senomas/gohtmx/store"
+)
+
+// SearchUsers implements store.store.
+func (s *SqliteAccountStore) SearchUsers(query string, limit int) ([]*store.User, error) {
+	return s.SearchUsersContext(context.Background(), query, limit)
+}
+
+// SearchUsersContext implements store.store.
+func (s *SqliteAccountStore) SearchUsersContext(ctx context.Context, query string, limit int) ([]*store.User, error) {
+	if !s.ValidLimit(limit) {
+		return nil, fmt.Errorf("%w %d", store.ErrInvalidLimit, limit)
+	}
+	users := []*store.User{}
+	terms := store.SearchTerms(query)
+	if len(terms) == 0 {
+		return users, nil
+	}
+	// the terms are letters and digits only, a trailing * matches a prefix
+	match := strings.Join(terms, "* ") + "*"
+	rank, args := searchRank(terms)
+	qry := "SELECT u.id, u.name, u.email, u.password, u.version FROM user_search JOIN user u ON u.id = user_search.rowid WHERE user_search MATCH ? AND u.deleted_at IS NULL ORDER BY " + rank + ", u.id LIMIT ?"
+	args = append([]interface{}{match}, args...)
+	args = append(args, limit)
+	err := s.db.SelectContext(ctx, &users, qry, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error search user '%s': %w", query, err)
+	}
+	return users, nil
+}
@@ -1,14 +1,27 @@
 package sqlite
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/senomas/gohtmx/store"
 )
 
+// UpdateUser implements store.store.
 func (s *SqliteAccountStore) UpdateUser(user *store.User) error {
-	updates := []string{}
+	return s.UpdateUserContext(context.Background(), user)
+}
+
+// UpdateUserContext implements store.store.
+func (s *SqliteAccountStore) UpdateUserContext(ctx context.Context, user *store.User) error {
+	if user.ID == nil {
+		return fmt.Errorf("error update user%s: %w", s.ValueString(user), store.ErrNotFound)
+	}
+	updates := []string{"version = version + 1"}
 	args := []interface{}{}
 	if user.Name != nil {
 		updates = append(updates, "name = ?")
@@ -18,26 +31,58 @@ func (s *SqliteAccountStore) UpdateUser(user *store.User) error {
 		updates = append(updates, "email = ?")
 		args = append(args, *user.Email)
 	}
-	if user.Password != nil {
-		updates = append(updates, "password = ?")
-		args = append(args, store.HashPassword(*user.Password))
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
 	}
-	tx := s.db.MustBegin()
 	defer tx.Rollback()
-	if len(updates) > 0 {
-		qry := "UPDATE user SET " + strings.Join(updates, ", ") + " WHERE id = ?"
-		args = append(args, user.ID)
-		rs, err := tx.Exec(qry, args...)
-		if err != nil {
-			return fmt.Errorf("error update user %s: %v", qry, err)
+	before, err := s.auditUser(ctx, tx, *user.ID)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return fmt.Errorf("error update user%s: %w", s.ValueString(user), store.ErrNotFound)
+	}
+	qry := "UPDATE user SET " + strings.Join(updates, ", ") + " WHERE id = ? AND deleted_at IS NULL"
+	args = append(args, user.ID)
+	if user.Version != nil {
+		qry += " AND version = ?"
+		args = append(args, *user.Version)
+	}
+	rs, err := tx.ExecContext(ctx, qry, args...)
+	if err != nil {
+		if table, field, ok := uniqueViolation(err); ok {
+			var v interface{}
+			switch field {
+			case "name":
+				v = *user.Name
+			case "email":
+				v = *user.Email
+			default:
+				v = s.ValueString(user)
+			}
+			return fmt.Errorf("error update user%s: %w", s.ValueString(user),
+				&store.DuplicateError{Table: table, Field: field, Value: v})
 		}
-		affected, err := rs.RowsAffected()
+		return fmt.Errorf("error update user %s: %w", qry, err)
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error update user%s affected: %w", s.ValueString(user), err)
+	}
+	if affected != 1 {
+		// the user was read above, it was updated since user.Version or
+		// deleted by another writer since
+		var found int64
+		err := tx.GetContext(ctx, &found, "SELECT count(id) FROM user WHERE id = ? AND deleted_at IS NULL", user.ID)
 		if err != nil {
-			return fmt.Errorf("error update user%s affected: %v", s.ValueString(user), err)
+			return fmt.Errorf("error select user.id %v: %w", *user.ID, err)
 		}
-		if affected != 1 {
-			return fmt.Errorf("error update user%s affected %v", s.ValueString(user), affected)
+		if found == 1 && user.Version != nil {
+			return fmt.Errorf("error update user%s: %w", s.ValueString(user),
+				&store.ConflictError{Table: "user", ID: *user.ID, Version: *user.Version})
 		}
+		return fmt.Errorf("error update user%s affected %v: %w", s.ValueString(user), affected, store.ErrNotFound)
 	}
 	if user.Privileges != nil {
 		npname := []interface{}{}
@@ -51,15 +96,18 @@ func (s *SqliteAccountStore) UpdateUser(user *store.User) error {
 			npname = append(npname, *privilege.Name)
 		}
 		qry += ")"
-		err := tx.Select(&npid, qry, npname...)
-		if err != nil {
-			return fmt.Errorf("error select privilege '%s' %+v: %v", qry, npname, err)
+		// an empty IN list is a syntax error outside sqlite
+		if len(npname) > 0 {
+			err := tx.SelectContext(ctx, &npid, qry, npname...)
+			if err != nil {
+				return fmt.Errorf("error select privilege '%s' %+v: %w", qry, npname, err)
+			}
 		}
 		opid := []int64{}
 		qry = "SELECT privilege FROM user_privilege WHERE user = ?"
-		err = tx.Select(&opid, qry, user.ID)
+		err := tx.SelectContext(ctx, &opid, qry, user.ID)
 		if err != nil {
-			return fmt.Errorf("error select user_privilege '%s' %+v: %v", qry, user.ID, err)
+			return fmt.Errorf("error select user_privilege '%s' %+v: %w", qry, user.ID, err)
 		}
 		ipid := []int64{}
 		rpid := []int64{}
@@ -90,19 +138,22 @@ func (s *SqliteAccountStore) UpdateUser(user *store.User) error {
 			Privilege int64
 		}
 		if len(ipid) > 0 {
-			ps, err := tx.PrepareNamed("INSERT INTO user_privilege (user, privilege) VALUES (:user, :privilege)")
+			ps, err := tx.PrepareNamedContext(ctx, "INSERT INTO user_privilege (user, privilege) VALUES (:user, :privilege)")
 			if err != nil {
-				return fmt.Errorf("error prepare insert into user_privilege: %v", err)
+				return fmt.Errorf("error prepare insert into user_privilege: %w", err)
 			}
 			for _, n := range ipid {
 				up := UserPrivilege{User: *user.ID, Privilege: n}
-				rs, err := ps.Exec(up)
+				rs, err := ps.ExecContext(ctx, up)
 				if err != nil {
-					return fmt.Errorf("error insert user_privilege%s: %v", s.ValueString(up), err)
+					if _, _, ok := uniqueViolation(err); ok {
+						return fmt.Errorf("error insert user_privilege%s: %w", s.ValueString(up), store.ErrConflict)
+					}
+					return fmt.Errorf("error insert user_privilege%s: %w", s.ValueString(up), err)
 				}
 				affected, err := rs.RowsAffected()
 				if err != nil {
-					return fmt.Errorf("error insert user_privilege%s affected %v: %v", s.ValueString(up), affected, err)
+					return fmt.Errorf("error insert user_privilege%s affected %v: %w", s.ValueString(up), affected, err)
 				}
 				if affected != 1 {
 					return fmt.Errorf("error insert user_privilege%s affected %v", s.ValueString(up), affected)
@@ -110,26 +161,79 @@ func (s *SqliteAccountStore) UpdateUser(user *store.User) error {
 			}
 		}
 		if len(rpid) > 0 {
-			ps, err := tx.PrepareNamed("DELETE FROM user_privilege WHERE user = :user AND privilege = :privilege")
+			ps, err := tx.PrepareNamedContext(ctx, "DELETE FROM user_privilege WHERE user = :user AND privilege = :privilege")
 			if err != nil {
-				return fmt.Errorf("error prepare delete user_privilege: %v", err)
+				return fmt.Errorf("error prepare delete user_privilege: %w", err)
 			}
 			for _, r := range rpid {
 				up := UserPrivilege{User: *user.ID, Privilege: r}
-				rs, err := ps.Exec(up)
+				rs, err := ps.ExecContext(ctx, up)
 				if err != nil {
-					return fmt.Errorf("error delete user_privilege%s: %v", s.ValueString(up), err)
+					return fmt.Errorf("error delete user_privilege%s: %w", s.ValueString(up), err)
 				}
 				affected, err := rs.RowsAffected()
 				if err != nil {
-					return fmt.Errorf("error delete user_privilege%s affected %v: %v", s.ValueString(up), affected, err)
+					return fmt.Errorf("error delete user_privilege%s affected %v: %w", s.ValueString(up), affected, err)
 				}
 				if affected != 1 {
-					return fmt.Errorf("error delete user_privilege%s affected %v", s.ValueString(up), affected)
+					return fmt.Errorf("error delete user_privilege%s affected %v: %w", s.ValueString(up), affected, store.ErrConflict)
 				}
 			}
 		}
 	}
-	err := tx.Commit()
-	return err
+	if user.Roles != nil {
+		if _, err := s.setUserRoles(ctx, tx, *user.ID, *user.Roles); err != nil {
+			return err
+		}
+	}
+	after, err := s.auditUser(ctx, tx, *user.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.audit(ctx, tx, store.NewAuditEvent(ctx, store.AUDIT_USER_UPDATE, "user", *user.ID, before, after)); err != nil {
+		return err
+	}
+	var version int64
+	err = tx.GetContext(ctx, &version, "SELECT version FROM user WHERE id = ?", user.ID)
+	if err != nil {
+		return fmt.Errorf("error get user.id %d version: %w", *user.ID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	user.Version = &version
+	return nil
+}
+
+// setUserRoles replaces the roles of a user with roles, looked up by name,
+// and returns them.
+func (s *SqliteAccountStore) setUserRoles(ctx context.Context, tx *sqlx.Tx, userID int64, roles []*store.Role) ([]*store.Role, error) {
+	_, err := tx.ExecContext(ctx, "DELETE FROM user_role WHERE user = ?", userID)
+	if err != nil {
+		return nil, fmt.Errorf("error delete user_role.user %d: %w", userID, err)
+	}
+	res := []*store.Role{}
+	for _, r := range roles {
+		role := store.Role{}
+		err := tx.GetContext(ctx, &role, "SELECT id, name, description FROM role WHERE name = ?", r.Name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error get role name '%s': %w", *r.Name, store.ErrNotFound)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error get role name '%s': %w", *r.Name, err)
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO user_role (user, role) VALUES (?, ?)", userID, *role.ID)
+		if err != nil {
+			if _, _, ok := uniqueViolation(err); ok {
+				return nil, fmt.Errorf("error insert user_role(%d, %d): %w", userID, *role.ID,
+					&store.DuplicateError{Table: "user_role", Field: "role", Value: *r.Name})
+			}
+			if foreignKeyViolation(err) {
+				return nil, fmt.Errorf("error insert user_role(%d, %d): %w", userID, *role.ID, store.ErrNotFound)
+			}
+			return nil, fmt.Errorf("error insert user_role(%d, %d): %w", userID, *role.ID, err)
+		}
+		res = append(res, &role)
+	}
+	return res, nil
 }
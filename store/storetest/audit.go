@@ -0,0 +1,228 @@
+package storetest
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAudit(t *testing.T, s store.AccountStore) {
+	start := time.Now().Add(-time.Second)
+	admin, err := s.AddUsers([]*store.User{
+		(&store.User{}).SetName("Admin").SetEmail("admin@foo.com").SetPassword("admin"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := store.WithActor(context.Background(), *admin[0].ID)
+	privileges, err := s.AddPrivilegesContext(ctx, []*store.Privilege{
+		(&store.Privilege{}).SetName("Admin").SetDescription("Administrator"),
+		(&store.Privilege{}).SetName("User").SetDescription("User"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := s.AddUsersContext(ctx, []*store.User{
+		(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1").
+			AddPrivilege((&store.Privilege{}).SetName("User")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := *users[0].ID
+
+	t.Run("add", func(t *testing.T) {
+		events, total, err := s.FindAuditEvents(&store.AuditFilter{}, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 4, total)
+		if assert.Len(t, events, 4) {
+			assert.Nil(t, events[0].ActorID, "no actor in ctx")
+			assert.Equal(t, store.AUDIT_USER_ADD, events[0].Action)
+			assert.Equal(t, *admin[0].ID, events[0].TargetID)
+			assert.Equal(t, store.AUDIT_PRIVILEGE_ADD, events[1].Action)
+			assert.Equal(t, "privilege", events[1].Target)
+			assert.Equal(t, *privileges[0].ID, events[1].TargetID)
+			assert.Equal(t, store.AuditChange{After: "Administrator"}, events[1].Changes["description"])
+			e := events[3]
+			assert.NotNil(t, e.ID)
+			assert.Equal(t, admin[0].ID, e.ActorID)
+			assert.Equal(t, store.AUDIT_USER_ADD, e.Action)
+			assert.Equal(t, "user", e.Target)
+			assert.Equal(t, id, e.TargetID)
+			assert.Equal(t, store.AuditChange{After: "User 1"}, e.Changes["name"])
+			assert.Equal(t, store.AuditChange{After: "user1@foo.com"}, e.Changes["email"])
+			assert.Equal(t, store.AuditChange{After: []interface{}{"User"}}, e.Changes["privileges"])
+			assert.NotContains(t, e.Changes, "password")
+			assert.False(t, e.CreatedAt.Before(start))
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		err := s.UpdateUserContext(ctx, (&store.User{}).SetID(id).SetEmail("user1@bar.com").
+			AddPrivilege((&store.Privilege{}).SetName("Admin")))
+		assert.NoError(t, err)
+		f := &store.AuditFilter{}
+		f.Action.Eq(store.AUDIT_USER_UPDATE)
+		events, _, err := s.FindAuditEvents(f, 0, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, map[string]store.AuditChange{
+				"email":      {Before: "user1@foo.com", After: "user1@bar.com"},
+				"privileges": {Before: []interface{}{"User"}, After: []interface{}{"Admin"}},
+			}, events[0].Changes)
+		}
+
+		assert.NoError(t, s.UpdateUserContext(ctx, (&store.User{}).SetID(id).SetName("User 1")))
+		_, total, err := s.FindAuditEvents(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total, "an update changing nothing is not recorded")
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		_, err := s.AddUsersContext(ctx, []*store.User{
+			(&store.User{}).SetName("User 2").SetEmail("user2@foo.com").SetPassword("user2"),
+			(&store.User{}).SetName("User 1").SetEmail("user3@foo.com").SetPassword("user3"),
+		})
+		assert.ErrorIs(t, err, store.ErrDuplicate)
+		err = s.UpdateUserContext(ctx, (&store.User{}).SetID(id).SetName("Admin"))
+		assert.ErrorIs(t, err, store.ErrDuplicate)
+		err = s.DeletePrivilegesContext(ctx, []int64{*privileges[0].ID})
+		assert.ErrorIs(t, err, store.ErrInUse)
+		_, total, err := s.FindAuditEvents(&store.AuditFilter{}, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 5, total, "a failed change is not recorded")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.NoError(t, s.DeleteUsersContext(ctx, []int64{id}))
+		assert.NoError(t, s.DeletePrivilegesContext(ctx, []int64{*privileges[1].ID}))
+		f := &store.AuditFilter{}
+		f.Target.Eq("user")
+		f.TargetID.Eq(id)
+		events, total, err := s.FindAuditEvents(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 3, total)
+		if assert.Len(t, events, 3) {
+			e := events[2]
+			assert.Equal(t, store.AUDIT_USER_DELETE, e.Action)
+			assert.Equal(t, store.AuditChange{Before: "User 1"}, e.Changes["name"])
+			assert.Equal(t, store.AuditChange{Before: []interface{}{"Admin"}}, e.Changes["privileges"])
+		}
+
+		f = &store.AuditFilter{}
+		f.Action.Eq(store.AUDIT_PRIVILEGE_DELETE)
+		events, _, err = s.FindAuditEvents(f, 0, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, *privileges[1].ID, events[0].TargetID)
+			assert.Equal(t, store.AuditChange{Before: "User"}, events[0].Changes["name"])
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		f := &store.AuditFilter{}
+		f.ActorID.Eq(*admin[0].ID)
+		_, total, err := s.FindAuditEvents(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 6, total)
+
+		f = &store.AuditFilter{}
+		f.ActorID.Null(true)
+		_, total, err = s.FindAuditEvents(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+
+		f = &store.AuditFilter{}
+		f.Set(url.Values{"from": {start.Format(time.RFC3339)}, "to": {time.Now().Add(time.Hour).Format(time.RFC3339)}})
+		events, total, err := s.FindAuditEvents(f, 2, 2)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 7, total)
+		if assert.Len(t, events, 2) {
+			assert.Equal(t, *privileges[1].ID, events[0].TargetID)
+		}
+
+		f = &store.AuditFilter{From: time.Now().Add(time.Hour)}
+		_, total, err = s.FindAuditEvents(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, total)
+	})
+
+	t.Run("privilege update", func(t *testing.T) {
+		_, err := s.AddPrivilegesContext(ctx, []*store.Privilege{
+			(&store.Privilege{}).SetName("Audit").SetDescription("Audit"),
+		})
+		assert.NoError(t, err)
+		err = s.UpdatePrivilegeContext(ctx, (&store.Privilege{}).SetID(*privileges[0].ID).SetDescription("Admin").
+			AddImplies((&store.Privilege{}).SetName("Audit")))
+		assert.NoError(t, err)
+		f := &store.AuditFilter{}
+		f.Action.Eq(store.AUDIT_PRIVILEGE_UPDATE)
+		events, _, err := s.FindAuditEvents(f, 0, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, admin[0].ID, events[0].ActorID)
+			assert.Equal(t, *privileges[0].ID, events[0].TargetID)
+			assert.Equal(t, map[string]store.AuditChange{
+				"description": {Before: "Administrator", After: "Admin"},
+				"implies":     {Before: []interface{}{}, After: []interface{}{"Audit"}},
+			}, events[0].Changes)
+		}
+	})
+
+	t.Run("role", func(t *testing.T) {
+		roles, err := s.AddRolesContext(ctx, []*store.Role{
+			(&store.Role{}).SetName("Auditor").SetDescription("Auditor").
+				AddPrivilege((&store.Privilege{}).SetName("Audit")),
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		rid := *roles[0].ID
+		err = s.UpdateRoleContext(ctx, (&store.Role{}).SetID(rid).SetDescription("Audit reader").
+			SetPrivileges([]*store.Privilege{}))
+		assert.NoError(t, err)
+		assert.NoError(t, s.DeleteRolesContext(ctx, []int64{rid}))
+		f := &store.AuditFilter{}
+		f.Target.Eq("role")
+		events, _, err := s.FindAuditEvents(f, 0, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, events, 3) {
+			assert.Equal(t, store.AUDIT_ROLE_ADD, events[0].Action)
+			assert.Equal(t, rid, events[0].TargetID)
+			assert.Equal(t, store.AuditChange{After: []interface{}{"Audit"}}, events[0].Changes["privileges"])
+			assert.Equal(t, store.AUDIT_ROLE_UPDATE, events[1].Action)
+			assert.Equal(t, map[string]store.AuditChange{
+				"description": {Before: "Auditor", After: "Audit reader"},
+				"privileges":  {Before: []interface{}{"Audit"}, After: []interface{}{}},
+			}, events[1].Changes)
+			assert.Equal(t, store.AUDIT_ROLE_DELETE, events[2].Action)
+			assert.Equal(t, store.AuditChange{Before: "Auditor"}, events[2].Changes["name"])
+		}
+	})
+
+	t.Run("password", func(t *testing.T) {
+		assert.NoError(t, s.ChangePasswordContext(ctx, *admin[0].ID, "admin", "admin2"))
+		assert.NoError(t, s.SetPasswordContext(ctx, *admin[0].ID, "admin3"))
+		err := s.ChangePasswordContext(ctx, *admin[0].ID, "wrong", "admin4")
+		assert.ErrorIs(t, err, store.ErrInvalidCredentials)
+		f := &store.AuditFilter{}
+		f.Action.Eq(store.AUDIT_USER_PASSWORD)
+		events, _, err := s.FindAuditEvents(f, 0, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, events, 2) {
+			for _, e := range events {
+				assert.Equal(t, *admin[0].ID, e.TargetID)
+				assert.Equal(t, map[string]store.AuditChange{"password": {After: "changed"}}, e.Changes)
+			}
+		}
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		_, _, err := s.FindAuditEvents(&store.AuditFilter{}, 0, 0)
+		assert.ErrorIs(t, err, store.ErrInvalidLimit)
+	})
+}
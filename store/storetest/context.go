@@ -0,0 +1,57 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func testContext(t *testing.T, s store.AccountStore) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	user := (&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1")
+	privilege := (&store.Privilege{}).SetName("Admin").SetDescription("Administrator")
+
+	_, err := s.AddUsersContext(ctx, []*store.User{user})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = s.AddPrivilegesContext(ctx, []*store.Privilege{privilege})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = s.GetUserContext(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = s.GetUserByNameContext(ctx, "User 1")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = s.GetUserByEmailContext(ctx, "user1@foo.com")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, _, err = s.FindUsersContext(ctx, &store.UserFilter{}, 0, 10)
+	assert.ErrorIs(t, err, context.Canceled)
+	err = s.UpdateUserContext(ctx, (&store.User{}).SetID(1).SetName("User One"))
+	assert.ErrorIs(t, err, context.Canceled)
+	err = s.DeleteUsersContext(ctx, []int64{1})
+	assert.ErrorIs(t, err, context.Canceled)
+	err = s.RestoreUsersContext(ctx, []int64{1})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = s.PurgeUsersContext(ctx, 0)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = s.AuthenticateContext(ctx, "User 1", "user1")
+	assert.ErrorIs(t, err, context.Canceled)
+	err = s.ChangePasswordContext(ctx, 1, "user1", "new-user1")
+	assert.ErrorIs(t, err, context.Canceled)
+	err = s.SetPasswordContext(ctx, 1, "new-user1")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = s.GetPrivilegeContext(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = s.GetPrivilegeByNameContext(ctx, "Admin")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, _, err = s.FindPrivilegesContext(ctx, &store.PrivilegeFilter{}, 0, 10)
+	assert.ErrorIs(t, err, context.Canceled)
+	err = s.DeletePrivilegesContext(ctx, []int64{1})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = s.GetUserPrivilegesContext(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, total, err := s.FindUsers(&store.UserFilter{}, 0, 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, total, "nothing is stored")
+}
@@ -0,0 +1,295 @@
+package storetest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func testPassword(t *testing.T, s store.AccountStore) {
+	users, err := s.AddUsers([]*store.User{
+		(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1"),
+		(&store.User{}).SetName("User 2").SetEmail("user2@foo.com").SetPassword("user2"),
+		(&store.User{}).SetName("User 3").SetEmail("user3@foo.com").SetPassword("user3"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := *users[0].ID
+
+	t.Run("authenticate", func(t *testing.T) {
+		user, err := s.Authenticate("User 1", "user1")
+		assert.NoError(t, err)
+		if assert.NotNil(t, user) {
+			assert.Equal(t, id, *user.ID)
+			assert.NotNil(t, user.Privileges)
+		}
+		user, err = s.Authenticate("user3@foo.com", "user3")
+		assert.NoError(t, err)
+		if assert.NotNil(t, user) {
+			assert.Equal(t, "User 3", *user.Name)
+		}
+	})
+
+	t.Run("authenticate prefers name over email", func(t *testing.T) {
+		err := s.UpdateUser((&store.User{}).SetID(id).SetEmail("User 3"))
+		assert.NoError(t, err)
+		user, err := s.Authenticate("User 3", "user3")
+		assert.NoError(t, err)
+		if assert.NotNil(t, user) {
+			assert.Equal(t, *users[2].ID, *user.ID)
+		}
+		err = s.UpdateUser((&store.User{}).SetID(id).SetEmail("user1@foo.com"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("authenticate with invalid credentials", func(t *testing.T) {
+		for _, c := range [][2]string{{"User 1", "user2"}, {"nobody", "user1"}, {"", ""}} {
+			_, err := s.Authenticate(c[0], c[1])
+			assert.ErrorIs(t, err, store.ErrInvalidCredentials, c[0])
+		}
+	})
+
+	t.Run("change password", func(t *testing.T) {
+		err := s.ChangePassword(id, "wrong", "new-user1")
+		assert.ErrorIs(t, err, store.ErrInvalidCredentials)
+		err = s.ChangePassword(id+1000, "user1", "new-user1")
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		err = s.ChangePassword(id, "user1", "")
+		assert.ErrorIs(t, err, store.ErrPasswordPolicy)
+
+		assert.NoError(t, s.ChangePassword(id, "user1", "new-user1"))
+		_, err = s.Authenticate("User 1", "user1")
+		assert.ErrorIs(t, err, store.ErrInvalidCredentials)
+		_, err = s.Authenticate("User 1", "new-user1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("set password", func(t *testing.T) {
+		err := s.SetPassword(id+1000, "user1")
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		err = s.SetPassword(id, "")
+		assert.ErrorIs(t, err, store.ErrPasswordPolicy)
+
+		assert.NoError(t, s.SetPassword(id, "user1"))
+		_, err = s.Authenticate("User 1", "user1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("add with password policy", func(t *testing.T) {
+		_, err := s.AddUsers([]*store.User{
+			(&store.User{}).SetName("User 4").SetEmail("user4@foo.com").SetPassword(""),
+		})
+		assert.ErrorIs(t, err, store.ErrPasswordPolicy)
+		assert.Equal(t, []string{store.RULE_MIN_LENGTH}, violations(t, err))
+	})
+}
+
+// violations returns the rules of the *store.PasswordPolicyError err wraps.
+func violations(t *testing.T, err error) []string {
+	rules := []string{}
+	var perr *store.PasswordPolicyError
+	if assert.ErrorAs(t, err, &perr) {
+		for _, v := range perr.Violations {
+			rules = append(rules, v.Rule)
+		}
+	}
+	return rules
+}
+
+func testPasswordPolicy(t *testing.T, s store.AccountStore) {
+	t.Run("load breached passwords", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "breached.txt")
+		assert.NoError(t, os.WriteFile(file, []byte("Passw0rd!\nQwerty#123\n"), 0o600))
+		breached, err := store.LoadBreachedPasswords(file)
+		assert.NoError(t, err)
+		assert.Equal(t, breachedPasswords, breached)
+	})
+
+	t.Run("add with weak password", func(t *testing.T) {
+		_, err := s.AddUsers([]*store.User{
+			(&store.User{}).SetName("weak").SetEmail("weak@foo.com").SetPassword("abc"),
+		})
+		assert.ErrorIs(t, err, store.ErrPasswordPolicy)
+		assert.Equal(t, []string{store.RULE_MIN_LENGTH, store.RULE_UPPER, store.RULE_DIGIT, store.RULE_SYMBOL}, violations(t, err))
+
+		long := (&store.User{}).SetName("long").SetEmail("long@foo.com").SetPassword("Aa1#" + strings.Repeat("x", 100))
+		assert.Nil(t, long.Password, "hashed by AddUsers once the policy is met")
+		_, err = s.AddUsers([]*store.User{long})
+		assert.Equal(t, []string{store.RULE_MAX_LENGTH}, violations(t, err))
+		assert.Nil(t, long.Password)
+
+		_, err = s.AddUsers([]*store.User{
+			(&store.User{}).SetName("breached").SetEmail("breached@foo.com").SetPassword("Passw0rd!"),
+		})
+		assert.Equal(t, []string{store.RULE_BREACHED}, violations(t, err))
+	})
+
+	users, err := s.AddUsers([]*store.User{
+		(&store.User{}).SetName("strong").SetEmail("strong@foo.com").SetPassword("Secret#123"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := *users[0].ID
+
+	t.Run("change password", func(t *testing.T) {
+		err := s.ChangePassword(id, "Secret#123", "secret")
+		assert.Equal(t, []string{store.RULE_MIN_LENGTH, store.RULE_UPPER, store.RULE_DIGIT, store.RULE_SYMBOL}, violations(t, err))
+		err = s.SetPassword(id, "Qwerty#123")
+		assert.Equal(t, []string{store.RULE_BREACHED}, violations(t, err))
+		_, err = s.Authenticate("strong", "Secret#123")
+		assert.NoError(t, err)
+	})
+
+	t.Run("overlong password", func(t *testing.T) {
+		long := "Aa1#" + strings.Repeat("x", 100)
+		err := s.ChangePassword(id, long, "Secret#127")
+		assert.ErrorIs(t, err, store.ErrInvalidCredentials)
+		err = s.ChangePassword(id, "Secret#123", long)
+		assert.Equal(t, []string{store.RULE_MAX_LENGTH}, violations(t, err))
+		_, err = s.Authenticate("strong", long)
+		assert.ErrorIs(t, err, store.ErrInvalidCredentials)
+	})
+
+	t.Run("password history", func(t *testing.T) {
+		err := s.ChangePassword(id, "Secret#123", "Secret#123")
+		assert.Equal(t, []string{store.RULE_HISTORY}, violations(t, err))
+		assert.NoError(t, s.ChangePassword(id, "Secret#123", "Secret#124"))
+		assert.NoError(t, s.SetPassword(id, "Secret#125"))
+
+		err = s.SetPassword(id, "Secret#123")
+		assert.Equal(t, []string{store.RULE_HISTORY}, violations(t, err))
+		err = s.ChangePassword(id, "Secret#125", "Secret#124")
+		assert.Equal(t, []string{store.RULE_HISTORY}, violations(t, err))
+
+		assert.NoError(t, s.SetPassword(id, "Secret#126"))
+		assert.NoError(t, s.ChangePassword(id, "Secret#126", "Secret#123"))
+		_, err = s.Authenticate("strong", "Secret#123")
+		assert.NoError(t, err)
+	})
+}
+
+func testPasswordHasher(t *testing.T, s store.AccountStore) {
+	t.Run("verify invalid hash", func(t *testing.T) {
+		for _, hash := range []string{"", "plain", "$md5$abc", "$argon2id$v=19$m=1$abc", "$2b$04$short", "$scrypt$ln=x$abc$def",
+			"$argon2id$v=19$m=65536,t=0,p=4$c2FsdHNhbHRzYWx0c2FsdA==$aGFzaGhhc2hoYXNoaGFzaA==",
+			"$argon2id$v=19$m=65536,t=1,p=0$c2FsdHNhbHRzYWx0c2FsdA==$aGFzaGhhc2hoYXNoaGFzaA==",
+			"$argon2id$v=19$m=65536,t=1,p=256$c2FsdHNhbHRzYWx0c2FsdA==$aGFzaGhhc2hoYXNoaGFzaA==",
+			"$argon2id$v=19$m=4294967295,t=1,p=4$c2FsdHNhbHRzYWx0c2FsdA==$aGFzaGhhc2hoYXNoaGFzaA==",
+			"$argon2id$v=19$m=65536,t=1,p=4$c2FsdHNhbHRzYWx0c2FsdA==$",
+			"$scrypt$ln=30,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$aGFzaGhhc2hoYXNoaGFzaA",
+			"$scrypt$ln=15,r=1000000,p=1$c2FsdHNhbHRzYWx0c2FsdA$aGFzaGhhc2hoYXNoaGFzaA",
+			"$scrypt$ln=10,r=8,p=1000000$c2FsdHNhbHRzYWx0c2FsdA$aGFzaGhhc2hoYXNoaGFzaA",
+		} {
+			ok, err := store.VerifyPassword("secret", hash)
+			assert.False(t, ok, hash)
+			assert.ErrorIs(t, err, store.ErrInvalidHash, hash)
+		}
+	})
+
+	t.Run("import legacy users", func(t *testing.T) {
+		bhash, err := bcrypt.GenerateFromPassword([]byte("bcrypt-secret"), 4)
+		assert.NoError(t, err)
+		shash, err := store.ScryptHasher{Params: store.ScryptParams{LogN: 10, R: 8, P: 1, SaltLength: 16, KeyLength: 32}}.Hash("scrypt-secret")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(shash, "$scrypt$ln=10,r=8,p=1$"))
+
+		bcryptUser := (&store.User{}).SetName("bcrypt").SetEmail("bcrypt@foo.com")
+		bcryptUser.Password = new(string)
+		*bcryptUser.Password = string(bhash)
+		scryptUser := (&store.User{}).SetName("scrypt").SetEmail("scrypt@foo.com")
+		scryptUser.Password = &shash
+		_, err = s.AddUsers([]*store.User{bcryptUser, scryptUser})
+		assert.NoError(t, err)
+	})
+
+	t.Run("authenticate legacy users", func(t *testing.T) {
+		_, err := s.Authenticate("bcrypt", "scrypt-secret")
+		assert.ErrorIs(t, err, store.ErrInvalidCredentials)
+		_, err = s.Authenticate("scrypt", "bcrypt-secret")
+		assert.ErrorIs(t, err, store.ErrInvalidCredentials)
+
+		for _, name := range []string{"bcrypt", "scrypt"} {
+			_, err := s.Authenticate(name, name+"-secret")
+			assert.NoError(t, err, name)
+			user, err := s.GetUserByName(name)
+			assert.NoError(t, err, name)
+			assert.True(t, strings.HasPrefix(*user.Password, "$argon2id$"), name)
+			_, err = s.Authenticate(name, name+"-secret")
+			assert.NoError(t, err, name)
+		}
+	})
+
+	t.Run("store hasher", func(t *testing.T) {
+		users, err := s.AddUsers([]*store.User{
+			(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1"),
+		})
+		if assert.NoError(t, err) {
+			assert.Contains(t, *users[0].Password, "$m=32768,t=2,p=2$")
+		}
+		hash, err := store.HashPassword("user1")
+		assert.NoError(t, err)
+		assert.Contains(t, hash, "$m=65536,t=1,p=4$", "the default hasher is unchanged")
+	})
+
+	t.Run("invalid hasher", func(t *testing.T) {
+		for _, h := range []store.PasswordHasher{
+			store.ScryptHasher{},
+			store.BcryptHasher{Cost: 99},
+			store.Argon2idHasher{Params: store.Argon2Params{Memory: 1, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}},
+		} {
+			assert.Error(t, store.Config{PasswordHasher: h}.Validate(), "%#v", h)
+		}
+		assert.NoError(t, store.Config{}.Validate())
+		assert.NoError(t, store.Config{PasswordHasher: store.ScryptHasher{Params: store.DefaultScryptParams}}.Validate())
+	})
+
+	t.Run("authenticate rehashes password", func(t *testing.T) {
+		user := (&store.User{}).SetName("User 2").SetEmail("user2@foo.com")
+		hash, err := store.Argon2idHasher{Params: store.DefaultArgon2Params}.Hash("user2")
+		assert.NoError(t, err)
+		user.Password = &hash
+		users, err := s.AddUsers([]*store.User{user})
+		if err != nil {
+			t.Fatal(err)
+		}
+		id := *users[0].ID
+		hasher := store.Argon2idHasher{Params: argon2Params}
+		assert.True(t, hasher.NeedsRehash(hash))
+
+		user, err = s.Authenticate("User 2", "user2")
+		assert.NoError(t, err)
+		if assert.NotNil(t, user) {
+			assert.Contains(t, *user.Password, "$m=32768,t=2,p=2$")
+		}
+		user, err = s.GetUser(id)
+		assert.NoError(t, err)
+		assert.False(t, hasher.NeedsRehash(*user.Password))
+		_, err = s.Authenticate("User 2", "user2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("default hasher", func(t *testing.T) {
+		hasher := store.GetDefaultPasswordHasher()
+		defer store.SetDefaultPasswordHasher(hasher)
+		store.SetDefaultPasswordHasher(store.BcryptHasher{Cost: 4})
+
+		hash, err := store.HashPassword("secret")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hash, "$2a$04$"))
+		ok, err := store.VerifyPassword("secret", hash)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.False(t, store.NeedsRehash(hash))
+
+		user, err := s.GetUserByName("bcrypt")
+		assert.NoError(t, err)
+		assert.True(t, store.NeedsRehash(*user.Password))
+	})
+}
@@ -0,0 +1,291 @@
+package storetest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func testPrivilege(t *testing.T, s store.AccountStore) {
+	var admin *store.Privilege
+	t.Run("add", func(t *testing.T) {
+		privileges := []*store.Privilege{
+			(&store.Privilege{}).SetName("Admin").SetDescription("Administrator"),
+			(&store.Privilege{}).SetName("User").SetDescription("User"),
+			(&store.Privilege{}).SetName("Guest").SetDescription("Guest"),
+		}
+		actual, err := s.AddPrivileges(privileges)
+		assert.NoError(t, err)
+		if assert.Len(t, actual, 3) {
+			for _, p := range actual {
+				assert.NotNil(t, p.ID)
+			}
+			admin = actual[0]
+		}
+	})
+	if admin == nil {
+		t.FailNow()
+	}
+
+	t.Run("add duplicate", func(t *testing.T) {
+		_, err := s.AddPrivileges([]*store.Privilege{
+			(&store.Privilege{}).SetName("Root").SetDescription("Root"),
+			(&store.Privilege{}).SetName("User").SetDescription("Another user"),
+		})
+		assert.ErrorIs(t, err, store.ErrDuplicate)
+		var derr *store.DuplicateError
+		if assert.ErrorAs(t, err, &derr) {
+			assert.Equal(t, "privilege", derr.Table)
+			assert.Equal(t, "name", derr.Field)
+			assert.EqualValues(t, "User", derr.Value)
+		}
+		_, err = s.GetPrivilegeByName("Root")
+		assert.ErrorIs(t, err, store.ErrNotFound, "failed add is rolled back")
+	})
+
+	t.Run("get", func(t *testing.T) {
+		p, err := s.GetPrivilege(*admin.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, admin, p)
+		p, err = s.GetPrivilegeByName("Admin")
+		assert.NoError(t, err)
+		assert.Equal(t, admin, p)
+
+		_, err = s.GetPrivilege(*admin.ID + 1000)
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		_, err = s.GetPrivilegeByName("Root")
+		assert.ErrorIs(t, err, store.ErrNotFound)
+	})
+
+	t.Run("find", func(t *testing.T) {
+		privileges, total, err := s.FindPrivileges(&store.PrivilegeFilter{}, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 3, total)
+		assert.Equal(t, []string{"Admin", "User", "Guest"}, names(privileges))
+	})
+
+	t.Run("find with filter", func(t *testing.T) {
+		f := &store.PrivilegeFilter{}
+		f.Name.Eq("User")
+		privileges, total, err := s.FindPrivileges(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+		assert.Equal(t, []string{"User"}, names(privileges))
+
+		f = &store.PrivilegeFilter{}
+		f.Description.Like("%ST")
+		privileges, total, err = s.FindPrivileges(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total, "like is case insensitive")
+		assert.Equal(t, []string{"Guest"}, names(privileges))
+
+		f = &store.PrivilegeFilter{}
+		f.ID.Eq(*admin.ID)
+		privileges, total, err = s.FindPrivileges(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+		assert.Equal(t, []string{"Admin"}, names(privileges))
+
+		f = &store.PrivilegeFilter{}
+		f.Name.Set("name", map[string][]string{"name.like": {"_ser"}})
+		f.Description.Set("description", map[string][]string{"description": {"User"}})
+		privileges, total, err = s.FindPrivileges(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+		assert.Equal(t, []string{"User"}, names(privileges))
+
+		f = &store.PrivilegeFilter{}
+		f.Name.Eq("Root")
+		privileges, total, err = s.FindPrivileges(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, total)
+		assert.Empty(t, privileges)
+	})
+
+	t.Run("find with offset and limit", func(t *testing.T) {
+		demo := []string{}
+		for i := 0; i < 20; i++ {
+			demo = append(demo, fmt.Sprintf("Demo-%02d", i))
+		}
+		addPrivileges(t, s, demo...)
+
+		f := &store.PrivilegeFilter{}
+		f.Name.Like("demo-%")
+		privileges, total, err := s.FindPrivileges(f, 5, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 20, total)
+		assert.Equal(t, demo[5:15], names(privileges))
+
+		privileges, total, err = s.FindPrivileges(f, 15, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 20, total)
+		assert.Equal(t, demo[15:], names(privileges))
+
+		privileges, total, err = s.FindPrivileges(f, 20, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 20, total)
+		assert.Empty(t, privileges)
+	})
+
+	t.Run("find with invalid limit", func(t *testing.T) {
+		for _, limit := range []int{0, -1, 101} {
+			_, _, err := s.FindPrivileges(&store.PrivilegeFilter{}, 0, limit)
+			assert.ErrorIs(t, err, store.ErrInvalidLimit, limit)
+		}
+		_, _, err := s.FindPrivileges(&store.PrivilegeFilter{}, 0, 100)
+		assert.NoError(t, err)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		f := &store.PrivilegeFilter{}
+		f.Name.Like("Demo-%")
+		privileges, _, err := s.FindPrivileges(f, 0, 100)
+		assert.NoError(t, err)
+
+		err = s.DeletePrivileges([]int64{*admin.ID + 1000, *privileges[0].ID})
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		_, err = s.GetPrivilege(*privileges[0].ID)
+		assert.NoError(t, err, "failed delete is rolled back")
+
+		assert.NoError(t, s.DeletePrivileges(privilegeIDs(privileges)))
+		_, total, err := s.FindPrivileges(&store.PrivilegeFilter{}, 0, 100)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 3, total)
+	})
+}
+
+func testImplies(t *testing.T, s store.AccountStore) {
+	guest := addPrivileges(t, s, "Guest")[0]
+	var admin, user *store.Privilege
+	t.Run("add", func(t *testing.T) {
+		privileges, err := s.AddPrivileges([]*store.Privilege{
+			(&store.Privilege{}).SetName("Admin").SetDescription("Administrator").
+				AddImplies((&store.Privilege{}).SetName("User")),
+			(&store.Privilege{}).SetName("User").SetDescription("User").
+				AddImplies((&store.Privilege{}).SetName("Guest")),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		admin, user = privileges[0], privileges[1]
+		if assert.NotNil(t, admin.Implies) {
+			assert.Equal(t, []string{"User"}, names(*admin.Implies))
+		}
+
+		p, err := s.GetPrivilegeByName("User")
+		assert.NoError(t, err)
+		if assert.NotNil(t, p.Implies) {
+			assert.Equal(t, []int64{*guest.ID}, privilegeIDs(*p.Implies))
+		}
+		p, err = s.GetPrivilege(*guest.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, p.Implies)
+	})
+	if admin == nil {
+		t.FailNow()
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := s.AddPrivileges([]*store.Privilege{
+			(&store.Privilege{}).SetName("Root").SetDescription("Root").
+				AddImplies((&store.Privilege{}).SetName("Nobody")),
+		})
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		_, err = s.GetPrivilegeByName("Root")
+		assert.ErrorIs(t, err, store.ErrNotFound, "failed add is rolled back")
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		_, err := s.AddPrivileges([]*store.Privilege{
+			(&store.Privilege{}).SetName("Root").SetDescription("Root").
+				AddImplies((&store.Privilege{}).SetName("Root")),
+		})
+		assert.ErrorIs(t, err, store.ErrCycle)
+		_, err = s.GetPrivilegeByName("Root")
+		assert.ErrorIs(t, err, store.ErrNotFound, "failed add is rolled back")
+
+		err = s.UpdatePrivilege((&store.Privilege{}).SetID(*guest.ID).
+			AddImplies((&store.Privilege{}).SetName("Admin")))
+		assert.ErrorIs(t, err, store.ErrCycle)
+		var cerr *store.CycleError
+		if assert.ErrorAs(t, err, &cerr) {
+			assert.Equal(t, []string{"Guest", "Admin", "User", "Guest"}, cerr.Path)
+		}
+		p, err := s.GetPrivilege(*guest.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, p.Implies, "failed update is rolled back")
+	})
+
+	t.Run("update", func(t *testing.T) {
+		err := s.UpdatePrivilege((&store.Privilege{}).SetID(*guest.ID).SetDescription("Visitor"))
+		assert.NoError(t, err)
+		p, err := s.GetPrivilege(*guest.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Guest", *p.Name)
+		assert.Equal(t, "Visitor", *p.Description)
+
+		err = s.UpdatePrivilege((&store.Privilege{}).SetID(*guest.ID).SetName("User"))
+		assert.ErrorIs(t, err, store.ErrDuplicate)
+		err = s.UpdatePrivilege((&store.Privilege{}).SetID(*admin.ID + 1000).SetName("Nobody"))
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		err = s.UpdatePrivilege((&store.Privilege{}).SetName("Nobody"))
+		assert.ErrorIs(t, err, store.ErrNotFound, "privilege without id")
+	})
+
+	t.Run("user privileges", func(t *testing.T) {
+		_, err := s.AddRoles([]*store.Role{
+			(&store.Role{}).SetName("Visitor").SetDescription("Visitor").
+				AddPrivilege((&store.Privilege{}).SetName("Guest")),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		users, err := s.AddUsers([]*store.User{
+			(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1").
+				AddPrivilege((&store.Privilege{}).SetName("Admin")).
+				AddRole((&store.Role{}).SetName("Visitor")),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		up, err := s.GetUserPrivileges(*users[0].ID)
+		assert.NoError(t, err)
+		if assert.Len(t, up, 3) {
+			assert.Equal(t, "Guest", *up[0].Name)
+			assert.False(t, up[0].Direct)
+			assert.Equal(t, []string{"Visitor"}, up[0].Roles)
+			assert.Equal(t, []string{"User"}, up[0].ImpliedBy)
+			assert.Equal(t, "Admin", *up[1].Name)
+			assert.True(t, up[1].Direct)
+			assert.Empty(t, up[1].ImpliedBy)
+			assert.Equal(t, "User", *up[2].Name)
+			assert.False(t, up[2].Direct)
+			assert.Empty(t, up[2].Roles)
+			assert.Equal(t, []string{"Admin"}, up[2].ImpliedBy)
+		}
+
+		u, err := s.GetUser(*users[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Admin"}, names(*u.Privileges))
+		if assert.NotNil(t, u.EffectivePrivileges) {
+			assert.Equal(t, []string{"Guest", "Admin", "User"}, names(*u.EffectivePrivileges))
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := s.DeletePrivileges([]int64{*user.ID})
+		assert.ErrorIs(t, err, store.ErrInUse, "implied by admin")
+
+		err = s.UpdatePrivilege((&store.Privilege{}).SetID(*admin.ID).SetImplies([]*store.Privilege{}))
+		assert.NoError(t, err)
+		p, err := s.GetPrivilege(*admin.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, p.Implies)
+
+		assert.NoError(t, s.DeletePrivileges([]int64{*user.ID}), "its own implications are deleted")
+		p, err = s.GetPrivilege(*guest.ID)
+		assert.NoError(t, err)
+	})
+}
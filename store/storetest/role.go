@@ -0,0 +1,190 @@
+package storetest
+
+import (
+	"testing"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRole(t *testing.T, s store.AccountStore) {
+	privileges := addPrivileges(t, s, "Admin", "User", "Guest")
+	var roles []*store.Role
+	t.Run("add", func(t *testing.T) {
+		var err error
+		roles, err = s.AddRoles([]*store.Role{
+			(&store.Role{}).SetName("Operator").SetDescription("Operator").
+				AddPrivilege((&store.Privilege{}).SetName("User")).
+				AddPrivilege((&store.Privilege{}).SetName("Admin")),
+			(&store.Role{}).SetName("Visitor").SetDescription("Visitor").
+				AddPrivilege((&store.Privilege{}).SetName("Guest")),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, roles, 2)
+		assert.NotNil(t, roles[0].ID)
+
+		role, err := s.GetRole(*roles[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Operator", *role.Name)
+		assert.Equal(t, privileges[:2], *role.Privileges)
+
+		role, err = s.GetRoleByName("Visitor")
+		assert.NoError(t, err)
+		assert.Equal(t, *roles[1].ID, *role.ID)
+		assert.Equal(t, privileges[2:], *role.Privileges)
+
+		_, err = s.GetRole(*roles[1].ID + 1000)
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		_, err = s.GetRoleByName("Nobody")
+		assert.ErrorIs(t, err, store.ErrNotFound)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		_, err := s.AddRoles([]*store.Role{
+			(&store.Role{}).SetName("Auditor").SetDescription("Auditor"),
+			(&store.Role{}).SetName("Operator").SetDescription("Operator"),
+		})
+		assert.ErrorIs(t, err, store.ErrDuplicate)
+		var derr *store.DuplicateError
+		if assert.ErrorAs(t, err, &derr) {
+			assert.Equal(t, "role", derr.Table)
+			assert.Equal(t, "name", derr.Field)
+		}
+		_, err = s.GetRoleByName("Auditor")
+		assert.ErrorIs(t, err, store.ErrNotFound, "failed add is rolled back")
+	})
+
+	t.Run("unknown privilege", func(t *testing.T) {
+		_, err := s.AddRoles([]*store.Role{
+			(&store.Role{}).SetName("Auditor").SetDescription("Auditor").
+				AddPrivilege((&store.Privilege{}).SetName("Root")),
+		})
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		_, err = s.GetRoleByName("Auditor")
+		assert.ErrorIs(t, err, store.ErrNotFound)
+	})
+
+	t.Run("find", func(t *testing.T) {
+		f := &store.RoleFilter{}
+		f.Name.Like("%or")
+		f.Sort = store.Sort{{Field: "name", Desc: true}}
+		found, total, err := s.FindRoles(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, total)
+		if assert.Len(t, found, 2) {
+			assert.Equal(t, "Visitor", *found[0].Name)
+			assert.Equal(t, "Operator", *found[1].Name)
+		}
+
+		_, _, err = s.FindRoles(&store.RoleFilter{Sort: store.Sort{{Field: "privilege"}}}, 0, 10)
+		assert.ErrorIs(t, err, store.ErrInvalidSort)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		err := s.UpdateRole((&store.Role{}).SetID(*roles[0].ID).SetDescription("System operator").
+			SetPrivileges([]*store.Privilege{(&store.Privilege{}).SetName("User")}))
+		assert.NoError(t, err)
+		role, err := s.GetRole(*roles[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Operator", *role.Name)
+		assert.Equal(t, "System operator", *role.Description)
+		assert.Equal(t, privileges[1:2], *role.Privileges)
+
+		err = s.UpdateRole((&store.Role{}).SetID(*roles[0].ID).SetName("Visitor"))
+		assert.ErrorIs(t, err, store.ErrDuplicate)
+		err = s.UpdateRole((&store.Role{}).SetID(*roles[1].ID + 1000).SetName("Nobody"))
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		err = s.UpdateRole((&store.Role{}).SetName("Nobody"))
+		assert.ErrorIs(t, err, store.ErrNotFound, "role without id")
+	})
+
+	var user *store.User
+	t.Run("user roles", func(t *testing.T) {
+		users, err := s.AddUsers([]*store.User{
+			(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1").
+				AddPrivilege((&store.Privilege{}).SetName("User")).
+				AddRole((&store.Role{}).SetName("Operator")),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		user = users[0]
+		got, err := s.GetUser(*user.ID)
+		assert.NoError(t, err)
+		if assert.NotNil(t, got.Roles) && assert.Len(t, *got.Roles, 1) {
+			assert.Equal(t, "Operator", *(*got.Roles)[0].Name)
+		}
+
+		err = s.UpdateUser((&store.User{}).SetID(*user.ID).AddRole((&store.Role{}).SetName("Visitor")))
+		assert.NoError(t, err)
+		got, err = s.GetUser(*user.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, *got.Roles, 1) {
+			assert.Equal(t, *roles[1].ID, *(*got.Roles)[0].ID)
+		}
+		assert.Equal(t, privileges[1:2], *got.Privileges, "direct privileges are kept")
+
+		err = s.UpdateUser((&store.User{}).SetID(*user.ID).AddRole((&store.Role{}).SetName("Nobody")))
+		assert.ErrorIs(t, err, store.ErrNotFound)
+
+		err = s.UpdateUser((&store.User{}).SetID(*user.ID).SetRoles([]*store.Role{
+			(&store.Role{}).SetName("Operator"),
+			(&store.Role{}).SetName("Visitor"),
+		}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("effective privileges", func(t *testing.T) {
+		up, err := s.GetUserPrivileges(*user.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, up, 2) {
+			assert.Equal(t, "User", *up[0].Name)
+			assert.True(t, up[0].Direct)
+			assert.Equal(t, []string{"Operator"}, up[0].Roles)
+			assert.Equal(t, "Guest", *up[1].Name)
+			assert.False(t, up[1].Direct)
+			assert.Equal(t, []string{"Visitor"}, up[1].Roles)
+		}
+	})
+
+	t.Run("authenticate", func(t *testing.T) {
+		users, err := s.AddUsers([]*store.User{
+			(&store.User{}).SetName("User 2").SetEmail("user2@foo.com").SetPassword("user2").
+				AddRole((&store.Role{}).SetName("Visitor")),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.Authenticate("User 2", "user2")
+		if assert.NoError(t, err) {
+			assert.Empty(t, *got.Privileges)
+			if assert.NotNil(t, got.Roles) && assert.Len(t, *got.Roles, 1) {
+				assert.Equal(t, "Visitor", *(*got.Roles)[0].Name)
+			}
+			if assert.NotNil(t, got.EffectivePrivileges) {
+				assert.Equal(t, []string{"Guest"}, names(*got.EffectivePrivileges), "held through the role")
+			}
+		}
+		assert.NoError(t, s.UpdateUser((&store.User{}).SetID(*users[0].ID).SetRoles([]*store.Role{})))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := s.DeletePrivileges([]int64{*privileges[2].ID})
+		assert.ErrorIs(t, err, store.ErrInUse, "privilege of a role")
+		err = s.DeleteRoles([]int64{*roles[1].ID})
+		assert.ErrorIs(t, err, store.ErrInUse)
+
+		assert.NoError(t, s.UpdateUser((&store.User{}).SetID(*user.ID).SetRoles([]*store.Role{})))
+		assert.NoError(t, s.DeleteRoles([]int64{*roles[1].ID}))
+		_, err = s.GetRole(*roles[1].ID)
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		assert.NoError(t, s.DeletePrivileges([]int64{*privileges[2].ID}))
+
+		err = s.DeleteRoles([]int64{*roles[0].ID, *roles[1].ID})
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		_, err = s.GetRole(*roles[0].ID)
+		assert.NoError(t, err, "failed delete is rolled back")
+	})
+}
@@ -0,0 +1,118 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSession(t *testing.T, s store.AccountStore) {
+	sessionStore, ok := s.(store.SessionStore)
+	if !ok {
+		t.Skip("not a session store")
+	}
+	ctx := context.Background()
+	users, err := s.AddUsers([]*store.User{
+		(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1"),
+		(&store.User{}).SetName("User 2").SetEmail("user2@foo.com").SetPassword("user2"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("create and get", func(t *testing.T) {
+		token, session, err := sessionStore.CreateSession(ctx, users[0], store.SessionMeta{IPAddress: "127.0.0.1", UserAgent: "test"})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+		assert.Equal(t, *users[0].ID, *session.UserID)
+
+		actual, err := sessionStore.GetSession(ctx, token)
+		assert.NoError(t, err)
+		assert.Equal(t, *session.ID, *actual.ID)
+		assert.Equal(t, "127.0.0.1", *actual.IPAddress)
+		assert.Equal(t, "test", *actual.UserAgent)
+
+		actual, err = sessionStore.RefreshSession(ctx, token)
+		assert.NoError(t, err)
+		assert.False(t, actual.LastSeenAt.Before(session.LastSeenAt))
+
+		_, err = sessionStore.GetSession(ctx, store.SessionTokenHash(token))
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		_, _, err = sessionStore.CreateSession(ctx, (&store.User{}).SetID(*users[1].ID+1000), store.SessionMeta{})
+		assert.ErrorIs(t, err, store.ErrNotFound)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		token, _, err := sessionStore.CreateSession(ctx, users[0], store.SessionMeta{})
+		assert.NoError(t, err)
+		assert.NoError(t, sessionStore.RevokeSession(ctx, token))
+		_, err = sessionStore.GetSession(ctx, token)
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		assert.ErrorIs(t, sessionStore.RevokeSession(ctx, token), store.ErrNotFound)
+	})
+
+	t.Run("revoke user sessions", func(t *testing.T) {
+		_, _, err := sessionStore.CreateSession(ctx, users[1], store.SessionMeta{})
+		assert.NoError(t, err)
+		revoked, err := sessionStore.RevokeUserSessions(ctx, *users[0].ID)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, revoked)
+		purged, err := sessionStore.PurgeExpiredSessions(ctx)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, purged)
+	})
+
+	t.Run("delete user", func(t *testing.T) {
+		token, _, err := sessionStore.CreateSession(ctx, users[1], store.SessionMeta{})
+		assert.NoError(t, err)
+		assert.NoError(t, s.DeleteUsers([]int64{*users[1].ID}))
+		_, err = sessionStore.GetSession(ctx, token)
+		assert.ErrorIs(t, err, store.ErrNotFound)
+	})
+}
+
+func testSessionExpiry(t *testing.T, s store.AccountStore) {
+	sessionStore, ok := s.(store.SessionStore)
+	if !ok {
+		t.Skip("not a session store")
+	}
+	ctx := context.Background()
+	users, err := s.AddUsers([]*store.User{
+		(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, session, err := sessionStore.CreateSession(ctx, users[0], store.SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.WithinDuration(t, time.Now().Add(time.Hour), session.ExpiresAt, time.Second)
+
+	t.Run("refresh", func(t *testing.T) {
+		time.Sleep(150 * time.Millisecond)
+		session, err := sessionStore.RefreshSession(ctx, token)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), session.LastSeenAt, 50*time.Millisecond)
+		time.Sleep(150 * time.Millisecond)
+		_, err = sessionStore.GetSession(ctx, token)
+		assert.NoError(t, err)
+	})
+
+	t.Run("idle", func(t *testing.T) {
+		time.Sleep(100 * time.Millisecond)
+		_, err := sessionStore.GetSession(ctx, token)
+		assert.ErrorIs(t, err, store.ErrSessionExpired)
+		_, err = sessionStore.RefreshSession(ctx, token)
+		assert.ErrorIs(t, err, store.ErrSessionExpired)
+
+		purged, err := sessionStore.PurgeExpiredSessions(ctx)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, purged)
+		_, err = sessionStore.GetSession(ctx, token)
+		assert.ErrorIs(t, err, store.ErrNotFound)
+	})
+}
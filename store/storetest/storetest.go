@@ -0,0 +1,120 @@
+// Package storetest holds the conformance tests every store.AccountStore
+// implementation must pass.
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// Factory returns a new, empty account store opened with cfg, the backend
+// sets its DSN and connection settings. The suite closes it when done.
+type Factory func(t *testing.T, cfg store.Config) store.AccountStore
+
+var breachedPasswords = map[string]struct{}{"Passw0rd!": {}, "Qwerty#123": {}}
+
+// argon2Params are the argon2id costs of the password hasher group, other
+// than DefaultArgon2Params.
+var argon2Params = store.Argon2Params{Memory: 32 * 1024, Iterations: 2, SaltLength: 16, KeyLength: 32, Parallelism: 2}
+
+// RunAccountStoreSuite runs every conformance test, each group on a store of
+// its own opened with the config of the group.
+func RunAccountStoreSuite(t *testing.T, factory Factory) {
+	groups := []struct {
+		run  func(t *testing.T, s store.AccountStore)
+		name string
+		cfg  store.Config
+	}{
+		{name: "schema", run: testSchema},
+		{name: "privilege", run: testPrivilege},
+		{name: "privilege implies", run: testImplies},
+		{name: "user", run: testUser},
+		{name: "filter operators", run: testFilterOperators},
+		{name: "filter groups", run: testFilterGroups},
+		{name: "sort", run: testSort},
+		{name: "page", run: testPage},
+		{name: "filter by privilege", run: testFilterPrivilege},
+		{name: "search", run: testSearch},
+		{name: "update user", run: testUpdateUser},
+		{name: "version", run: testVersion},
+		{name: "delete", run: testDelete},
+		{name: "soft delete", run: testSoftDelete},
+		{name: "role", run: testRole},
+		{name: "audit", run: testAudit},
+		{name: "password", run: testPassword},
+		{name: "password policy", run: testPasswordPolicy, cfg: store.Config{
+			PasswordPolicy: &store.PasswordPolicy{
+				MinLength:     8,
+				MaxLength:     64,
+				RequireUpper:  true,
+				RequireLower:  true,
+				RequireDigit:  true,
+				RequireSymbol: true,
+				History:       3,
+				Breached:      breachedPasswords,
+			},
+		}},
+		{name: "password hasher", run: testPasswordHasher, cfg: store.Config{
+			PasswordHasher: store.Argon2idHasher{Params: argon2Params},
+		}},
+		{name: "session", run: testSession},
+		{name: "session expiry", run: testSessionExpiry, cfg: store.Config{
+			Session: store.SessionConfig{TTL: time.Hour, IdleTimeout: 200 * time.Millisecond},
+		}},
+		{name: "context", run: testContext},
+	}
+	for _, g := range groups {
+		t.Run(g.name, func(t *testing.T) {
+			s := factory(t, g.cfg)
+			defer s.Close()
+			g.run(t, s)
+		})
+	}
+}
+
+func userIDs(users []*store.User) []int64 {
+	res := []int64{}
+	for _, u := range users {
+		res = append(res, *u.ID)
+	}
+	return res
+}
+
+func privilegeIDs(privileges []*store.Privilege) []int64 {
+	res := []int64{}
+	for _, p := range privileges {
+		res = append(res, *p.ID)
+	}
+	return res
+}
+
+func names(privileges []*store.Privilege) []string {
+	res := []string{}
+	for _, p := range privileges {
+		res = append(res, *p.Name)
+	}
+	return res
+}
+
+// addPrivileges adds the privileges name, each described as "<name> privilege".
+func addPrivileges(t *testing.T, s store.AccountStore, name ...string) []*store.Privilege {
+	privileges := []*store.Privilege{}
+	for _, n := range name {
+		privileges = append(privileges, (&store.Privilege{}).SetName(n).SetDescription(n+" privilege"))
+	}
+	res, err := s.AddPrivileges(privileges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func testSchema(t *testing.T, s store.AccountStore) {
+	version, err := s.SchemaVersion(context.Background())
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, version, int64(0))
+}
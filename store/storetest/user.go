@@ -0,0 +1,336 @@
+package storetest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func testUser(t *testing.T, s store.AccountStore) {
+	privileges := addPrivileges(t, s, "Admin", "User", "Guest")
+	var admin, user1, user2 *store.User
+	t.Run("add", func(t *testing.T) {
+		users := []*store.User{
+			(&store.User{}).SetName("Administrator").SetEmail("admin@cool.com").SetPassword("admin").
+				AddPrivilege((&store.Privilege{}).SetName("Admin")).
+				AddPrivilege((&store.Privilege{}).SetName("User")),
+			(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1").
+				AddPrivilege((&store.Privilege{}).SetName("User")),
+			(&store.User{}).SetName("User 2").SetEmail("user2@bar.com").SetPassword("user2"),
+		}
+		actual, err := s.AddUsers(users)
+		assert.NoError(t, err)
+		if assert.Len(t, actual, 3) {
+			for _, u := range actual {
+				assert.NotNil(t, u.ID)
+			}
+			assert.Equal(t, privileges[:2], *actual[0].Privileges)
+			assert.Equal(t, privileges[1:2], *actual[1].Privileges)
+			admin, user1, user2 = actual[0], actual[1], actual[2]
+		}
+	})
+	if admin == nil {
+		t.FailNow()
+	}
+
+	t.Run("add duplicate", func(t *testing.T) {
+		for _, tc := range []struct {
+			user  *store.User
+			field string
+			value string
+		}{
+			{(&store.User{}).SetName("User 1").SetEmail("user3@foo.com").SetPassword("user3"), "name", "User 1"},
+			{(&store.User{}).SetName("User 3").SetEmail("user1@foo.com").SetPassword("user3"), "email", "user1@foo.com"},
+		} {
+			_, err := s.AddUsers([]*store.User{
+				(&store.User{}).SetName("User 4").SetEmail("user4@foo.com").SetPassword("user4"),
+				tc.user,
+			})
+			assert.ErrorIs(t, err, store.ErrDuplicate)
+			var derr *store.DuplicateError
+			if assert.ErrorAs(t, err, &derr) {
+				assert.Equal(t, "user", derr.Table)
+				assert.Equal(t, tc.field, derr.Field)
+				assert.EqualValues(t, tc.value, derr.Value)
+			}
+			_, err = s.GetUserByName("User 4")
+			assert.ErrorIs(t, err, store.ErrNotFound, "failed add is rolled back")
+		}
+	})
+
+	t.Run("add duplicate privilege", func(t *testing.T) {
+		_, err := s.AddUsers([]*store.User{
+			(&store.User{}).SetName("User 3").SetEmail("user3@foo.com").SetPassword("user3").
+				AddPrivilege((&store.Privilege{}).SetName("User")).
+				AddPrivilege((&store.Privilege{}).SetName("User")),
+		})
+		assert.ErrorIs(t, err, store.ErrDuplicate)
+		var derr *store.DuplicateError
+		if assert.ErrorAs(t, err, &derr) {
+			assert.Equal(t, "user_privilege", derr.Table)
+			assert.Equal(t, "privilege", derr.Field)
+			assert.EqualValues(t, "User", derr.Value)
+		}
+		_, err = s.GetUserByName("User 3")
+		assert.ErrorIs(t, err, store.ErrNotFound, "failed add is rolled back")
+	})
+
+	t.Run("add with unknown privilege", func(t *testing.T) {
+		_, err := s.AddUsers([]*store.User{
+			(&store.User{}).SetName("User 3").SetEmail("user3@foo.com").SetPassword("user3").
+				AddPrivilege((&store.Privilege{}).SetName("Root")),
+		})
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		_, err = s.GetUserByName("User 3")
+		assert.ErrorIs(t, err, store.ErrNotFound, "failed add is rolled back")
+	})
+
+	t.Run("get", func(t *testing.T) {
+		for _, get := range []func() (*store.User, error){
+			func() (*store.User, error) { return s.GetUser(*admin.ID) },
+			func() (*store.User, error) { return s.GetUserByName("Administrator") },
+			func() (*store.User, error) { return s.GetUserByEmail("admin@cool.com") },
+		} {
+			user, err := get()
+			assert.NoError(t, err)
+			if assert.NotNil(t, user) {
+				assert.Equal(t, *admin.ID, *user.ID)
+				assert.Equal(t, "Administrator", *user.Name)
+				assert.Equal(t, "admin@cool.com", *user.Email)
+				assert.Equal(t, *admin.Password, *user.Password)
+				assert.Equal(t, privileges[:2], *user.Privileges)
+			}
+		}
+
+		_, err := s.GetUser(*admin.ID + 1000)
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		_, err = s.GetUserByName("nobody")
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		_, err = s.GetUserByEmail("nobody@foo.com")
+		assert.ErrorIs(t, err, store.ErrNotFound)
+	})
+
+	t.Run("get user privileges", func(t *testing.T) {
+		ups, err := s.GetUserPrivileges(*admin.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, ups, 2) {
+			assert.Equal(t, *privileges[0].ID, *ups[0].ID)
+			assert.Equal(t, "Admin", *ups[0].Name)
+			assert.Equal(t, "Admin privilege", *ups[0].Description)
+			assert.Equal(t, "User", *ups[1].Name)
+		}
+		ups, err = s.GetUserPrivileges(*admin.ID + 1000)
+		assert.NoError(t, err)
+		assert.Empty(t, ups)
+	})
+
+	t.Run("find", func(t *testing.T) {
+		users, total, err := s.FindUsers(&store.UserFilter{}, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 3, total)
+		assert.Equal(t, []int64{*admin.ID, *user1.ID, *user2.ID}, userIDs(users))
+	})
+
+	t.Run("find with filter", func(t *testing.T) {
+		f := &store.UserFilter{}
+		f.Name.Eq("User 1")
+		users, total, err := s.FindUsers(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+		assert.Equal(t, []int64{*user1.ID}, userIDs(users))
+
+		f = &store.UserFilter{}
+		f.Email.Like("%@FOO.com")
+		users, total, err = s.FindUsers(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total, "like is case insensitive")
+		assert.Equal(t, []int64{*user1.ID}, userIDs(users))
+
+		f = &store.UserFilter{}
+		f.ID.Set("id", map[string][]string{"id": {fmt.Sprint(*admin.ID)}})
+		users, total, err = s.FindUsers(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+		assert.Equal(t, []int64{*admin.ID}, userIDs(users))
+
+		f = &store.UserFilter{}
+		f.Name.Like("User %")
+		f.Email.Like("%.com")
+		users, total, err = s.FindUsers(f, 1, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, total)
+		assert.Len(t, users, 1)
+	})
+
+	t.Run("find with invalid limit", func(t *testing.T) {
+		for _, limit := range []int{0, -1, 101} {
+			_, _, err := s.FindUsers(&store.UserFilter{}, 0, limit)
+			assert.ErrorIs(t, err, store.ErrInvalidLimit, limit)
+		}
+	})
+}
+
+func testUpdateUser(t *testing.T, s store.AccountStore) {
+	privileges := addPrivileges(t, s, "Admin", "User", "Guest")
+	users, err := s.AddUsers([]*store.User{
+		(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1").
+			AddPrivilege((&store.Privilege{}).SetName("Admin")).
+			AddPrivilege((&store.Privilege{}).SetName("User")),
+		(&store.User{}).SetName("User 2").SetEmail("user2@foo.com").SetPassword("user2"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := *users[0].ID
+
+	t.Run("name and email", func(t *testing.T) {
+		err := s.UpdateUser((&store.User{}).SetID(id).SetName("User One"))
+		assert.NoError(t, err)
+		err = s.UpdateUser((&store.User{}).SetID(id).SetEmail("one@foo.com"))
+		assert.NoError(t, err)
+		user, err := s.GetUser(id)
+		assert.NoError(t, err)
+		assert.Equal(t, "User One", *user.Name)
+		assert.Equal(t, "one@foo.com", *user.Email)
+		assert.Equal(t, privileges[:2], *user.Privileges, "privileges are kept")
+
+		err = s.UpdateUser((&store.User{}).SetID(id).SetName("User One").SetEmail("one@foo.com"))
+		assert.NoError(t, err, "update to the current values")
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		err := s.UpdateUser((&store.User{}).SetID(id).SetName("User 2"))
+		assert.ErrorIs(t, err, store.ErrDuplicate)
+		var derr *store.DuplicateError
+		if assert.ErrorAs(t, err, &derr) {
+			assert.Equal(t, "name", derr.Field)
+		}
+		err = s.UpdateUser((&store.User{}).SetID(id).SetEmail("user2@foo.com"))
+		assert.ErrorIs(t, err, store.ErrDuplicate)
+		user, err := s.GetUser(id)
+		assert.NoError(t, err)
+		assert.Equal(t, "User One", *user.Name)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		err := s.UpdateUser((&store.User{}).SetID(id + 1000).SetName("Nobody"))
+		assert.ErrorIs(t, err, store.ErrNotFound)
+	})
+
+	t.Run("privileges", func(t *testing.T) {
+		err := s.UpdateUser((&store.User{}).SetID(id).SetPrivileges([]*store.Privilege{
+			(&store.Privilege{}).SetName("User"),
+			(&store.Privilege{}).SetName("Guest"),
+		}))
+		assert.NoError(t, err)
+		user, err := s.GetUser(id)
+		assert.NoError(t, err)
+		assert.Equal(t, privileges[1:], *user.Privileges)
+
+		err = s.UpdateUser((&store.User{}).SetID(id).SetPrivileges([]*store.Privilege{}))
+		assert.NoError(t, err)
+		user, err = s.GetUser(id)
+		assert.NoError(t, err)
+		assert.Empty(t, *user.Privileges)
+
+		err = s.UpdateUser((&store.User{}).SetID(id).AddPrivilege((&store.Privilege{}).SetName("Admin")))
+		assert.NoError(t, err)
+		user, err = s.GetUser(id)
+		assert.NoError(t, err)
+		assert.Equal(t, privileges[:1], *user.Privileges)
+
+		other, err := s.GetUser(*users[1].ID)
+		assert.NoError(t, err)
+		assert.Empty(t, *other.Privileges)
+	})
+
+	t.Run("password is not changed", func(t *testing.T) {
+		err := s.UpdateUser((&store.User{}).SetID(id).SetPassword("changed"))
+		assert.NoError(t, err)
+		_, err = s.Authenticate("User One", "user1")
+		assert.NoError(t, err)
+	})
+}
+
+func testVersion(t *testing.T, s store.AccountStore) {
+	privileges := addPrivileges(t, s, "Admin")
+	users, err := s.AddUsers([]*store.User{
+		(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := *users[0].ID
+
+	t.Run("added", func(t *testing.T) {
+		assert.EqualValues(t, 1, *users[0].Version)
+		user, err := s.GetUser(id)
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, 1, *user.Version)
+		}
+		found, _, err := s.FindUsers(&store.UserFilter{}, 0, 10)
+		if assert.NoError(t, err) && assert.Len(t, found, 1) {
+			assert.EqualValues(t, 1, *found[0].Version)
+		}
+		privilege, err := s.GetPrivilege(*privileges[0].ID)
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, 1, *privilege.Version)
+		}
+	})
+
+	t.Run("update user", func(t *testing.T) {
+		user := (&store.User{}).SetID(id).SetName("User One").SetVersion(1)
+		assert.NoError(t, s.UpdateUser(user))
+		assert.EqualValues(t, 2, *user.Version)
+		stored, err := s.GetUser(id)
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, 2, *stored.Version)
+		}
+	})
+
+	t.Run("stale user", func(t *testing.T) {
+		err := s.UpdateUser((&store.User{}).SetID(id).SetName("User Uno").SetVersion(1))
+		assert.ErrorIs(t, err, store.ErrConflict)
+		var cerr *store.ConflictError
+		if assert.ErrorAs(t, err, &cerr) {
+			assert.Equal(t, "user", cerr.Table)
+			assert.Equal(t, id, cerr.ID)
+			assert.EqualValues(t, 1, cerr.Version)
+		}
+		user, err := s.GetUser(id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "User One", *user.Name)
+			assert.EqualValues(t, 2, *user.Version)
+		}
+		err = s.UpdateUser((&store.User{}).SetID(id + 1000).SetName("Nobody").SetVersion(1))
+		assert.ErrorIs(t, err, store.ErrNotFound)
+	})
+
+	t.Run("update user without version", func(t *testing.T) {
+		user := (&store.User{}).SetID(id).SetEmail("one@foo.com")
+		assert.NoError(t, s.UpdateUser(user))
+		assert.EqualValues(t, 3, *user.Version)
+	})
+
+	t.Run("update privilege", func(t *testing.T) {
+		privilege := (&store.Privilege{}).SetID(*privileges[0].ID).SetDescription("Root").SetVersion(1)
+		assert.NoError(t, s.UpdatePrivilege(privilege))
+		assert.EqualValues(t, 2, *privilege.Version)
+
+		err := s.UpdatePrivilege((&store.Privilege{}).SetID(*privileges[0].ID).SetDescription("Admin").SetVersion(1))
+		assert.ErrorIs(t, err, store.ErrConflict)
+		var cerr *store.ConflictError
+		if assert.ErrorAs(t, err, &cerr) {
+			assert.Equal(t, "privilege", cerr.Table)
+		}
+		stored, err := s.GetPrivilege(*privileges[0].ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Root", *stored.Description)
+			assert.EqualValues(t, 2, *stored.Version)
+		}
+		err = s.UpdatePrivilege((&store.Privilege{}).SetID(*privileges[0].ID + 1000).SetDescription("None").SetVersion(1))
+		assert.ErrorIs(t, err, store.ErrNotFound)
+	})
+}
@@ -0,0 +1,194 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDelete(t *testing.T, s store.AccountStore) {
+	privileges := addPrivileges(t, s, "Admin", "User")
+	users, err := s.AddUsers([]*store.User{
+		(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1").
+			AddPrivilege((&store.Privilege{}).SetName("Admin")),
+		(&store.User{}).SetName("User 2").SetEmail("user2@foo.com").SetPassword("user2"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("privilege in use", func(t *testing.T) {
+		err := s.DeletePrivileges([]int64{*privileges[0].ID})
+		assert.ErrorIs(t, err, store.ErrInUse)
+		_, err = s.GetPrivilege(*privileges[0].ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		err := s.DeleteUsers([]int64{*users[1].ID + 1000})
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		err = s.DeleteUsers([]int64{*users[1].ID, *users[1].ID + 1000})
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		_, err = s.GetUser(*users[1].ID)
+		assert.NoError(t, err, "failed delete is rolled back")
+	})
+
+	t.Run("users", func(t *testing.T) {
+		assert.NoError(t, s.DeleteUsers(userIDs(users)))
+		for _, u := range users {
+			_, err := s.GetUser(*u.ID)
+			assert.ErrorIs(t, err, store.ErrNotFound)
+		}
+		_, total, err := s.FindUsers(&store.UserFilter{}, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, total)
+	})
+
+	t.Run("privilege no longer in use", func(t *testing.T) {
+		assert.NoError(t, s.DeletePrivileges(privilegeIDs(privileges)))
+		_, err := s.GetPrivilege(*privileges[0].ID)
+		assert.ErrorIs(t, err, store.ErrNotFound)
+	})
+}
+
+func testSoftDelete(t *testing.T, s store.AccountStore) {
+	privileges := addPrivileges(t, s, "Admin")
+	users, err := s.AddUsers([]*store.User{
+		(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1").
+			AddPrivilege((&store.Privilege{}).SetName("Admin")),
+		(&store.User{}).SetName("User 2").SetEmail("user2@foo.com").SetPassword("user2"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := *users[0].ID
+	var token string
+	sessionStore, sessions := s.(store.SessionStore)
+	if sessions {
+		token, _, err = sessionStore.CreateSession(context.Background(), users[0], store.SessionMeta{})
+		assert.NoError(t, err)
+	}
+
+	t.Run("hidden", func(t *testing.T) {
+		assert.NoError(t, s.DeleteUsers([]int64{id}))
+		ups, err := s.GetUserPrivileges(id)
+		assert.NoError(t, err)
+		assert.Empty(t, ups)
+		_, err = s.GetUser(id)
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		_, err = s.GetUserByName("User 1")
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		_, err = s.GetUserByEmail("user1@foo.com")
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		_, total, err := s.FindUsers(&store.UserFilter{}, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+		found, err := s.SearchUsers("user", 10)
+		assert.NoError(t, err)
+		assert.Len(t, found, 1)
+		_, err = s.Authenticate("User 1", "user1")
+		assert.ErrorIs(t, err, store.ErrInvalidCredentials)
+		assert.ErrorIs(t, s.UpdateUser((&store.User{}).SetID(id).SetName("User One")), store.ErrNotFound)
+		assert.ErrorIs(t, s.SetPassword(id, "new-user1"), store.ErrNotFound)
+		assert.ErrorIs(t, s.DeleteUsers([]int64{id}), store.ErrNotFound)
+		if sessions {
+			_, err = sessionStore.GetSession(context.Background(), token)
+			assert.ErrorIs(t, err, store.ErrNotFound, "sessions are revoked")
+		}
+	})
+
+	t.Run("with deleted", func(t *testing.T) {
+		found, total, err := s.FindUsers(&store.UserFilter{WithDeleted: true}, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, total)
+		if assert.Len(t, found, 2) {
+			assert.NotNil(t, found[0].DeletedAt)
+			assert.Nil(t, found[1].DeletedAt)
+		}
+		page, err := s.FindUsersPage(&store.UserFilter{WithDeleted: true}, store.Page{Limit: 10, Count: store.COUNT_EXACT})
+		assert.NoError(t, err)
+		assert.Len(t, page.Users, 2)
+	})
+
+	var other []*store.User
+	t.Run("name of deleted user", func(t *testing.T) {
+		var err error
+		other, err = s.AddUsers([]*store.User{
+			(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("other1"),
+		})
+		assert.NoError(t, err)
+		err = s.RestoreUsers([]int64{id})
+		assert.ErrorIs(t, err, store.ErrDuplicate)
+		var derr *store.DuplicateError
+		if assert.ErrorAs(t, err, &derr) {
+			assert.Equal(t, "user", derr.Table)
+		}
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		assert.NoError(t, s.DeleteUsers(userIDs(other)))
+		assert.NoError(t, s.RestoreUsers([]int64{id}))
+		user, err := s.GetUser(id)
+		if assert.NoError(t, err) {
+			assert.Nil(t, user.DeletedAt)
+			assert.Equal(t, privileges, *user.Privileges, "privileges are kept")
+		}
+		ups, err := s.GetUserPrivileges(id)
+		assert.NoError(t, err)
+		if assert.Len(t, ups, 1) {
+			assert.Equal(t, "Admin", *ups[0].Name)
+		}
+		_, err = s.Authenticate("User 1", "user1")
+		assert.NoError(t, err)
+		assert.ErrorIs(t, s.RestoreUsers([]int64{id}), store.ErrNotFound)
+
+		f := &store.AuditFilter{}
+		f.Action.Eq(store.AUDIT_USER_RESTORE)
+		_, total, err := s.FindAuditEvents(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+	})
+
+	t.Run("privilege held by deleted user", func(t *testing.T) {
+		assert.ErrorIs(t, s.DeletePrivileges(privilegeIDs(privileges)), store.ErrInUse)
+		assert.NoError(t, s.DeleteUsers([]int64{id}))
+		assert.NoError(t, s.DeletePrivileges(privilegeIDs(privileges)))
+		assert.NoError(t, s.RestoreUsers([]int64{id}))
+		user, err := s.GetUser(id)
+		if assert.NoError(t, err) {
+			assert.Empty(t, *user.Privileges)
+		}
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		assert.NoError(t, s.DeleteUsers([]int64{*users[1].ID}))
+		purged, err := s.PurgeUsers(time.Hour)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, purged)
+		purged, err = s.PurgeUsers(0)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, purged)
+		assert.ErrorIs(t, s.RestoreUsers([]int64{*users[1].ID}), store.ErrNotFound)
+		_, total, err := s.FindUsers(&store.UserFilter{WithDeleted: true}, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+
+		f := &store.AuditFilter{}
+		f.Action.Eq(store.AUDIT_USER_PURGE)
+		events, _, err := s.FindAuditEvents(f, 0, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, events, 2, "one event per purged user") {
+			targets := []int64{}
+			for _, e := range events {
+				assert.Equal(t, "user", e.Target)
+				assert.Contains(t, e.Changes, "name")
+				assert.Nil(t, e.Changes["name"].After)
+				targets = append(targets, e.TargetID)
+			}
+			assert.Contains(t, targets, *users[1].ID)
+		}
+	})
+}
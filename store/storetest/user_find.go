@@ -0,0 +1,575 @@
+package storetest
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func testFilterOperators(t *testing.T, s store.AccountStore) {
+	users, err := s.AddUsers([]*store.User{
+		(&store.User{}).SetName("User 1").SetEmail("user1@foo.com").SetPassword("user1"),
+		(&store.User{}).SetName("User 2").SetEmail("user2@foo.com").SetPassword("user2"),
+		(&store.User{}).SetName("User 3").SetEmail("user3@bar.com").SetPassword("user3"),
+		(&store.User{}).SetName("User 4").SetEmail("user4@bar.com").SetPassword("user4"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := userIDs(users)
+	find := func(t *testing.T, f *store.UserFilter) []int64 {
+		users, total, err := s.FindUsers(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(users), total)
+		return userIDs(users)
+	}
+
+	t.Run("int64", func(t *testing.T) {
+		for _, tc := range []struct {
+			set      func(f *store.FilterInt64)
+			name     string
+			expected []int64
+		}{
+			{name: "ne", set: func(f *store.FilterInt64) { f.Ne(id[1]) }, expected: []int64{id[0], id[2], id[3]}},
+			{name: "in", set: func(f *store.FilterInt64) { f.In(id[1], id[3], id[3]+1000) }, expected: []int64{id[1], id[3]}},
+			{name: "in empty", set: func(f *store.FilterInt64) { f.In() }, expected: []int64{}},
+			{name: "nin", set: func(f *store.FilterInt64) { f.Nin(id[1], id[3]) }, expected: []int64{id[0], id[2]}},
+			{name: "nin empty", set: func(f *store.FilterInt64) { f.Nin() }, expected: id},
+			{name: "gte", set: func(f *store.FilterInt64) { f.Gte(id[2]) }, expected: id[2:]},
+			{name: "lte", set: func(f *store.FilterInt64) { f.Lte(id[1]) }, expected: id[:2]},
+			{name: "between", set: func(f *store.FilterInt64) { f.Between(id[1], id[2]) }, expected: id[1:3]},
+			{name: "null", set: func(f *store.FilterInt64) { f.Null(true) }, expected: []int64{}},
+			{name: "not null", set: func(f *store.FilterInt64) { f.Null(false) }, expected: id},
+		} {
+			f := &store.UserFilter{}
+			tc.set(&f.ID)
+			assert.Equal(t, tc.expected, find(t, f), tc.name)
+		}
+	})
+
+	t.Run("string", func(t *testing.T) {
+		for _, tc := range []struct {
+			set      func(f *store.UserFilter)
+			name     string
+			expected []int64
+		}{
+			{name: "ne", set: func(f *store.UserFilter) { f.Name.Ne("User 1") }, expected: id[1:]},
+			{name: "in", set: func(f *store.UserFilter) { f.Email.In("user2@foo.com", "user4@bar.com") }, expected: []int64{id[1], id[3]}},
+			{name: "in empty", set: func(f *store.UserFilter) { f.Email.In() }, expected: []int64{}},
+			{name: "nin", set: func(f *store.UserFilter) { f.Name.Nin("User 1", "User 4") }, expected: id[1:3]},
+			{name: "null", set: func(f *store.UserFilter) { f.Email.Null(true) }, expected: []int64{}},
+			{name: "empty", set: func(f *store.UserFilter) { f.Email.Empty(true) }, expected: []int64{}},
+			{name: "not empty", set: func(f *store.UserFilter) { f.Email.Empty(false) }, expected: id},
+			{name: "combined", set: func(f *store.UserFilter) {
+				f.Email.Like("%@bar.com")
+				f.Name.Ne("User 3")
+			}, expected: id[3:]},
+		} {
+			f := &store.UserFilter{}
+			tc.set(f)
+			assert.Equal(t, tc.expected, find(t, f), tc.name)
+		}
+	})
+
+	t.Run("set from query", func(t *testing.T) {
+		for _, tc := range []struct {
+			query    url.Values
+			expected []int64
+		}{
+			{query: url.Values{"id.in": {fmt.Sprintf("%d,%d", id[0], id[2])}}, expected: []int64{id[0], id[2]}},
+			{query: url.Values{"id.in": {fmt.Sprint(id[0]), fmt.Sprint(id[3])}}, expected: []int64{id[0], id[3]}},
+			{query: url.Values{"id.nin": {fmt.Sprint(id[0])}}, expected: id[1:]},
+			{query: url.Values{"id.ne": {fmt.Sprint(id[0])}}, expected: id[1:]},
+			{query: url.Values{"id.gte": {fmt.Sprint(id[1])}, "id.lte": {fmt.Sprint(id[2])}}, expected: id[1:3]},
+			{query: url.Values{"id.lte": {fmt.Sprint(id[0])}}, expected: id[:1]},
+			{query: url.Values{"id.null": {"false"}, "email.null": {"false"}}, expected: id},
+			{query: url.Values{"name.ne": {"User 2"}, "email.like": {"%@foo.com"}}, expected: id[:1]},
+			{query: url.Values{"name.in": {"User 2", "User 3"}}, expected: id[1:3]},
+			{query: url.Values{"name.in": {"User 2,User 3"}}, expected: id[1:3]},
+			{query: url.Values{"email.empty": {"false"}}, expected: id},
+			{query: url.Values{"email.nin": {"user1@foo.com"}}, expected: id[1:]},
+			{query: url.Values{"id": {"x"}, "id.in": {"1,x"}}, expected: id},
+		} {
+			f := &store.UserFilter{}
+			f.ID.Set("id", tc.query)
+			f.Name.Set("name", tc.query)
+			f.Email.Set("email", tc.query)
+			assert.Equal(t, tc.expected, find(t, f), tc.query.Encode())
+		}
+	})
+
+	t.Run("privilege", func(t *testing.T) {
+		privileges := addPrivileges(t, s, "Admin", "User", "Guest")
+		f := &store.PrivilegeFilter{}
+		f.ID.Nin(*privileges[0].ID)
+		f.Description.In("User privilege", "Guest privilege", "Root privilege")
+		f.Name.Ne("Guest")
+		actual, total, err := s.FindPrivileges(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+		assert.Equal(t, []string{"User"}, names(actual))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := s.AddPrivileges([]*store.Privilege{(&store.Privilege{}).SetName("Blank").SetDescription("")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := &store.PrivilegeFilter{}
+		f.Description.Set("description", url.Values{"description.empty": {"true"}})
+		actual, _, err := s.FindPrivileges(f, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Blank"}, names(actual))
+		f.Description.Empty(false)
+		actual, _, err = s.FindPrivileges(f, 0, 10)
+		assert.NoError(t, err)
+		assert.NotContains(t, names(actual), "Blank")
+	})
+}
+
+func testFilterGroups(t *testing.T, s store.AccountStore) {
+	users, err := s.AddUsers([]*store.User{
+		(&store.User{}).SetName("Alice").SetEmail("alice@foo.com").SetPassword("alice"),
+		(&store.User{}).SetName("Bob").SetEmail("bob@foo.com").SetPassword("bob"),
+		(&store.User{}).SetName("Carol").SetEmail("carol@bar.com").SetPassword("carol"),
+		(&store.User{}).SetName("Dave").SetEmail("alice.dave@bar.com").SetPassword("dave"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := userIDs(users)
+	find := func(t *testing.T, f *store.UserFilter) []int64 {
+		users, total, err := s.FindUsers(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(users), total)
+		return userIDs(users)
+	}
+	filter := func(set func(f *store.UserFilter)) *store.UserFilter {
+		f := &store.UserFilter{}
+		set(f)
+		return f
+	}
+
+	t.Run("tree", func(t *testing.T) {
+		for _, tc := range []struct {
+			filter   *store.UserFilter
+			name     string
+			expected []int64
+		}{
+			{name: "or", filter: &store.UserFilter{Or: []*store.UserFilter{
+				filter(func(f *store.UserFilter) { f.Name.Eq("Bob") }),
+				filter(func(f *store.UserFilter) { f.Email.Like("%@bar.com") }),
+			}}, expected: id[1:]},
+			{name: "or empty group", filter: &store.UserFilter{Or: []*store.UserFilter{
+				filter(func(f *store.UserFilter) { f.Name.Eq("Bob") }),
+				{},
+			}}, expected: id},
+			{name: "not", filter: &store.UserFilter{Not: filter(func(f *store.UserFilter) {
+				f.Email.Like("%@foo.com")
+			})}, expected: id[2:]},
+			{name: "and", filter: &store.UserFilter{And: []*store.UserFilter{
+				filter(func(f *store.UserFilter) { f.Email.Like("%@bar.com") }),
+				filter(func(f *store.UserFilter) { f.Name.Ne("Dave") }),
+			}}, expected: id[2:3]},
+			{name: "nested", filter: filter(func(f *store.UserFilter) {
+				f.ID.Gte(id[1])
+				f.Or = []*store.UserFilter{
+					filter(func(f *store.UserFilter) { f.Name.In("Alice", "Bob") }),
+					filter(func(f *store.UserFilter) {
+						f.Not = &store.UserFilter{Or: []*store.UserFilter{
+							filter(func(f *store.UserFilter) { f.Name.Eq("Carol") }),
+							filter(func(f *store.UserFilter) { f.ID.Eq(id[1]) }),
+						}}
+					}),
+				}
+			}), expected: []int64{id[1], id[3]}},
+		} {
+			assert.Equal(t, tc.expected, find(t, tc.filter), tc.name)
+		}
+	})
+
+	t.Run("set from query", func(t *testing.T) {
+		for _, tc := range []struct {
+			query    url.Values
+			expected []int64
+		}{
+			{query: url.Values{"name|email.like": {"%alice%"}}, expected: []int64{id[0], id[3]}},
+			{query: url.Values{"name|email.like": {"%bar%"}, "name.ne": {"Dave"}}, expected: id[2:3]},
+			{query: url.Values{"or.0.name": {"Bob"}, "or.1.id": {fmt.Sprint(id[3])}}, expected: []int64{id[1], id[3]}},
+			{query: url.Values{"not.email.like": {"%@foo.com"}}, expected: id[2:]},
+			{query: url.Values{"or.0.not.name.like": {"%o%"}, "or.1.name": {"Bob"}}, expected: []int64{id[0], id[1], id[3]}},
+			{query: url.Values{"and.0.email.like": {"%@foo.com"}, "and.1.not.name": {"Alice"}}, expected: id[1:2]},
+			{query: url.Values{"or.x.name": {"Bob"}, "foo.name": {"Bob"}}, expected: id},
+		} {
+			f := &store.UserFilter{}
+			f.Set(tc.query)
+			assert.Equal(t, tc.expected, find(t, f), tc.query.Encode())
+		}
+	})
+
+	t.Run("privilege", func(t *testing.T) {
+		addPrivileges(t, s, "Admin", "User", "Guest")
+		f := &store.PrivilegeFilter{}
+		f.Set(url.Values{"or.0.name": {"Admin"}, "or.1.description.like": {"guest%"}, "not.name": {"Guest"}})
+		actual, total, err := s.FindPrivileges(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+		assert.Equal(t, []string{"Admin"}, names(actual))
+	})
+}
+
+func testSort(t *testing.T, s store.AccountStore) {
+	users, err := s.AddUsers([]*store.User{
+		(&store.User{}).SetName("carol").SetEmail("carol@foo.com").SetPassword("carol"),
+		(&store.User{}).SetName("alice").SetEmail("alice@foo.com").SetPassword("alice"),
+		(&store.User{}).SetName("bob").SetEmail("bob@bar.com").SetPassword("bob"),
+		(&store.User{}).SetName("dave").SetEmail("dave@bar.com").SetPassword("dave"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := userIDs(users)
+	find := func(t *testing.T, f *store.UserFilter, offset int64, limit int) []int64 {
+		users, total, err := s.FindUsers(f, offset, limit)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 4, total)
+		return userIDs(users)
+	}
+
+	t.Run("default", func(t *testing.T) {
+		assert.Equal(t, id, find(t, &store.UserFilter{}, 0, 10))
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		for _, tc := range []struct {
+			name     string
+			sort     store.Sort
+			expected []int64
+		}{
+			{name: "name", sort: store.Sort{{Field: "name"}}, expected: []int64{id[1], id[2], id[0], id[3]}},
+			{name: "name desc", sort: store.Sort{{Field: "name", Desc: true}}, expected: []int64{id[3], id[0], id[2], id[1]}},
+			{name: "id desc", sort: store.Sort{{Field: "id", Desc: true}}, expected: []int64{id[3], id[2], id[1], id[0]}},
+		} {
+			assert.Equal(t, tc.expected, find(t, &store.UserFilter{Sort: tc.sort}, 0, 10), tc.name)
+		}
+	})
+
+	t.Run("set from query", func(t *testing.T) {
+		for _, tc := range []struct {
+			query    url.Values
+			expected []int64
+		}{
+			{query: url.Values{"sort": {"email"}}, expected: []int64{id[1], id[2], id[0], id[3]}},
+			{query: url.Values{"sort": {"-name"}}, expected: []int64{id[3], id[0], id[2], id[1]}},
+			{query: url.Values{"sort": {"name,-id"}}, expected: []int64{id[1], id[2], id[0], id[3]}},
+			{query: url.Values{"sort": {"name"}, "email.like": {"%@bar.com"}}, expected: []int64{id[2], id[3]}},
+		} {
+			f := &store.UserFilter{}
+			f.Set(tc.query)
+			users, _, err := s.FindUsers(f, 0, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, userIDs(users), tc.query.Encode())
+		}
+	})
+
+	t.Run("page", func(t *testing.T) {
+		f := &store.UserFilter{Sort: store.Sort{{Field: "name"}}}
+		assert.Equal(t, []int64{id[2], id[0]}, find(t, f, 1, 2))
+	})
+
+	t.Run("tiebreak on id", func(t *testing.T) {
+		p, err := s.AddPrivileges([]*store.Privilege{
+			(&store.Privilege{}).SetName("b").SetDescription("same"),
+			(&store.Privilege{}).SetName("a").SetDescription("same"),
+			(&store.Privilege{}).SetName("c").SetDescription("same"),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, desc := range []bool{false, true} {
+			f := &store.PrivilegeFilter{Sort: store.Sort{{Field: "description", Desc: desc}}}
+			privileges, _, err := s.FindPrivileges(f, 0, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, privilegeIDs(p), privilegeIDs(privileges))
+		}
+
+		f := &store.PrivilegeFilter{Sort: store.Sort{{Field: "id", Desc: true}, {Field: "name"}}}
+		privileges, _, err := s.FindPrivileges(f, 0, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{*p[2].ID, *p[1].ID}, privilegeIDs(privileges))
+	})
+
+	t.Run("invalid field", func(t *testing.T) {
+		for _, field := range []string{"password", "name; DROP TABLE user", ""} {
+			_, _, err := s.FindUsers(&store.UserFilter{Sort: store.Sort{{Field: field}}}, 0, 10)
+			assert.ErrorIs(t, err, store.ErrInvalidSort, field)
+		}
+		_, _, err := s.FindPrivileges(&store.PrivilegeFilter{Sort: store.Sort{{Field: "email"}}}, 0, 10)
+		assert.ErrorIs(t, err, store.ErrInvalidSort)
+	})
+}
+
+func testPage(t *testing.T, s store.AccountStore) {
+	users := []*store.User{}
+	for _, name := range []string{"g", "c", "e", "a", "f", "b", "d"} {
+		users = append(users, (&store.User{}).SetName(name).SetEmail(name+"@foo.com").SetPassword(name))
+	}
+	users, err := s.AddUsers(users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ids in name order
+	id := []int64{*users[3].ID, *users[5].ID, *users[1].ID, *users[6].ID, *users[2].ID, *users[4].ID, *users[0].ID}
+	f := &store.UserFilter{Sort: store.Sort{{Field: "name"}}}
+
+	t.Run("next and prev", func(t *testing.T) {
+		page, err := s.FindUsersPage(f, store.Page{Limit: 3, Count: store.COUNT_EXACT})
+		assert.NoError(t, err)
+		assert.Equal(t, id[:3], userIDs(page.Users))
+		assert.Empty(t, page.Prev)
+		if assert.NotNil(t, page.Total) {
+			assert.EqualValues(t, 7, *page.Total)
+		}
+
+		page, err = s.FindUsersPage(f, store.Page{Limit: 3, Cursor: page.Next})
+		assert.NoError(t, err)
+		assert.Equal(t, id[3:6], userIDs(page.Users))
+		assert.Nil(t, page.Total)
+		assert.NotEmpty(t, page.Prev)
+
+		last, err := s.FindUsersPage(f, store.Page{Limit: 3, Cursor: page.Next})
+		assert.NoError(t, err)
+		assert.Equal(t, id[6:], userIDs(last.Users))
+		assert.Empty(t, last.Next)
+
+		page, err = s.FindUsersPage(f, store.Page{Limit: 3, Cursor: last.Prev})
+		assert.NoError(t, err)
+		assert.Equal(t, id[3:6], userIDs(page.Users))
+		assert.NotEmpty(t, page.Next)
+
+		page, err = s.FindUsersPage(f, store.Page{Limit: 3, Cursor: page.Prev})
+		assert.NoError(t, err)
+		assert.Equal(t, id[:3], userIDs(page.Users))
+		assert.Empty(t, page.Prev)
+		assert.NotEmpty(t, page.Next)
+	})
+
+	t.Run("desc with filter", func(t *testing.T) {
+		f := &store.UserFilter{Sort: store.Sort{{Field: "email", Desc: true}}}
+		f.ID.Nin(id[6])
+		page, err := s.FindUsersPage(f, store.Page{Limit: 4, Count: store.COUNT_ESTIMATE})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{id[5], id[4], id[3], id[2]}, userIDs(page.Users))
+		if assert.NotNil(t, page.Total) {
+			assert.EqualValues(t, 6, *page.Total)
+		}
+		assert.False(t, page.Estimated, "a filtered total is counted")
+		page, err = s.FindUsersPage(f, store.Page{Limit: 4, Cursor: page.Next})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{id[1], id[0]}, userIDs(page.Users))
+		assert.Empty(t, page.Next)
+	})
+
+	t.Run("rows added before the cursor", func(t *testing.T) {
+		page, err := s.FindUsersPage(f, store.Page{Limit: 3})
+		assert.NoError(t, err)
+		added, err := s.AddUsers([]*store.User{(&store.User{}).SetName("0").SetEmail("0@foo.com").SetPassword("0")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		page, err = s.FindUsersPage(f, store.Page{Limit: 3, Cursor: page.Next})
+		assert.NoError(t, err)
+		assert.Equal(t, id[3:6], userIDs(page.Users))
+		assert.NoError(t, s.DeleteUsers(userIDs(added)))
+	})
+
+	t.Run("estimate", func(t *testing.T) {
+		all, err := s.FindUsersPage(&store.UserFilter{WithDeleted: true}, store.Page{Limit: 1, Count: store.COUNT_ESTIMATE})
+		assert.NoError(t, err)
+		live, err := s.FindUsersPage(&store.UserFilter{}, store.Page{Limit: 1, Count: store.COUNT_ESTIMATE})
+		assert.NoError(t, err)
+		assert.Equal(t, all.Estimated, live.Estimated, "the live users are estimated like the table")
+		if assert.NotNil(t, all.Total) && !all.Estimated {
+			assert.EqualValues(t, 8, *all.Total)
+		}
+		if assert.NotNil(t, live.Total) && !live.Estimated {
+			assert.EqualValues(t, 7, *live.Total)
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		page, err := s.FindUsersPage(f, store.Page{Limit: 3})
+		assert.NoError(t, err)
+		for _, page := range []store.Page{
+			{Limit: 3, Cursor: "not a cursor"},
+			{Limit: 3, Cursor: "e30"},
+			{Limit: 3, Cursor: page.Next + "x"},
+			{Limit: 3, Cursor: (&store.Cursor{Sort: "name,id", Values: []interface{}{"a", "x"}}).Encode()},
+			{Limit: 3, Cursor: (&store.Cursor{Sort: "name,id", Values: []interface{}{1, 1}}).Encode()},
+			{Limit: 3, Cursor: (&store.Cursor{Sort: "name,id", Values: []interface{}{"a", 1.5}}).Encode()},
+		} {
+			_, err := s.FindUsersPage(f, page)
+			assert.ErrorIs(t, err, store.ErrInvalidCursor, page.Cursor)
+		}
+		_, err = s.FindUsersPage(&store.UserFilter{}, store.Page{Limit: 3, Cursor: page.Next})
+		assert.ErrorIs(t, err, store.ErrInvalidCursor)
+		_, err = s.FindUsersPage(f, store.Page{Limit: 0})
+		assert.ErrorIs(t, err, store.ErrInvalidLimit)
+	})
+
+	t.Run("set from query", func(t *testing.T) {
+		page := store.Page{}
+		page.Set(url.Values{"limit": {"2"}, "count": {"exact"}})
+		f := &store.UserFilter{}
+		f.Set(url.Values{"sort": {"-name"}})
+		res, err := s.FindUsersPage(f, page)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{id[6], id[5]}, userIDs(res.Users))
+		assert.NotNil(t, res.Total)
+
+		page.Set(url.Values{"cursor": {res.Next}})
+		res, err = s.FindUsersPage(f, page)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{id[4], id[3]}, userIDs(res.Users))
+	})
+
+	t.Run("privilege", func(t *testing.T) {
+		p := addPrivileges(t, s, "Admin", "User", "Guest")
+		f := &store.PrivilegeFilter{}
+		page, err := s.FindPrivilegesPage(f, store.Page{Limit: 2, Count: store.COUNT_EXACT})
+		assert.NoError(t, err)
+		assert.Equal(t, privilegeIDs(p[:2]), privilegeIDs(page.Privileges))
+		if assert.NotNil(t, page.Total) {
+			assert.EqualValues(t, 3, *page.Total)
+		}
+		page, err = s.FindPrivilegesPage(f, store.Page{Limit: 2, Cursor: page.Next})
+		assert.NoError(t, err)
+		assert.Equal(t, privilegeIDs(p[2:]), privilegeIDs(page.Privileges))
+		assert.Empty(t, page.Next)
+	})
+}
+
+func testFilterPrivilege(t *testing.T, s store.AccountStore) {
+	p := addPrivileges(t, s, "Admin", "User", "Guest")
+	users, err := s.AddUsers([]*store.User{
+		(&store.User{}).SetName("root").SetEmail("root@foo.com").SetPassword("root").
+			SetPrivileges([]*store.Privilege{p[0], p[1]}),
+		(&store.User{}).SetName("user").SetEmail("user@foo.com").SetPassword("user").
+			SetPrivileges([]*store.Privilege{p[1]}),
+		(&store.User{}).SetName("guest").SetEmail("guest@foo.com").SetPassword("guest").
+			SetPrivileges([]*store.Privilege{p[2]}),
+		(&store.User{}).SetName("none").SetEmail("none@foo.com").SetPassword("none"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := userIDs(users)
+	find := func(t *testing.T, f *store.UserFilter) []int64 {
+		users, total, err := s.FindUsers(f, 0, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(users), total)
+		return userIDs(users)
+	}
+
+	t.Run("operators", func(t *testing.T) {
+		for _, tc := range []struct {
+			set      func(f *store.FilterPrivilege)
+			name     string
+			expected []int64
+		}{
+			{name: "any", set: func(f *store.FilterPrivilege) { f.Any("Admin", "Guest") }, expected: []int64{id[0], id[2]}},
+			{name: "any empty", set: func(f *store.FilterPrivilege) { f.Any() }, expected: []int64{}},
+			{name: "any unknown", set: func(f *store.FilterPrivilege) { f.Any("Root") }, expected: []int64{}},
+			{name: "all", set: func(f *store.FilterPrivilege) { f.All("Admin", "User") }, expected: id[:1]},
+			{name: "all empty", set: func(f *store.FilterPrivilege) { f.All() }, expected: id},
+			{name: "none", set: func(f *store.FilterPrivilege) { f.None("User") }, expected: id[2:]},
+			{name: "none empty", set: func(f *store.FilterPrivilege) { f.None() }, expected: id},
+			{name: "any id", set: func(f *store.FilterPrivilege) { f.AnyID(*p[1].ID) }, expected: id[:2]},
+			{name: "all id", set: func(f *store.FilterPrivilege) { f.AllID(*p[1].ID, *p[2].ID) }, expected: []int64{}},
+			{name: "none id", set: func(f *store.FilterPrivilege) { f.NoneID(*p[0].ID, *p[2].ID) }, expected: []int64{id[1], id[3]}},
+			{name: "names and ids", set: func(f *store.FilterPrivilege) {
+				f.All("User")
+				f.IDs = []int64{*p[0].ID}
+			}, expected: id[:1]},
+		} {
+			f := &store.UserFilter{}
+			tc.set(&f.Privilege)
+			assert.Equal(t, tc.expected, find(t, f), tc.name)
+		}
+	})
+
+	t.Run("in groups", func(t *testing.T) {
+		f := &store.UserFilter{}
+		f.Set(url.Values{"or.0.privilege.any": {"Guest"}, "or.1.not.privilege.none": {"Admin"}})
+		assert.Equal(t, []int64{id[0], id[2]}, find(t, f))
+
+		f = &store.UserFilter{}
+		f.Set(url.Values{"privilege.id.none": {fmt.Sprint(*p[0].ID)}, "name.ne": {"guest"}})
+		assert.Equal(t, []int64{id[1], id[3]}, find(t, f))
+	})
+
+	t.Run("with privileges", func(t *testing.T) {
+		f := &store.UserFilter{WithPrivileges: true}
+		users, _, err := s.FindUsers(f, 0, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, users, 4) {
+			for i, expected := range [][]string{{"Admin", "User"}, {"User"}, {"Guest"}, {}} {
+				if assert.NotNil(t, users[i].Privileges) {
+					assert.Equal(t, expected, names(*users[i].Privileges))
+				}
+			}
+		}
+
+		users, _, err = s.FindUsers(&store.UserFilter{}, 0, 10)
+		assert.NoError(t, err)
+		for _, u := range users {
+			assert.Nil(t, u.Privileges)
+		}
+
+		f.Privilege.Any("User")
+		page, err := s.FindUsersPage(f, store.Page{Limit: 1})
+		assert.NoError(t, err)
+		if assert.Len(t, page.Users, 1) && assert.NotNil(t, page.Users[0].Privileges) {
+			assert.Equal(t, []string{"Admin", "User"}, names(*page.Users[0].Privileges))
+		}
+	})
+
+	t.Run("held through role and implication", func(t *testing.T) {
+		addPrivileges(t, s, "Report")
+		_, err := s.AddPrivileges([]*store.Privilege{
+			(&store.Privilege{}).SetName("Audit").SetDescription("Audit").
+				AddImplies((&store.Privilege{}).SetName("Report")),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = s.AddRoles([]*store.Role{
+			(&store.Role{}).SetName("Auditor").SetDescription("Auditor").
+				AddPrivilege((&store.Privilege{}).SetName("Audit")),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.UpdateUser((&store.User{}).SetID(id[3]).AddRole((&store.Role{}).SetName("Auditor")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tc := range []struct {
+			set      func(f *store.FilterPrivilege)
+			name     string
+			expected []int64
+		}{
+			{name: "any role", set: func(f *store.FilterPrivilege) { f.Any("Audit") }, expected: id[3:]},
+			{name: "any implied", set: func(f *store.FilterPrivilege) { f.Any("Report") }, expected: id[3:]},
+			{name: "all", set: func(f *store.FilterPrivilege) { f.All("Audit", "Report") }, expected: id[3:]},
+			{name: "none", set: func(f *store.FilterPrivilege) { f.None("Report") }, expected: id[:3]},
+		} {
+			f := &store.UserFilter{}
+			tc.set(&f.Privilege)
+			assert.Equal(t, tc.expected, find(t, f), tc.name)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package storetest
+
+import (
+	"testing"
+
+	"github.com/senomas/gohtmx/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSearch(t *testing.T, s store.AccountStore) {
+	users, err := s.AddUsers([]*store.User{
+		(&store.User{}).SetName("John Smith").SetEmail("john.smith@gmail.com").SetPassword("john"),
+		(&store.User{}).SetName("Joan Doe").SetEmail("joan@yahoo.com").SetPassword("joan"),
+		(&store.User{}).SetName("Bob Jones").SetEmail("bob@gmail.com").SetPassword("bob"),
+		(&store.User{}).SetName("Alice").SetEmail("alice@example.org").SetPassword("alice"),
+		(&store.User{}).SetName("Carol").SetEmail("carol.alice@example.org").SetPassword("carol"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := userIDs(users)
+	search := func(t *testing.T, query string) []int64 {
+		users, err := s.SearchUsers(query, 10)
+		assert.NoError(t, err)
+		return userIDs(users)
+	}
+
+	t.Run("terms", func(t *testing.T) {
+		for _, tc := range []struct {
+			query    string
+			expected []int64
+		}{
+			{query: "smith", expected: id[:1]},
+			{query: "JOAN", expected: id[1:2]},
+			{query: "joh gmail", expected: id[:1]},
+			{query: "jo gmail", expected: []int64{id[0], id[2]}},
+			{query: "john@gmail", expected: id[:1]},
+			{query: "gmail", expected: []int64{id[0], id[2]}},
+			{query: "yahoo smith", expected: []int64{}},
+			{query: "zzzz", expected: []int64{}},
+			{query: "", expected: []int64{}},
+			{query: " @.- ", expected: []int64{}},
+		} {
+			assert.ElementsMatch(t, tc.expected, search(t, tc.query), tc.query)
+		}
+	})
+
+	t.Run("name ranks first", func(t *testing.T) {
+		assert.Equal(t, []int64{id[3], id[4]}, search(t, "alice"))
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		users, err := s.SearchUsers("gmail", 1)
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		_, err = s.SearchUsers("gmail", 0)
+		assert.ErrorIs(t, err, store.ErrInvalidLimit)
+	})
+
+	t.Run("follows updates", func(t *testing.T) {
+		assert.NoError(t, s.UpdateUser((&store.User{}).SetID(id[1]).SetName("Joan Walker")))
+		assert.Equal(t, id[1:2], search(t, "walker"))
+		assert.Equal(t, []int64{}, search(t, "doe"))
+
+		assert.NoError(t, s.DeleteUsers([]int64{id[0]}))
+		assert.Equal(t, id[2:3], search(t, "gmail"))
+
+		added, err := s.AddUsers([]*store.User{
+			(&store.User{}).SetName("Dave Smith").SetEmail("dave@example.org").SetPassword("dave"),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, userIDs(added), search(t, "smith"))
+	})
+}
@@ -1,34 +1,145 @@
 package store
 
-import (
-	"crypto/rand"
-	"encoding/base64"
-	"fmt"
-	"strconv"
-	"strings"
-
-	"golang.org/x/crypto/argon2"
-)
-
+// User is an account, Privileges are the privileges granted to it directly
+// and EffectivePrivileges, set by the getters, all it holds through them, its
+// Roles and their implications. DeletedAt is when the user was soft deleted,
+// in unix milliseconds, nil for a live user. Version counts the updates of the
+// user, UpdateUser fails with a ConflictError when it is set and stale.
 type User struct {
-	Password   *string `db:"password"`
-	Privileges *[]*Privilege
-	Name       *string `db:"name"`
-	Email      *string `db:"email"`
-	ID         *int64  `db:"id"`
+	DeletedAt           *int64  `db:"deleted_at"`
+	Version             *int64  `db:"version"`
+	Password            *string `db:"password"`
+	plainPassword       *string
+	Privileges          *[]*Privilege
+	EffectivePrivileges *[]*Privilege
+	Roles               *[]*Role
+	Name                *string `db:"name"`
+	Email               *string `db:"email"`
+	ID                  *int64  `db:"id"`
 }
 
+// UserPrivilege is a privilege a user holds, Direct when granted to the user
+// itself, through each role in Roles and implied by each privilege, the user
+// holds, in ImpliedBy.
 type UserPrivilege struct {
 	Name        *string
 	Description *string
 	UserID      *int64
 	ID          *int64
+	Roles       []string
+	ImpliedBy   []string
+	Direct      bool
 }
 
+// UserFilter matches the users passing every field filter and every And
+// group, at least one Or group when there are any, and not the Not group.
+// Sort orders the results, WithPrivileges loads the privileges of the users
+// found and WithDeleted includes the soft deleted users, they are ignored in
+// nested groups.
 type UserFilter struct {
-	Name  FilterString
-	Email FilterString
-	ID    FilterInt64
+	Not            *UserFilter
+	And            []*UserFilter
+	Or             []*UserFilter
+	Name           FilterString
+	Email          FilterString
+	Privilege      FilterPrivilege
+	Sort           Sort
+	ID             FilterInt64
+	WithPrivileges bool
+	WithDeleted    bool
+}
+
+// FilterPrivilege matches the users holding any, all or none of the
+// privileges named in Names or with an id in IDs, held as in UserPrivilege:
+// granted directly, through a role or implied. It is set from the query
+// values id.any, id.all and id.none, lists of names, and id.id.any,
+// id.id.all and id.id.none, lists of ids, the first one present wins.
+type FilterPrivilege struct {
+	Names []string
+	IDs   []int64
+	Op    int
+}
+
+func (f *FilterPrivilege) Set(id string, values map[string][]string) {
+	for _, op := range []struct {
+		suffix string
+		op     int
+	}{{".any", OP_ANY}, {".all", OP_ALL}, {".none", OP_NONE}} {
+		if v, ok := values[id+op.suffix]; ok {
+			f.Op = op.op
+			f.Names = v
+			return
+		}
+		if v, ok := values[id+".id"+op.suffix]; ok {
+			if vi, err := parseInt64s(v); err == nil {
+				f.Op = op.op
+				f.IDs = vi
+				return
+			}
+		}
+	}
+}
+
+// Any matches the users holding at least one of the privileges.
+func (f *FilterPrivilege) Any(names ...string) {
+	f.Op = OP_ANY
+	f.Names = names
+}
+
+// All matches the users holding every one of the privileges.
+func (f *FilterPrivilege) All(names ...string) {
+	f.Op = OP_ALL
+	f.Names = names
+}
+
+// None matches the users holding none of the privileges.
+func (f *FilterPrivilege) None(names ...string) {
+	f.Op = OP_NONE
+	f.Names = names
+}
+
+// AnyID matches the users holding at least one of the privileges.
+func (f *FilterPrivilege) AnyID(ids ...int64) {
+	f.Op = OP_ANY
+	f.IDs = ids
+}
+
+// AllID matches the users holding every one of the privileges.
+func (f *FilterPrivilege) AllID(ids ...int64) {
+	f.Op = OP_ALL
+	f.IDs = ids
+}
+
+// NoneID matches the users holding none of the privileges.
+func (f *FilterPrivilege) NoneID(ids ...int64) {
+	f.Op = OP_NONE
+	f.IDs = ids
+}
+
+// Set sets the filter from query values as described in FilterInt64,
+// FilterString and splitFilterQuery, for example "name|email.like=%joe%" or
+// "or.0.name=admin&or.1.not.email.like=%example.com".
+func (f *UserFilter) Set(values map[string][]string) {
+	f.ID.Set("id", values)
+	f.Name.Set("name", values)
+	f.Email.Set("email", values)
+	f.Privilege.Set("privilege", values)
+	f.Sort.Set("sort", values)
+	q := splitFilterQuery(values)
+	for _, v := range q.And {
+		g := &UserFilter{}
+		g.Set(v)
+		f.And = append(f.And, g)
+	}
+	for _, v := range q.Or {
+		g := &UserFilter{}
+		g.Set(v)
+		f.Or = append(f.Or, g)
+	}
+	if q.Not != nil {
+		f.Not = &UserFilter{}
+		f.Not.Set(q.Not)
+	}
 }
 
 type UserList struct {
@@ -41,6 +152,11 @@ func (u *User) SetID(v int64) *User {
 	return u
 }
 
+func (u *User) SetVersion(v int64) *User {
+	u.Version = &v
+	return u
+}
+
 func (u *User) SetName(v string) *User {
 	u.Name = &v
 	return u
@@ -51,16 +167,40 @@ func (u *User) SetEmail(v string) *User {
 	return u
 }
 
+// SetPassword sets the plain password, AddUsers checks it against the
+// password policy before hashing it into Password.
 func (u *User) SetPassword(v string) *User {
-	u.Password = HashPassword(v)
+	u.Password = nil
+	u.plainPassword = &v
 	return u
 }
 
+// PlainPassword returns the password given to SetPassword.
+func (u *User) PlainPassword() (string, bool) {
+	if u.plainPassword == nil {
+		return "", false
+	}
+	return *u.plainPassword, true
+}
+
 func (u *User) SetPrivileges(v []*Privilege) *User {
 	u.Privileges = &v
 	return u
 }
 
+func (u *User) SetRoles(v []*Role) *User {
+	u.Roles = &v
+	return u
+}
+
+func (u *User) AddRole(r *Role) *User {
+	if u.Roles == nil {
+		u.Roles = &[]*Role{}
+	}
+	*u.Roles = append(*u.Roles, r)
+	return u
+}
+
 func (u *User) AddPrivilege(p *Privilege) *User {
 	if u.Privileges == nil {
 		u.Privileges = &[]*Privilege{}
@@ -68,62 +208,3 @@ func (u *User) AddPrivilege(p *Privilege) *User {
 	*u.Privileges = append(*u.Privileges, p)
 	return u
 }
-
-func HashPassword(password string) *string {
-	b := make([]byte, 16) // salt length
-	_, err := rand.Read(b)
-	if err != nil {
-		panic(err)
-	}
-	iterations := uint32(1)
-	memory := uint32(64 * 1024)
-	parallelism := uint8(4)
-	hash := argon2.IDKey([]byte(password), b, iterations, memory, parallelism, 32)
-	b64Salt := base64.StdEncoding.EncodeToString(b)
-	b64Hash := base64.StdEncoding.EncodeToString(hash)
-	encodedHash := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, memory, iterations, parallelism, b64Salt, b64Hash)
-	return &encodedHash
-}
-
-func VerifyPassword(password string, encodedHash string) bool {
-	parts := strings.Split(encodedHash, "$")
-	if len(parts) != 6 {
-		panic("invalid encodedHash")
-	}
-	if parts[1] != "argon2id" {
-		panic(fmt.Errorf("invalid encodedHash algorithm '%s'", parts[1]))
-	}
-	if parts[2] != fmt.Sprintf("v=%d", argon2.Version) {
-		panic(fmt.Errorf("invalid encodedHash version '%s' != 'v=%v'", parts[2], argon2.Version))
-	}
-	iterations := uint32(1)
-	memory := uint32(64 * 1024)
-	parallelism := uint8(4)
-	for _, pp := range strings.Split(parts[3], ",") {
-		ps := strings.Split(pp, "=")
-		if len(ps) == 2 {
-			v, err := strconv.ParseInt(ps[1], 10, 64)
-			if err != nil {
-				panic(fmt.Errorf("invalid encodedHash '%s'", pp))
-			}
-			switch ps[0] {
-			case "m":
-				memory = uint32(v)
-			case "t":
-				iterations = uint32(v)
-			case "p":
-				parallelism = uint8(v)
-			}
-		}
-	}
-	salt, err := base64.StdEncoding.DecodeString(parts[4])
-	if err != nil {
-		return false
-	}
-	hash, err := base64.StdEncoding.DecodeString(parts[5])
-	if err != nil {
-		return false
-	}
-	comparisonHash := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, uint32(len(hash)))
-	return parts[5] == base64.StdEncoding.EncodeToString(comparisonHash)
-}